@@ -0,0 +1,275 @@
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/diff"
+	"github.com/stacklok/trusty-sdk-go/pkg/githubapi"
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+// contentGetter fetches files from GitHub repositories.
+type contentGetter interface {
+	GetFileContent(ctx context.Context, owner, repo, path, ref string) (string, error)
+}
+
+// changeOutput is the JSON output of a dependency changed between two
+// revisions.
+type changeOutput struct {
+	Change     string   `json:"change"`
+	Ecosystem  string   `json:"ecosystem"`
+	Name       string   `json:"name"`
+	OldVersion string   `json:"old_version,omitempty"`
+	NewVersion string   `json:"new_version,omitempty"`
+	OldScore   *float64 `json:"old_score,omitempty"`
+	NewScore   *float64 `json:"new_score,omitempty"`
+	ScoreDelta *float64 `json:"score_delta,omitempty"`
+	Malicious  bool     `json:"malicious"`
+	Path       string   `json:"path,omitempty"`
+	Line       int      `json:"line,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func (a *app) runDiff(ctx context.Context, args []string) int {
+	fs, opts := a.flagSet("diff", "<old> <new>")
+	minScore := fs.Float64("min-score", result.DefaultMinScore, "flag new packages with a Trusty score below this value")
+	repo := fs.String("repo", "", "compare two git refs of this GitHub repository (owner/repo) instead of two files")
+	var paths stringList
+	fs.Var(&paths, "path", "with --repo, path of a manifest to compare (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprint(a.stderr, "Usage: trusty diff [flags] <old-file> <new-file>\n"+
+			"       trusty diff [flags] --repo <owner/repo> --path <manifest> <base-ref> <head-ref>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if code, ok := a.parse(fs, opts, args, 2); !ok {
+		return code
+	}
+	if fs.NArg() != 2 || (*repo != "" && len(paths) == 0) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var oldDeps, newDeps []parser.Dependency
+	var err error
+	if *repo != "" {
+		oldDeps, newDeps, err = a.readRefs(ctx, *repo, paths, fs.Arg(0), fs.Arg(1))
+	} else {
+		oldDeps, newDeps, err = readFiles(fs.Arg(0), fs.Arg(1))
+	}
+	if err != nil {
+		a.errorf("%v", err)
+		return exitAPIError
+	}
+
+	deltas := diff.Fetch(ctx, a.newV2(opts.endpoint), diff.Dependencies(oldDeps, newDeps), result.FetchOptions{})
+	code := exitOK
+	for _, f := range result.Findings(diff.Packages(deltas), result.Thresholds{MinScore: *minScore}) {
+		if f.Severity == result.SeverityError {
+			code = exitFailed
+		}
+	}
+	for _, d := range deltas {
+		for _, p := range []*result.Package{d.Before, d.After} {
+			if p != nil && p.Err != nil {
+				a.errorf("%v", p.Err)
+				code = exitAPIError
+			}
+		}
+	}
+
+	if opts.format == report.FormatJSON {
+		err = writeJSON(a.stdout, changesOutput(deltas))
+	} else {
+		err = writeChanges(a.stdout, deltas)
+	}
+	if err != nil {
+		a.errorf("writing output: %v", err)
+		return exitAPIError
+	}
+	return code
+}
+
+// readFiles reads the dependencies of two manifests.
+func readFiles(oldPath, newPath string) ([]parser.Dependency, []parser.Dependency, error) {
+	oldDeps, errs := readDependencies([]string{oldPath}, nil)
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	newDeps, errs := readDependencies([]string{newPath}, nil)
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return oldDeps, newDeps, nil
+}
+
+// readRefs reads the dependencies of the manifests at two refs of a GitHub
+// repository. A manifest missing at one of the refs has no dependencies
+// there. The repository is on github.com, or on the GitHub Enterprise
+// Server of GITHUB_API_URL, and read with GITHUB_TOKEN.
+func (a *app) readRefs(
+	ctx context.Context, repo string, paths []string, oldRef, newRef string,
+) ([]parser.Dependency, []parser.Dependency, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" {
+		return nil, nil, fmt.Errorf("invalid repository %q, expected owner/repo", repo)
+	}
+	client, err := a.newGitHub(githubapi.Options{
+		BaseURL: gitHubBaseURL(os.Getenv("GITHUB_API_URL")),
+		Token:   os.Getenv("GITHUB_TOKEN"),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	read := func(ref string) ([]parser.Dependency, error) {
+		var deps []parser.Dependency
+		var errs []error
+		missing := 0
+		for _, p := range paths {
+			content, err := client.GetFileContent(ctx, owner, name, p, ref)
+			if err != nil {
+				if githubapi.IsNotFound(err) {
+					missing++
+					continue
+				}
+				errs = append(errs, fmt.Errorf("fetching %s at %s: %w", p, ref, err))
+				continue
+			}
+			fileDeps, _, err := parser.ParseDetailed(p, content, parser.Options{})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parsing %s at %s: %w", p, ref, err))
+				continue
+			}
+			deps = append(deps, fileDeps...)
+		}
+		if missing == len(paths) {
+			errs = append(errs, fmt.Errorf("none of the manifests exist at %s", ref))
+		}
+		return deps, errors.Join(errs...)
+	}
+
+	oldDeps, err := read(oldRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	newDeps, err := read(newRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	return oldDeps, newDeps, nil
+}
+
+// gitHubBaseURL returns the base URL of the GitHub API set in the
+// environment of GitHub Actions, or the empty string for github.com.
+func gitHubBaseURL(apiURL string) string {
+	if strings.TrimSuffix(apiURL, "/") == "https://api.github.com" {
+		return ""
+	}
+	return apiURL
+}
+
+func changesOutput(deltas []diff.Delta) []changeOutput {
+	res := make([]changeOutput, len(deltas))
+	for i := range deltas {
+		d := &deltas[i]
+		dep := d.Dependency()
+		res[i] = changeOutput{
+			Change:     string(d.Kind),
+			Ecosystem:  d.Ecosystem,
+			Name:       d.Name,
+			OldVersion: d.OldVersion(),
+			NewVersion: d.NewVersion(),
+			ScoreDelta: d.ScoreDelta(),
+			Path:       dep.Location.Path,
+			Line:       dep.Location.Line,
+		}
+		if d.Before != nil {
+			res[i].OldScore = d.Before.Score()
+		}
+		if d.After != nil {
+			res[i].NewScore = d.After.Score()
+			res[i].Malicious = d.After.IsMalicious()
+		}
+		var errs []string
+		for _, p := range []*result.Package{d.Before, d.After} {
+			if p != nil && p.Err != nil {
+				errs = append(errs, p.Err.Error())
+			}
+		}
+		res[i].Error = strings.Join(errs, "; ")
+	}
+	return res
+}
+
+func writeChanges(w io.Writer, deltas []diff.Delta) error {
+	if len(deltas) == 0 {
+		_, err := fmt.Fprintln(w, "No dependencies changed")
+		return err
+	}
+	t := newTable(w, "CHANGE", "PACKAGE", "OLD", "NEW", "SCORE", "DELTA", "MALICIOUS", "LOCATION")
+	better, worse := 0, 0
+	for i := range deltas {
+		d := &deltas[i]
+		score := "-"
+		switch {
+		case d.Before != nil && d.After != nil:
+			score = report.FormatScore(d.Before.Score()) + " -> " + report.FormatScore(d.After.Score())
+		case d.After != nil:
+			score = report.FormatScore(d.After.Score())
+		case d.Before != nil:
+			score = report.FormatScore(d.Before.Score())
+		}
+		delta := "-"
+		if sd := d.ScoreDelta(); sd != nil {
+			delta = strconv.FormatFloat(*sd, 'f', 1, 64)
+			switch {
+			case *sd > 0:
+				delta = "+" + delta
+				better++
+			case *sd < 0:
+				worse++
+			}
+		}
+		malicious := "-"
+		if d.After != nil && d.After.Err == nil {
+			malicious = yesNo(d.After.IsMalicious())
+		}
+		t.row(d.Kind, d.Ecosystem+"/"+d.Name, dash(d.OldVersion()), dash(d.NewVersion()),
+			score, delta, malicious, dash(report.Location(d.Dependency())))
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d dependencies changed, %d with a better Trusty score, %d with a worse one\n",
+		len(deltas), better, worse)
+	return err
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
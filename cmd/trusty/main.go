@@ -0,0 +1,276 @@
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command trusty queries the Trusty API about packages, and checks the
+// dependencies of projects against Trusty data and policies.
+//
+// Packages are identified by their package URL, eg pkg:npm/lodash@4.17.21.
+// Run trusty help for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/githubapi"
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+	v1client "github.com/stacklok/trusty-sdk-go/pkg/v1/client"
+	v2client "github.com/stacklok/trusty-sdk-go/pkg/v2/client"
+)
+
+// Exit codes of the commands.
+const (
+	// exitOK means the command ran and found nothing to report.
+	exitOK = 0
+	// exitFailed means the command ran and found problems, eg packages
+	// violating the policy.
+	exitFailed = 1
+	// exitUsage means the command line is invalid, eg none of the paths
+	// it names could be read.
+	exitUsage = 2
+	// exitAPIError means the Trusty API or the inputs could not be read.
+	// It takes precedence over exitFailed, as the results are incomplete.
+	exitAPIError = 3
+	// exitNothingToScan means no dependencies were found to check.
+	exitNothingToScan = 4
+)
+
+// version is the version of the command, set at build time with
+// -ldflags "-X main.version=v1.2.3".
+var version = ""
+
+// app holds what the commands need, so that tests can replace it.
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	newV1     func(endpoint string) v1client.Trusty
+	newV2     func(endpoint string) v2client.Trusty
+	newGitHub func(opts githubapi.Options) (contentGetter, error)
+}
+
+// command is a subcommand of the CLI.
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(a *app, ctx context.Context, args []string) int
+}
+
+// commands are the subcommands of the CLI, in the order of the help.
+var commands = []command{
+	{"summary", "<purl>...", "Show the Trusty summary of packages", (*app).runSummary},
+	{"pkg", "<purl>...", "Show the metadata Trusty has about packages", (*app).runPackage},
+	{"alternatives", "<purl>...", "Show alternatives to packages", (*app).runAlternatives},
+	{"provenance", "<purl>...", "Show the provenance of packages", (*app).runProvenance},
+	{"report", "<purl>...", "Show the v1 report of packages", (*app).runReport},
+	{"scan", "[path]...", "Check the dependencies of projects", (*app).runScan},
+	{"diff", "<old> <new>", "Show how the dependencies changed between two manifests", (*app).runDiff},
+	{"policy", "check <purl>...", "Check packages against a policy", (*app).runPolicy},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := newApp().run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+func newApp() *app {
+	return &app{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		newV1: func(endpoint string) v1client.Trusty {
+			if endpoint == "" {
+				return v1client.New()
+			}
+			opts := v1client.DefaultOptions
+			opts.BaseURL = endpoint
+			return v1client.NewWithOptions(opts)
+		},
+		newV2: func(endpoint string) v2client.Trusty {
+			if endpoint == "" {
+				return v2client.New()
+			}
+			opts := v2client.DefaultOptions
+			opts.BaseURL = endpoint
+			return v2client.NewWithOptions(opts)
+		},
+		newGitHub: func(opts githubapi.Options) (contentGetter, error) {
+			return githubapi.NewGitHubClientWithOptions(opts)
+		},
+	}
+}
+
+// run runs the command line and returns the exit code.
+func (a *app) run(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("trusty", flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	showVersion := fs.Bool("version", false, "print the version and exit")
+	fs.Usage = a.usage
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err)
+	}
+
+	if *showVersion {
+		fmt.Fprintln(a.stdout, "trusty", versionString())
+		return exitOK
+	}
+	if fs.NArg() == 0 {
+		a.usage()
+		return exitUsage
+	}
+
+	name, rest := fs.Arg(0), fs.Args()[1:]
+	switch name {
+	case "help":
+		a.usage()
+		return exitOK
+	case "version":
+		fmt.Fprintln(a.stdout, "trusty", versionString())
+		return exitOK
+	}
+	for _, c := range commands {
+		if c.name == name {
+			return c.run(a, ctx, rest)
+		}
+	}
+	fmt.Fprintf(a.stderr, "trusty: unknown command %q\n\n", name)
+	a.usage()
+	return exitUsage
+}
+
+func (a *app) usage() {
+	fmt.Fprint(a.stderr, "Usage: trusty [--version] <command> [flags] [args]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(a.stderr, "  %-13s %s\n", c.name, c.help)
+	}
+	fmt.Fprint(a.stderr, "\nRun trusty <command> -h for the flags of a command.\n")
+}
+
+// options are the flags shared by all the commands.
+type options struct {
+	endpoint string
+	output   string
+	color    string
+
+	// format is the parsed output format.
+	format report.Format
+	// formats are the output formats supported by the command.
+	formats []report.Format
+}
+
+// flagSet returns the flag set of a command, with the shared flags. The
+// command supports the table and JSON output formats.
+func (a *app) flagSet(name, usage string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet("trusty "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	opts := &options{formats: []report.Format{report.FormatTable, report.FormatJSON}}
+	fs.StringVar(&opts.endpoint, "endpoint", "", "base URL of the Trusty API (default from TRUSTY_ENDPOINT)")
+	fs.StringVar(&opts.output, "output", "table", "output format: table or json")
+	fs.StringVar(&opts.output, "o", "table", "shorthand for --output")
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: trusty %s [flags] %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs, opts
+}
+
+// reportFlagSet returns the flag set of a command printing a report, which
+// supports all the formats of the report package.
+func (a *app) reportFlagSet(name, usage string) (*flag.FlagSet, *options) {
+	fs, opts := a.flagSet(name, usage)
+	opts.formats = report.Formats
+	fs.Lookup("output").Usage = "output format: table, json, markdown or html"
+	fs.StringVar(&opts.color, "color", colorAuto, "colour the table output: auto, always or never")
+	return fs, opts
+}
+
+// parse parses the arguments of a command, checking the output format
+// and that there are at least minArgs positional arguments.
+func (a *app) parse(fs *flag.FlagSet, opts *options, args []string, minArgs int) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		return usageCode(err), false
+	}
+	format, err := report.ParseFormat(opts.output)
+	if err != nil || !slices.Contains(opts.formats, format) {
+		fmt.Fprintf(a.stderr, "%s: invalid output format %q\n", fs.Name(), opts.output)
+		return exitUsage, false
+	}
+	opts.format = format
+	switch opts.color {
+	case "", colorAuto, colorAlways, colorNever:
+	default:
+		fmt.Fprintf(a.stderr, "%s: invalid colour mode %q\n", fs.Name(), opts.color)
+		return exitUsage, false
+	}
+	if fs.NArg() < minArgs {
+		fs.Usage()
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// parsePurls converts package URL arguments to dependencies.
+func (a *app) parsePurls(name string, purls []string) ([]parser.Dependency, bool) {
+	var deps []parser.Dependency
+	var errs []string
+	for _, purl := range purls {
+		dep, err := parser.DependencyFromPurl(purl)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		deps = append(deps, dep)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(a.stderr, "trusty %s: %s\n", name, strings.Join(errs, "\n"))
+		return nil, false
+	}
+	return deps, true
+}
+
+func (a *app) errorf(format string, args ...any) {
+	fmt.Fprintf(a.stderr, "trusty: "+format+"\n", args...)
+}
+
+// usageCode returns the exit code of a flag parsing error. Asking for the
+// help is not an error.
+func usageCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	return exitUsage
+}
+
+// versionString returns the version set at build time, or the version of
+// the module when installed with go install.
+func versionString() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
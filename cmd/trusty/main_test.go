@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/githubapi"
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+	v1client "github.com/stacklok/trusty-sdk-go/pkg/v1/client"
+	v2client "github.com/stacklok/trusty-sdk-go/pkg/v2/client"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+type fakeTrusty struct {
+	summaries map[string]*v2types.PackageSummaryAnnotation
+}
+
+func (f *fakeTrusty) Summary(_ context.Context, dep *v2types.Dependency) (*v2types.PackageSummaryAnnotation, error) {
+	s, ok := f.summaries[dep.PackageName]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return s, nil
+}
+
+func (*fakeTrusty) PackageMetadata(context.Context, *v2types.Dependency) (*v2types.TrustyPackageData, error) {
+	return &v2types.TrustyPackageData{}, nil
+}
+
+func (*fakeTrusty) Alternatives(context.Context, *v2types.Dependency) (*v2types.PackageAlternatives, error) {
+	return &v2types.PackageAlternatives{}, nil
+}
+
+func (*fakeTrusty) Provenance(context.Context, *v2types.Dependency) (*v2types.Provenance, error) {
+	return &v2types.Provenance{}, nil
+}
+
+type fakeGitHub map[string]string
+
+func (f fakeGitHub) GetFileContent(_ context.Context, owner, repo, path, ref string) (string, error) {
+	content, ok := f[owner+"/"+repo+"/"+path+"@"+ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return content, nil
+}
+
+func newTestApp(client v2client.Trusty) (*app, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &app{
+		stdout:    &stdout,
+		stderr:    &stderr,
+		newV1:     func(string) v1client.Trusty { panic("unexpected v1 call") },
+		newV2:     func(string) v2client.Trusty { return client },
+		newGitHub: func(githubapi.Options) (contentGetter, error) { return fakeGitHub{}, nil },
+	}, &stdout, &stderr
+}
+
+func testClient() *fakeTrusty {
+	good := 8.2
+	low := 1.5
+	return &fakeTrusty{summaries: map[string]*v2types.PackageSummaryAnnotation{
+		"lodash":  {Score: &good},
+		"leftpad": {Score: &low},
+		"evil":    {Description: v2types.SummaryDescription{Malicious: true}},
+	}}
+}
+
+func TestRunUsage(t *testing.T) {
+	t.Parallel()
+
+	a, stdout, stderr := newTestApp(testClient())
+	require.Equal(t, exitUsage, a.run(context.Background(), nil))
+	require.Contains(t, stderr.String(), "Usage: trusty")
+
+	require.Equal(t, exitUsage, a.run(context.Background(), []string{"nope"}))
+	require.Contains(t, stderr.String(), `unknown command "nope"`)
+
+	require.Equal(t, exitOK, a.run(context.Background(), []string{"--version"}))
+	require.Contains(t, stdout.String(), "trusty ")
+
+	require.Equal(t, exitUsage, a.run(context.Background(), []string{"summary"}))
+	require.Equal(t, exitUsage, a.run(context.Background(), []string{"summary", "-o", "xml", "pkg:npm/lodash"}))
+	require.Equal(t, exitUsage, a.run(context.Background(), []string{"summary", "lodash"}))
+	require.Equal(t, exitUsage, a.run(context.Background(), []string{"summary", "-o", "html", "pkg:npm/lodash"}))
+}
+
+func TestRunSummary(t *testing.T) {
+	t.Parallel()
+
+	a, stdout, _ := newTestApp(testClient())
+	code := a.run(context.Background(), []string{"summary", "pkg:npm/lodash@4.17.21", "pkg:npm/evil"})
+	require.Equal(t, exitOK, code)
+	require.Contains(t, stdout.String(), "lodash@4.17.21  8.2")
+	require.Contains(t, stdout.String(), "evil            -      yes")
+
+	a, stdout, stderr := newTestApp(testClient())
+	code = a.run(context.Background(), []string{"summary", "-o", "json", "pkg:npm/lodash", "pkg:npm/missing"})
+	require.Equal(t, exitAPIError, code)
+	require.Contains(t, stderr.String(), "not found")
+
+	var entries []entry
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	require.Len(t, entries, 2)
+	require.Equal(t, "pkg:npm/lodash", entries[0].Purl)
+	require.NotNil(t, entries[0].Data)
+	require.Equal(t, "not found", entries[1].Error)
+}
+
+// cliEnvVar makes the test binary run the command instead of the tests, so
+// that tests can check what the whole process writes.
+const cliEnvVar = "TRUSTY_TEST_RUN_CLI"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(cliEnvVar) == "1" {
+		main()
+	}
+	os.Exit(m.Run())
+}
+
+func TestRunReport(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/report", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("package_name") != "lodash" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"package_name": "lodash", "package_type": "npm",
+			"summary": {"score": 8.2},
+			"package_data": {"status": "complete", "archived": false}
+		}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	// The real v1 client talks to the fake API, and the output is read
+	// from the standard output of the process.
+	cmd := exec.Command(os.Args[0], "report", "-o", "json", "--endpoint", srv.URL, "pkg:npm/lodash@4.17.21")
+	cmd.Env = append(os.Environ(), cliEnvVar+"=1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	require.NoError(t, cmd.Run(), stderr.String())
+
+	var entries []entry
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &entries), stdout.String())
+	require.Len(t, entries, 1)
+	require.Equal(t, "pkg:npm/lodash@4.17.21", entries[0].Purl)
+	require.Empty(t, entries[0].Error)
+}
+
+func TestRunScan(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	manifest := `{"dependencies": {"lodash": "4.17.21", "leftpad": "1.0.0"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(manifest), 0o600))
+
+	a, stdout, _ := newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(), []string{"scan", dir}))
+	require.Contains(t, stdout.String(), "leftpad")
+	require.Contains(t, stdout.String(), "2 packages checked, 1 finding (1 warning)")
+
+	a, stdout, _ = newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(), []string{"scan", "-o", "markdown", dir}))
+	require.Contains(t, stdout.String(), "## Trusty report")
+
+	a, stdout, _ = newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(), []string{"scan", "-o", "json", dir}))
+	var doc report.Document
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Equal(t, report.SchemaVersion, doc.SchemaVersion)
+	require.Len(t, doc.Packages, 2)
+
+	manifest = `{"dependencies": {"evil": "1.0.0"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(manifest), 0o600))
+	a, _, _ = newTestApp(testClient())
+	require.Equal(t, exitFailed, a.run(context.Background(), []string{"scan", dir}))
+}
+
+func TestRunScanExitCodes(t *testing.T) {
+	t.Parallel()
+
+	empty := t.TempDir()
+	a, _, stderr := newTestApp(testClient())
+	require.Equal(t, exitNothingToScan, a.run(context.Background(), []string{"scan", empty}))
+	require.Contains(t, stderr.String(), "no dependencies found")
+
+	dir := t.TempDir()
+	manifest := `{"dependencies": {"lodash": "4.17.21", "leftpad": "1.0.0"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(manifest), 0o600))
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("min_score: 5\n"), 0o600))
+
+	a, stdout, _ := newTestApp(testClient())
+	require.Equal(t, exitFailed, a.run(context.Background(), []string{"scan", "--policy", policyFile, dir}))
+	require.Contains(t, stdout.String(), "leftpad")
+
+	ignoreFile := filepath.Join(t.TempDir(), ".trustyignore")
+	ignore := "suppressions:\n  - purl: pkg:npm/leftpad\n    justification: Vendored\n    expires: 2999-01-01\n"
+	require.NoError(t, os.WriteFile(ignoreFile, []byte(ignore), 0o600))
+	a, _, _ = newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(),
+		[]string{"scan", "--policy", policyFile, "--ignore", ignoreFile, dir}))
+
+	manifest = `{"dependencies": {"lodash": "4.17.21", "unknown": "1.0.0"}}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(manifest), 0o600))
+	a, _, stderr = newTestApp(testClient())
+	require.Equal(t, exitAPIError, a.run(context.Background(), []string{"scan", dir}))
+	require.Contains(t, stderr.String(), "unknown")
+}
+
+func TestRunScanBrokenManifest(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"),
+		[]byte(`{"dependencies": {"lodash": "4.17.21", "leftpad": "1.0.0"}}`), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "svc"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "svc", "Cargo.toml"), []byte("[dependencies\n"), 0o600))
+
+	a, stdout, stderr := newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(), []string{"scan", "-o", "json", dir}))
+	require.Contains(t, stderr.String(), "warning: scanning "+dir+": parsing svc/Cargo.toml")
+	var doc report.Document
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Packages, 2)
+
+	a, _, stderr = newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(), []string{"scan", filepath.Join(dir, "missing"), dir}))
+	require.Contains(t, stderr.String(), "warning: ")
+
+	a, _, stderr = newTestApp(testClient())
+	require.Equal(t, exitUsage, a.run(context.Background(), []string{"scan", filepath.Join(dir, "svc", "Cargo.toml")}))
+	require.Contains(t, stderr.String(), "no dependencies could be read")
+}
+
+func TestRunScanIgnoreFilePerPath(t *testing.T) {
+	t.Parallel()
+
+	manifest := `{"dependencies": {"lodash": "4.17.21", "leftpad": "1.0.0"}}`
+	svc, other := t.TempDir(), t.TempDir()
+	for _, dir := range []string{svc, other} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte(manifest), 0o600))
+	}
+	// The paths of the suppressions are relative to the scanned directory
+	ignore := "suppressions:\n  - purl: pkg:npm/leftpad\n    path: package.json\n" +
+		"    justification: Vendored\n    expires: 2999-01-01\n"
+	require.NoError(t, os.WriteFile(filepath.Join(svc, ".trustyignore"), []byte(ignore), 0o600))
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("min_score: 5\n"), 0o600))
+
+	a, _, stderr := newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(), []string{"scan", "--policy", policyFile, svc}), stderr.String())
+	a, _, _ = newTestApp(testClient())
+	require.Equal(t, exitOK, a.run(context.Background(),
+		[]string{"scan", "--policy", policyFile, filepath.Join(svc, "package.json")}))
+
+	a, stdout, _ := newTestApp(testClient())
+	require.Equal(t, exitFailed, a.run(context.Background(), []string{"scan", "--policy", policyFile, "-o", "json", svc, other}))
+	var doc report.Document
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	require.Len(t, doc.Findings, 1)
+	require.Equal(t, filepath.ToSlash(filepath.Join(other, "package.json")), doc.Findings[0].Path)
+}
+
+func TestRunDiff(t *testing.T) {
+	t.Parallel()
+
+	// The parser recognizes manifests by their file name
+	oldManifest := filepath.Join(t.TempDir(), "package.json")
+	newManifest := filepath.Join(t.TempDir(), "package.json")
+	require.NoError(t, os.WriteFile(oldManifest, []byte(`{"dependencies": {"lodash": "4.17.21", "leftpad": "1.0.0"}}`), 0o600))
+	require.NoError(t, os.WriteFile(newManifest, []byte(`{"dependencies": {"lodash": "4.17.21", "evil": "1.0.0"}}`), 0o600))
+
+	a, stdout, _ := newTestApp(testClient())
+	require.Equal(t, exitFailed, a.run(context.Background(), []string{"diff", oldManifest, newManifest}))
+	require.Contains(t, stdout.String(), "added    npm/evil")
+	require.Contains(t, stdout.String(), "removed  npm/leftpad")
+
+	a, stdout, _ = newTestApp(testClient())
+	a.newGitHub = func(githubapi.Options) (contentGetter, error) {
+		return fakeGitHub{
+			"acme/web/package.json@main": `{"dependencies": {"lodash": "4.17.20"}}`,
+			"acme/web/package.json@pr":   `{"dependencies": {"lodash": "4.17.21"}}`,
+		}, nil
+	}
+	code := a.run(context.Background(), []string{"diff", "-o", "json", "--repo", "acme/web", "--path", "package.json", "main", "pr"})
+	require.Equal(t, exitOK, code)
+	var changes []changeOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &changes))
+	require.Len(t, changes, 1)
+	require.Equal(t, "upgraded", changes[0].Change)
+	require.Equal(t, "4.17.20", changes[0].OldVersion)
+
+	a, _, _ = newTestApp(testClient())
+	require.Equal(t, exitUsage, a.run(context.Background(), []string{"diff", "--repo", "acme/web", "main", "pr"}))
+}
+
+func TestGitHubBaseURL(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", gitHubBaseURL(""))
+	require.Equal(t, "", gitHubBaseURL("https://api.github.com"))
+	require.Equal(t, "https://github.example.com/api/v3", gitHubBaseURL("https://github.example.com/api/v3"))
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+// Colour modes of the --color flag.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// entry is the JSON output of a query on a package.
+type entry struct {
+	Purl  string `json:"purl"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeReport prints the packages checked and the findings raised on them
+// in the output format of the command.
+func (a *app) writeReport(opts *options, packages []result.Package, findings []result.Finding) error {
+	r := &report.Report{
+		ToolVersion: versionString(),
+		Packages:    packages,
+		Findings:    findings,
+	}
+	if opts.format == report.FormatHTML {
+		r.GeneratedAt = time.Now()
+	}
+	return r.Write(a.stdout, opts.format, report.Options{Color: a.useColor(opts.color)})
+}
+
+// useColor returns whether to colour the output in the given mode. In
+// auto mode, the output is coloured when it is a terminal and NO_COLOR is
+// not set.
+func (a *app) useColor(mode string) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := a.stdout.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// table writes aligned columns.
+type table struct {
+	tw *tabwriter.Writer
+}
+
+func newTable(w io.Writer, headers ...any) *table {
+	t := &table{tw: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}
+	t.row(headers...)
+	return t
+}
+
+func (t *table) row(cells ...any) {
+	for i, c := range cells {
+		if i > 0 {
+			fmt.Fprint(t.tw, "\t")
+		}
+		fmt.Fprint(t.tw, c)
+	}
+	fmt.Fprintln(t.tw)
+}
+
+func (t *table) flush() error {
+	return t.tw.Flush()
+}
+
+// fields writes aligned name and value pairs, skipping empty values.
+func fields(w io.Writer, pairs ...any) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		v := formatValue(pairs[i+1])
+		if v == "" {
+			continue
+		}
+		fmt.Fprintf(tw, "%s:\t%s\n", pairs[i], v)
+	}
+	return tw.Flush()
+}
+
+// formatValue formats a value of the API types, dereferencing pointers.
+// Nil pointers format as the empty string.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	case *bool:
+		if v == nil {
+			return ""
+		}
+		return yesNo(*v)
+	case bool:
+		return yesNo(v)
+	case *float64:
+		if v == nil {
+			return ""
+		}
+		return report.FormatScore(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
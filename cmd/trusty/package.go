@@ -0,0 +1,272 @@
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v1types "github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+// queryResult is the answer of the API about a package.
+type queryResult[T any] struct {
+	dep  parser.Dependency
+	data *T
+	err  error
+}
+
+// parsePurlCommand parses the flags and package URL arguments of the
+// commands querying packages.
+func (a *app) parsePurlCommand(name string, args []string) (*options, []parser.Dependency, int) {
+	fs, opts := a.flagSet(name, "<purl>...")
+	if code, ok := a.parse(fs, opts, args, 1); !ok {
+		return nil, nil, code
+	}
+	deps, ok := a.parsePurls(name, fs.Args())
+	if !ok {
+		return nil, nil, exitUsage
+	}
+	return opts, deps, exitOK
+}
+
+// query fetches the data of each package and prints it. Packages that
+// could not be fetched are reported on stderr, and make the command exit
+// with exitAPIError.
+func query[T any](
+	a *app, ctx context.Context, opts *options, deps []parser.Dependency,
+	fetch func(context.Context, *parser.Dependency) (*T, error),
+	text func(io.Writer, []queryResult[T]) error,
+) int {
+	results := make([]queryResult[T], len(deps))
+	code := exitOK
+	for i := range deps {
+		results[i].dep = deps[i]
+		results[i].data, results[i].err = fetch(ctx, &deps[i])
+		if results[i].err != nil {
+			a.errorf("%s: %v", deps[i].Purl(), results[i].err)
+			code = exitAPIError
+		}
+	}
+
+	var err error
+	if opts.format == report.FormatJSON {
+		entries := make([]entry, len(results))
+		for i, r := range results {
+			entries[i] = entry{Purl: r.dep.Purl()}
+			if r.err != nil {
+				entries[i].Error = r.err.Error()
+			} else {
+				entries[i].Data = r.data
+			}
+		}
+		err = writeJSON(a.stdout, entries)
+	} else {
+		var ok []queryResult[T]
+		for _, r := range results {
+			if r.err == nil {
+				ok = append(ok, r)
+			}
+		}
+		err = text(a.stdout, ok)
+	}
+	if err != nil {
+		a.errorf("writing output: %v", err)
+		return exitAPIError
+	}
+	return code
+}
+
+func (a *app) runSummary(ctx context.Context, args []string) int {
+	opts, deps, code := a.parsePurlCommand("summary", args)
+	if deps == nil {
+		return code
+	}
+	client := a.newV2(opts.endpoint)
+	return query(a, ctx, opts, deps,
+		func(ctx context.Context, dep *parser.Dependency) (*v2types.PackageSummaryAnnotation, error) {
+			return client.Summary(ctx, dep.ToV2())
+		},
+		func(w io.Writer, results []queryResult[v2types.PackageSummaryAnnotation]) error {
+			t := newTable(w, "PACKAGE", "SCORE", "MALICIOUS", "TYPOSQUATTING", "PROVENANCE")
+			for _, r := range results {
+				pkg := result.Package{Dependency: r.dep, Summary: r.data}
+				provenance := string(pkg.ProvenanceType())
+				if provenance == "" {
+					provenance = "-"
+				}
+				t.row(pkg.Dependency.ID(), report.FormatScore(pkg.Score()), yesNo(pkg.IsMalicious()),
+					report.FormatScore(pkg.Typosquatting()), provenance)
+			}
+			return t.flush()
+		})
+}
+
+func (a *app) runPackage(ctx context.Context, args []string) int {
+	opts, deps, code := a.parsePurlCommand("pkg", args)
+	if deps == nil {
+		return code
+	}
+	client := a.newV2(opts.endpoint)
+	return query(a, ctx, opts, deps,
+		func(ctx context.Context, dep *parser.Dependency) (*v2types.TrustyPackageData, error) {
+			return client.PackageMetadata(ctx, dep.ToV2())
+		},
+		func(w io.Writer, results []queryResult[v2types.TrustyPackageData]) error {
+			for i, r := range results {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				m := r.data
+				var malicious string
+				if m.Malicious != nil {
+					malicious = m.Malicious.Summary
+				}
+				err := fields(w,
+					"Package", r.dep.ID(),
+					"Type", string(m.Type),
+					"Status", (*string)(m.Status),
+					"Description", m.Description,
+					"Author", m.Author,
+					"Repository", m.RepositoryName,
+					"Home page", m.HomePage,
+					"Stars", m.StarGazersCount,
+					"Forks", m.ForksCount,
+					"Contributors", m.ContributorCount,
+					"Archived", m.Archived,
+					"Deprecated", m.IsDeprecated,
+					"Malicious", malicious,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (a *app) runAlternatives(ctx context.Context, args []string) int {
+	opts, deps, code := a.parsePurlCommand("alternatives", args)
+	if deps == nil {
+		return code
+	}
+	client := a.newV2(opts.endpoint)
+	return query(a, ctx, opts, deps,
+		func(ctx context.Context, dep *parser.Dependency) (*v2types.PackageAlternatives, error) {
+			return client.Alternatives(ctx, dep.ToV2())
+		},
+		func(w io.Writer, results []queryResult[v2types.PackageAlternatives]) error {
+			for i, r := range results {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "Alternatives to %s:\n", r.dep.ID())
+				if r.data.Status == v2types.StatusInProgress {
+					fmt.Fprintln(w, "(Trusty is still computing the alternatives, the list may be incomplete)")
+				}
+				t := newTable(w, "PACKAGE", "SCORE", "DESCRIPTION")
+				for _, alt := range r.data.Packages {
+					t.row(alt.PackageName, report.FormatScore(alt.Score), formatValue(alt.RepoDescription))
+				}
+				if err := t.flush(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (a *app) runProvenance(ctx context.Context, args []string) int {
+	opts, deps, code := a.parsePurlCommand("provenance", args)
+	if deps == nil {
+		return code
+	}
+	client := a.newV2(opts.endpoint)
+	return query(a, ctx, opts, deps,
+		func(ctx context.Context, dep *parser.Dependency) (*v2types.Provenance, error) {
+			return client.Provenance(ctx, dep.ToV2())
+		},
+		func(w io.Writer, results []queryResult[v2types.Provenance]) error {
+			for i, r := range results {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				p := r.data
+				err := fields(w,
+					"Package", r.dep.ID(),
+					"Score", report.FormatScore(p.Sore),
+					"Source repository", p.Sigstore.SourceRepo,
+					"Workflow", p.Sigstore.Workflow,
+					"Issuer", p.Sigstore.Issuer,
+					"Token issuer", p.Sigstore.TokenIssuer,
+					"Transparency log", p.Sigstore.Transparency,
+					"Versions", p.Historical.Versions,
+					"Tags", p.Historical.Tags,
+					"Common tags", p.Historical.Common,
+					"Overlap", p.Historical.Overlap,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (a *app) runReport(ctx context.Context, args []string) int {
+	opts, deps, code := a.parsePurlCommand("report", args)
+	if deps == nil {
+		return code
+	}
+	client := a.newV1(opts.endpoint)
+	return query(a, ctx, opts, deps,
+		func(ctx context.Context, dep *parser.Dependency) (*v1types.Reply, error) {
+			v1dep := dep.ToV1()
+			return client.Report(ctx, &v1dep)
+		},
+		func(w io.Writer, results []queryResult[v1types.Reply]) error {
+			for i, r := range results {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				pkg := result.FromReply(r.data)
+				var malicious string
+				if mal := pkg.Malicious(); mal != nil {
+					malicious = "yes"
+					if mal.Summary != "" {
+						malicious = mal.Summary
+					}
+				}
+				err := fields(w,
+					"Package", pkg.Dependency.ID(),
+					"Type", r.data.PackageType,
+					"Score", report.FormatScore(pkg.Score()),
+					"Typosquatting", report.FormatScore(pkg.Typosquatting()),
+					"Archived", pkg.Archived(),
+					"Deprecated", pkg.Deprecated(),
+					"Malicious", malicious,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/policy"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+func (a *app) runPolicy(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprint(a.stderr, "Usage: trusty policy check [flags] <purl>...\n")
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help" || args[0] == "help") {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	fs, opts := a.reportFlagSet("policy check", "<purl>...")
+	policyFile := fs.String("policy", "", "policy file, in YAML or JSON (required)")
+	provenance := fs.Bool("provenance", false, "fetch the provenance of the packages, for rules using it")
+	if code, ok := a.parse(fs, opts, args[1:], 1); !ok {
+		return code
+	}
+	if *policyFile == "" {
+		a.errorf("policy check: --policy is required")
+		return exitUsage
+	}
+	p, err := policy.LoadFile(*policyFile)
+	if err != nil {
+		a.errorf("loading policy: %v", err)
+		return exitUsage
+	}
+	deps, ok := a.parsePurls("policy check", fs.Args())
+	if !ok {
+		return exitUsage
+	}
+
+	packages := result.Fetch(ctx, a.newV2(opts.endpoint), deps, result.FetchOptions{Provenance: *provenance})
+	violations := p.Evaluate(packages)
+
+	code := exitOK
+	if policy.Failed(violations) {
+		code = exitFailed
+	}
+	for i := range packages {
+		if packages[i].Err != nil {
+			a.errorf("%v", packages[i].Err)
+			code = exitAPIError
+		}
+	}
+
+	if err := a.writeReport(opts, packages, toFindings(violations)); err != nil {
+		a.errorf("writing output: %v", err)
+		return exitAPIError
+	}
+	return code
+}
+
+// toFindings converts policy violations to findings, to report them like
+// the findings of the scan command.
+func toFindings(violations []policy.Violation) []result.Finding {
+	res := make([]result.Finding, len(violations))
+	for i, v := range violations {
+		res[i] = result.Finding{Rule: v.Rule, Severity: v.Severity, Package: v.Package, Message: v.Message}
+	}
+	return res
+}
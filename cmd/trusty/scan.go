@@ -0,0 +1,298 @@
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/policy"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+func (a *app) runScan(ctx context.Context, args []string) int {
+	fs, opts := a.reportFlagSet("scan", "[path]...")
+	policyFile := fs.String("policy", "", "policy file, in YAML or JSON, deciding which packages fail the scan")
+	ignoreFile := fs.String("ignore", "",
+		"ignore file of accepted risks (default "+policy.IgnoreFileName+" of each path, if present)")
+	minScore := fs.Float64("min-score", result.DefaultMinScore,
+		"flag packages with a Trusty score below this value, without a policy")
+	maxTypo := fs.Float64("max-typosquatting", 0,
+		"flag packages with a typosquatting risk above this value (0 disables), without a policy")
+	provenance := fs.Bool("provenance", false, "fetch the provenance of the packages")
+	workers := fs.Int("workers", result.DefaultWorkers, "number of packages queried in parallel")
+	var exclude stringList
+	fs.Var(&exclude, "exclude", "glob pattern of files and directories to skip, in addition to the defaults (repeatable)")
+	if code, ok := a.parse(fs, opts, args, 0); !ok {
+		return code
+	}
+
+	var p *policy.Policy
+	if *policyFile != "" {
+		var err error
+		if p, err = policy.LoadFile(*policyFile); err != nil {
+			a.errorf("loading policy: %v", err)
+			return exitUsage
+		}
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	ignores, err := loadIgnores(*ignoreFile, paths)
+	if err != nil {
+		a.errorf("loading ignore file: %v", err)
+		return exitUsage
+	}
+	deps, errs := readDependencies(paths, exclude)
+	for _, err := range errs {
+		a.errorf("warning: %v", err)
+	}
+	if len(deps) == 0 && len(errs) > 0 {
+		a.errorf("scan: no dependencies could be read from %s", strings.Join(paths, ", "))
+		return exitUsage
+	}
+	if len(deps) == 0 {
+		a.errorf("scan: no dependencies found in %s", strings.Join(paths, ", "))
+		return exitNothingToScan
+	}
+
+	packages := result.Fetch(ctx, a.newV2(opts.endpoint), deps, result.FetchOptions{
+		Workers: *workers, Provenance: *provenance,
+	})
+
+	var findings []result.Finding
+	if p != nil {
+		findings = toFindings(p.Evaluate(packages))
+	} else {
+		findings = result.Findings(packages, result.Thresholds{MinScore: *minScore, MaxTyposquatting: *maxTypo})
+	}
+	findings = suppress(findings, ignores)
+	a.warnExpired(ignores)
+
+	code := exitOK
+	for _, f := range findings {
+		if f.Severity == result.SeverityError {
+			code = exitFailed
+		}
+	}
+	for i := range packages {
+		if packages[i].Err != nil {
+			a.errorf("%v", packages[i].Err)
+			code = exitAPIError
+		}
+	}
+
+	if err := a.writeReport(opts, packages, findings); err != nil {
+		a.errorf("writing output: %v", err)
+		return exitAPIError
+	}
+	return code
+}
+
+// scopedIgnore is an ignore file applying to the findings about the
+// dependencies declared under a scanned path.
+type scopedIgnore struct {
+	// path is the path of the ignore file.
+	path string
+	// root is the slash separated directory the file applies to, with the
+	// paths of its suppressions relative to it. It is empty when the file
+	// applies to all the findings, with the paths as reported.
+	root       string
+	suppressor *policy.Suppressor
+}
+
+// loadIgnores loads the ignore file at file, which applies to all the
+// findings. When file is empty, it loads the ignore file of each scanned
+// directory, or of the directory of each scanned file, if there is one.
+// Each applies to the dependencies declared under its directory.
+func loadIgnores(file string, paths []string) ([]scopedIgnore, error) {
+	now := time.Now()
+	if file != "" {
+		ignore, err := policy.LoadIgnoreFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return []scopedIgnore{{path: file, suppressor: ignore.Suppressor(now)}}, nil
+	}
+
+	var res []scopedIgnore
+	seen := map[string]bool{}
+	for _, p := range paths {
+		dir := filepath.Clean(p)
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		file := filepath.Join(dir, policy.IgnoreFileName)
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		ignore, err := policy.LoadIgnoreFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		res = append(res, scopedIgnore{path: file, root: filepath.ToSlash(dir), suppressor: ignore.Suppressor(now)})
+	}
+	return res, nil
+}
+
+// suppress returns the findings not suppressed by the ignore file applying
+// to them: the one of the deepest directory their dependency is declared
+// under. Findings about no package are suppressed by any ignore file.
+func suppress(findings []result.Finding, ignores []scopedIgnore) []result.Finding {
+	var res []result.Finding
+	for _, f := range findings {
+		if f.Package == nil {
+			kept := true
+			for _, ignore := range ignores {
+				kept = len(ignore.suppressor.Findings([]result.Finding{f})) > 0 && kept
+			}
+			if kept {
+				res = append(res, f)
+			}
+			continue
+		}
+
+		var ignore *scopedIgnore
+		var rel string
+		for i := range ignores {
+			r, ok := relativeTo(ignores[i].root, f)
+			if ok && (ignore == nil || len(ignores[i].root) > len(ignore.root)) {
+				ignore, rel = &ignores[i], r
+			}
+		}
+		if ignore == nil {
+			res = append(res, f)
+			continue
+		}
+		scoped := f
+		if ignore.root != "" {
+			pkg := *f.Package
+			pkg.Dependency.Location.Path = rel
+			scoped.Package = &pkg
+		}
+		if len(ignore.suppressor.Findings([]result.Finding{scoped})) > 0 {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// warnExpired reports the expired suppressions of the ignore files.
+func (a *app) warnExpired(ignores []scopedIgnore) {
+	for _, ignore := range ignores {
+		for _, problem := range ignore.suppressor.Problems() {
+			if problem.Kind == policy.ProblemExpired {
+				a.errorf("warning: %s: %s", ignore.path, problem.Message)
+			}
+		}
+	}
+}
+
+// relativeTo returns the path of the manifest declaring the dependency of
+// a finding relative to root, and false if it is not under root. All the
+// findings are under the empty root.
+func relativeTo(root string, f result.Finding) (string, bool) {
+	if root == "" {
+		return "", true
+	}
+	if f.Package.Dependency.Location.Path == "" {
+		return "", false
+	}
+	p := path.Clean(f.Package.Dependency.Location.Path)
+	if root == "." {
+		return p, p != ".." && !strings.HasPrefix(p, "../")
+	}
+	rel, ok := strings.CutPrefix(p, root+"/")
+	return rel, ok
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// readDependencies reads the dependencies of the given files, and of the
+// projects found in the given directories. Files and directories matching
+// the exclude patterns are skipped when scanning directories. Paths and
+// files that cannot be read or parsed are skipped, and returned with the
+// dependencies read from the others, one error per file.
+func readDependencies(paths []string, exclude []string) ([]parser.Dependency, []error) {
+	scanOpts := parser.ScanOptions{}
+	if len(exclude) > 0 {
+		scanOpts.Ignore = append(slices.Clone(parser.DefaultIgnore), exclude...)
+	}
+	var deps []parser.Dependency
+	var errs []error
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if !info.IsDir() {
+			content, err := os.ReadFile(p)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fileDeps, _, err := parser.ParseDetailed(filepath.ToSlash(p), string(content), parser.Options{})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parsing %s: %w", p, err))
+				continue
+			}
+			deps = append(deps, fileDeps...)
+			continue
+		}
+
+		projects, err := parser.Scan(os.DirFS(p), scanOpts)
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				errs = append(errs, fmt.Errorf("scanning %s: %w", p, err))
+			}
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("scanning %s: %w", p, err))
+		}
+		root := filepath.ToSlash(p)
+		for _, project := range projects {
+			for _, dep := range project.Dependencies {
+				if root != "." {
+					dep.Location.Path = path.Join(root, dep.Location.Path)
+				}
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps, errs
+}
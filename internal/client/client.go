@@ -26,9 +26,9 @@ import (
 	"strings"
 	"time"
 
-	packageurl "github.com/package-url/packageurl-go"
 	khttp "sigs.k8s.io/release-utils/http"
 
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
 	v1types "github.com/stacklok/trusty-sdk-go/pkg/v1/types"
 	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
 )
@@ -226,27 +226,21 @@ func (_ *Trusty) PurlToEcosystem(purl string) v1types.Ecosystem {
 	}
 }
 
-// PurlToDependency takes a string with a package url
+// PurlToDependency takes a string with a package url. The dependency is
+// named like parser.DependencyFromPurl names it, eg with the PEP 503
+// normalized name of PyPI packages.
 func (t *Trusty) PurlToDependency(purlString string) (*v1types.Dependency, error) {
-	e := t.PurlToEcosystem(purlString)
-	if e == 0 {
+	if t.PurlToEcosystem(purlString) == 0 {
 		// Ecosystem nil or not supported
 		return nil, fmt.Errorf("ecosystem not supported")
 	}
 
-	purl, err := packageurl.FromString(purlString)
+	dep, err := parser.DependencyFromPurl(purlString)
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse package url: %w", err)
-	}
-	name := purl.Name
-	if purl.Namespace != "" {
-		name = purl.Namespace + "/" + purl.Name
+		return nil, err
 	}
-	return &v1types.Dependency{
-		Ecosystem: e,
-		Name:      name,
-		Version:   purl.Version,
-	}, nil
+	v1dep := dep.ToV1()
+	return &v1dep, nil
 }
 
 // Report returns a dependency report with all the data that Trusty has
@@ -274,7 +268,6 @@ func (t *Trusty) Report(_ context.Context, dep *v1types.Dependency) (*v1types.Re
 		if err := dec.Decode(&r); err != nil {
 			return nil, fmt.Errorf("could not unmarshal response: %w", err)
 		}
-		fmt.Printf("Attempt #%d to fetch package, status: %s", tries, r.PackageData.Status)
 
 		shouldRetry, err := evalRetry(r.PackageData.Status, t.Options)
 		if err != nil {
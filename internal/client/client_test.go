@@ -491,6 +491,16 @@ func TestPurlToDependency(t *testing.T) {
 			expected: &v1types.Dependency{Name: "@react-stately/color", Version: "3.7.0", Ecosystem: v1types.ECOSYSTEM_NPM},
 			mustErr:  false,
 		},
+		{
+			name:    "maven-not-in-v1",
+			purl:    "pkg:maven/org.apache.commons/commons-lang3@3.14.0",
+			mustErr: true,
+		},
+		{
+			name:     "pypi-normalized",
+			purl:     "pkg:pypi/Zope.Interface@6.0",
+			expected: &v1types.Dependency{Name: "zope-interface", Version: "6.0", Ecosystem: v1types.ECOSYSTEM_PYPI},
+		},
 		{
 			name:     "no-version",
 			purl:     "pkg:npm/%40react-stately/color",
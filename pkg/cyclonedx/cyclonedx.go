@@ -0,0 +1,473 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cyclonedx models the parts of CycloneDX 1.4 to 1.6 documents
+// used by the Trusty libraries, and reads them from their JSON and XML
+// encodings. The fields not modelled here are kept when decoding a
+// document, and written back in their place when encoding it again, so
+// that annotating a document does not lose any of its data.
+package cyclonedx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	// BOMFormat is the value of the bomFormat field of JSON documents.
+	BOMFormat = "CycloneDX"
+
+	// xmlNamespacePrefix is the XML namespace of a document, without the
+	// spec version.
+	xmlNamespacePrefix = "http://cyclonedx.org/schema/bom/"
+)
+
+// BOM is a CycloneDX document.
+type BOM struct {
+	XMLName         xml.Name        `json:"-" xml:"bom"`
+	XMLNS           string          `json:"-" xml:"xmlns,attr"`
+	BOMFormat       string          `json:"bomFormat" xml:"-"`
+	SpecVersion     string          `json:"specVersion" xml:"-"`
+	SerialNumber    string          `json:"serialNumber,omitempty" xml:"serialNumber,attr,omitempty"`
+	Version         int             `json:"version,omitempty" xml:"version,attr,omitempty"`
+	Metadata        *Metadata       `json:"metadata,omitempty" xml:"metadata,omitempty"`
+	Components      []Component     `json:"components,omitempty" xml:"components>component,omitempty"`
+	Dependencies    []Dependency    `json:"dependencies,omitempty" xml:"dependencies>dependency,omitempty"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty" xml:"vulnerabilities>vulnerability,omitempty"`
+
+	unknown unknownFields
+}
+
+// Metadata describes the document and the component it is about.
+type Metadata struct {
+	Timestamp string `json:"timestamp,omitempty" xml:"timestamp,omitempty"`
+
+	// Tools is kept verbatim as its JSON shape changed in CycloneDX 1.5.
+	Tools    json.RawMessage `json:"tools,omitempty" xml:"-"`
+	XMLTools *RawXML         `json:"-" xml:"tools,omitempty"`
+
+	Component  *Component `json:"component,omitempty" xml:"component,omitempty"`
+	Properties []Property `json:"properties,omitempty" xml:"properties>property,omitempty"`
+
+	unknown unknownFields
+}
+
+// RawXML holds an XML element whose content is kept verbatim.
+type RawXML struct {
+	Inner string `xml:",innerxml"`
+}
+
+// Component is a piece of software described in the document.
+type Component struct {
+	Type               string              `json:"type" xml:"type,attr"`
+	BOMRef             string              `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	Author             string              `json:"author,omitempty" xml:"author,omitempty"`
+	Publisher          string              `json:"publisher,omitempty" xml:"publisher,omitempty"`
+	Group              string              `json:"group,omitempty" xml:"group,omitempty"`
+	Name               string              `json:"name" xml:"name"`
+	Version            string              `json:"version,omitempty" xml:"version,omitempty"`
+	Description        string              `json:"description,omitempty" xml:"description,omitempty"`
+	Scope              string              `json:"scope,omitempty" xml:"scope,omitempty"`
+	Hashes             []Hash              `json:"hashes,omitempty" xml:"hashes>hash,omitempty"`
+	Licenses           Licenses            `json:"licenses,omitempty" xml:"licenses,omitempty"`
+	CPE                string              `json:"cpe,omitempty" xml:"cpe,omitempty"`
+	PURL               string              `json:"purl,omitempty" xml:"purl,omitempty"`
+	ExternalReferences []ExternalReference `json:"externalReferences,omitempty" xml:"externalReferences>reference,omitempty"`
+	Properties         []Property          `json:"properties,omitempty" xml:"properties>property,omitempty"`
+	Components         []Component         `json:"components,omitempty" xml:"components>component,omitempty"`
+
+	unknown unknownFields
+}
+
+// Hash is a checksum of a component.
+type Hash struct {
+	Algorithm string `json:"alg" xml:"alg,attr"`
+	Content   string `json:"content" xml:",chardata"`
+
+	unknown unknownFields
+}
+
+// Licenses is the list of licenses of a component.
+type Licenses []LicenseChoice
+
+// LicenseChoice is either a license or an SPDX license expression.
+type LicenseChoice struct {
+	License    *License `json:"license,omitempty"`
+	Expression string   `json:"expression,omitempty"`
+
+	unknown unknownFields
+}
+
+// License is a license identified by its SPDX ID or its name.
+type License struct {
+	ID   string `json:"id,omitempty" xml:"id,omitempty"`
+	Name string `json:"name,omitempty" xml:"name,omitempty"`
+	URL  string `json:"url,omitempty" xml:"url,omitempty"`
+
+	unknown unknownFields
+}
+
+// ExternalReference points to a resource related to a component.
+type ExternalReference struct {
+	Type    string `json:"type" xml:"type,attr"`
+	URL     string `json:"url" xml:"url"`
+	Comment string `json:"comment,omitempty" xml:"comment,omitempty"`
+
+	unknown unknownFields
+}
+
+// Property is a name-value pair attached to an element of the document.
+type Property struct {
+	Name  string `json:"name" xml:"name,attr"`
+	Value string `json:"value,omitempty" xml:",chardata"`
+
+	unknown unknownFields
+}
+
+// Dependency lists the components another one depends on, referenced by
+// their bom-ref.
+type Dependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	unknown unknownFields
+}
+
+// Vulnerability describes a vulnerability, or in VEX documents its impact,
+// affecting components of the document.
+type Vulnerability struct {
+	BOMRef         string     `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	ID             string     `json:"id" xml:"id"`
+	Source         *Source    `json:"source,omitempty" xml:"source,omitempty"`
+	Ratings        []Rating   `json:"ratings,omitempty" xml:"ratings>rating,omitempty"`
+	CWEs           []int      `json:"cwes,omitempty" xml:"cwes>cwe,omitempty"`
+	Description    string     `json:"description,omitempty" xml:"description,omitempty"`
+	Detail         string     `json:"detail,omitempty" xml:"detail,omitempty"`
+	Recommendation string     `json:"recommendation,omitempty" xml:"recommendation,omitempty"`
+	Published      string     `json:"published,omitempty" xml:"published,omitempty"`
+	Updated        string     `json:"updated,omitempty" xml:"updated,omitempty"`
+	Analysis       *Analysis  `json:"analysis,omitempty" xml:"analysis,omitempty"`
+	Affects        []Affects  `json:"affects,omitempty" xml:"affects>target,omitempty"`
+	Properties     []Property `json:"properties,omitempty" xml:"properties>property,omitempty"`
+
+	unknown unknownFields
+}
+
+// Source is the origin of the information about a vulnerability.
+type Source struct {
+	Name string `json:"name,omitempty" xml:"name,omitempty"`
+	URL  string `json:"url,omitempty" xml:"url,omitempty"`
+
+	unknown unknownFields
+}
+
+// Rating is the severity of a vulnerability.
+type Rating struct {
+	Severity string `json:"severity,omitempty" xml:"severity,omitempty"`
+	Method   string `json:"method,omitempty" xml:"method,omitempty"`
+
+	unknown unknownFields
+}
+
+// Analysis is the assessment of the impact of a vulnerability.
+type Analysis struct {
+	State         string   `json:"state,omitempty" xml:"state,omitempty"`
+	Justification string   `json:"justification,omitempty" xml:"justification,omitempty"`
+	Response      []string `json:"response,omitempty" xml:"responses>response,omitempty"`
+	Detail        string   `json:"detail,omitempty" xml:"detail,omitempty"`
+
+	unknown unknownFields
+}
+
+// Affects references a component affected by a vulnerability.
+type Affects struct {
+	Ref string `json:"ref" xml:"ref"`
+
+	unknown unknownFields
+}
+
+// Decode reads a CycloneDX document from its JSON or XML encoding.
+func Decode(data []byte) (*BOM, error) {
+	var bom BOM
+	trimmed := bytes.TrimSpace(data)
+	if DetectFormat(trimmed) == FormatXML {
+		if err := xml.Unmarshal(trimmed, &bom); err != nil {
+			return nil, fmt.Errorf("decoding XML document: %w", err)
+		}
+		spec, ok := strings.CutPrefix(bom.XMLNS, xmlNamespacePrefix)
+		if !ok {
+			return nil, fmt.Errorf("unknown XML namespace %q", bom.XMLNS)
+		}
+		bom.BOMFormat = BOMFormat
+		bom.SpecVersion = spec
+		return &bom, nil
+	}
+
+	if err := json.Unmarshal(trimmed, &bom); err != nil {
+		return nil, fmt.Errorf("decoding JSON document: %w", err)
+	}
+	if bom.BOMFormat != BOMFormat {
+		return nil, fmt.Errorf("unexpected bomFormat %q", bom.BOMFormat)
+	}
+	return &bom, nil
+}
+
+// AllComponents returns the components of the document, including the
+// ones nested in other components, in depth first order. The component
+// described in the metadata is not included.
+func (b *BOM) AllComponents() []*Component {
+	var res []*Component
+	var walk func([]Component)
+	walk = func(components []Component) {
+		for i := range components {
+			res = append(res, &components[i])
+			walk(components[i].Components)
+		}
+	}
+	walk(b.Components)
+	return res
+}
+
+// UnmarshalJSON reads a document, keeping the members not modelled.
+func (b *BOM) UnmarshalJSON(data []byte) error {
+	type plain BOM
+	return decodeJSON(data, (*plain)(b), &b.unknown)
+}
+
+// UnmarshalXML reads a document, keeping the attributes and elements not
+// modelled.
+func (b *BOM) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain BOM
+	return decodeXML(dec, start, (*plain)(b), &b.unknown)
+}
+
+// UnmarshalJSON reads a metadata, keeping the members not modelled.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	type plain Metadata
+	return decodeJSON(data, (*plain)(m), &m.unknown)
+}
+
+// UnmarshalXML reads a metadata, keeping the attributes and elements not
+// modelled.
+func (m *Metadata) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Metadata
+	return decodeXML(dec, start, (*plain)(m), &m.unknown)
+}
+
+// UnmarshalJSON reads a component, keeping the members not modelled.
+func (c *Component) UnmarshalJSON(data []byte) error {
+	type plain Component
+	return decodeJSON(data, (*plain)(c), &c.unknown)
+}
+
+// UnmarshalXML reads a component, keeping the attributes and elements not
+// modelled.
+func (c *Component) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Component
+	return decodeXML(dec, start, (*plain)(c), &c.unknown)
+}
+
+// UnmarshalJSON reads a license choice, keeping the members not modelled.
+func (c *LicenseChoice) UnmarshalJSON(data []byte) error {
+	type plain LicenseChoice
+	return decodeJSON(data, (*plain)(c), &c.unknown)
+}
+
+// UnmarshalJSON reads a license, keeping the members not modelled.
+func (l *License) UnmarshalJSON(data []byte) error {
+	type plain License
+	return decodeJSON(data, (*plain)(l), &l.unknown)
+}
+
+// UnmarshalXML reads a license, keeping the attributes and elements not
+// modelled.
+func (l *License) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain License
+	return decodeXML(dec, start, (*plain)(l), &l.unknown)
+}
+
+// UnmarshalJSON reads a hash, keeping the members not modelled.
+func (h *Hash) UnmarshalJSON(data []byte) error {
+	type plain Hash
+	return decodeJSON(data, (*plain)(h), &h.unknown)
+}
+
+// UnmarshalXML reads a hash, keeping the attributes and elements not
+// modelled.
+func (h *Hash) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Hash
+	return decodeXML(dec, start, (*plain)(h), &h.unknown)
+}
+
+// UnmarshalJSON reads a property, keeping the members not modelled.
+func (p *Property) UnmarshalJSON(data []byte) error {
+	type plain Property
+	return decodeJSON(data, (*plain)(p), &p.unknown)
+}
+
+// UnmarshalXML reads a property, keeping the attributes and elements not
+// modelled.
+func (p *Property) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Property
+	return decodeXML(dec, start, (*plain)(p), &p.unknown)
+}
+
+// UnmarshalJSON reads a source, keeping the members not modelled.
+func (s *Source) UnmarshalJSON(data []byte) error {
+	type plain Source
+	return decodeJSON(data, (*plain)(s), &s.unknown)
+}
+
+// UnmarshalXML reads a source, keeping the attributes and elements not
+// modelled.
+func (s *Source) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Source
+	return decodeXML(dec, start, (*plain)(s), &s.unknown)
+}
+
+// UnmarshalJSON reads a reference, keeping the members not modelled.
+func (r *ExternalReference) UnmarshalJSON(data []byte) error {
+	type plain ExternalReference
+	return decodeJSON(data, (*plain)(r), &r.unknown)
+}
+
+// UnmarshalXML reads a reference, keeping the attributes and elements not
+// modelled.
+func (r *ExternalReference) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain ExternalReference
+	return decodeXML(dec, start, (*plain)(r), &r.unknown)
+}
+
+// UnmarshalJSON reads a vulnerability, keeping the members not modelled.
+func (v *Vulnerability) UnmarshalJSON(data []byte) error {
+	type plain Vulnerability
+	return decodeJSON(data, (*plain)(v), &v.unknown)
+}
+
+// UnmarshalXML reads a vulnerability, keeping the attributes and elements not
+// modelled.
+func (v *Vulnerability) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Vulnerability
+	return decodeXML(dec, start, (*plain)(v), &v.unknown)
+}
+
+// UnmarshalJSON reads a rating, keeping the members not modelled.
+func (r *Rating) UnmarshalJSON(data []byte) error {
+	type plain Rating
+	return decodeJSON(data, (*plain)(r), &r.unknown)
+}
+
+// UnmarshalXML reads a rating, keeping the attributes and elements not
+// modelled.
+func (r *Rating) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Rating
+	return decodeXML(dec, start, (*plain)(r), &r.unknown)
+}
+
+// UnmarshalJSON reads a analysis, keeping the members not modelled.
+func (a *Analysis) UnmarshalJSON(data []byte) error {
+	type plain Analysis
+	return decodeJSON(data, (*plain)(a), &a.unknown)
+}
+
+// UnmarshalXML reads a analysis, keeping the attributes and elements not
+// modelled.
+func (a *Analysis) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Analysis
+	return decodeXML(dec, start, (*plain)(a), &a.unknown)
+}
+
+// UnmarshalJSON reads a affected component, keeping the members not modelled.
+func (a *Affects) UnmarshalJSON(data []byte) error {
+	type plain Affects
+	return decodeJSON(data, (*plain)(a), &a.unknown)
+}
+
+// UnmarshalXML reads a affected component, keeping the attributes and elements not
+// modelled.
+func (a *Affects) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	type plain Affects
+	return decodeXML(dec, start, (*plain)(a), &a.unknown)
+}
+
+type xmlDependency struct {
+	Ref          string          `xml:"ref,attr"`
+	Dependencies []xmlDependency `xml:"dependency"`
+}
+
+// UnmarshalJSON reads a dependency, keeping the members not modelled, eg
+// the provides of CycloneDX 1.6.
+func (d *Dependency) UnmarshalJSON(data []byte) error {
+	type plain Dependency
+	return decodeJSON(data, (*plain)(d), &d.unknown)
+}
+
+// UnmarshalXML reads a dependency from its nested XML representation,
+// keeping the attributes and elements not modelled.
+func (d *Dependency) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	var x xmlDependency
+	if err := decodeXML(dec, start, &x, &d.unknown); err != nil {
+		return err
+	}
+	d.Ref = x.Ref
+	for _, child := range x.Dependencies {
+		d.DependsOn = append(d.DependsOn, child.Ref)
+	}
+	return nil
+}
+
+// UnmarshalXML reads the licenses of a component, which are encoded as a
+// sequence of license and expression elements. The attributes of the
+// expressions are kept.
+func (l *Licenses) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "license":
+				var license License
+				if err := dec.DecodeElement(&license, &t); err != nil {
+					return err
+				}
+				*l = append(*l, LicenseChoice{License: &license})
+			case "expression":
+				var expression string
+				if err := dec.DecodeElement(&expression, &t); err != nil {
+					return err
+				}
+				c := LicenseChoice{Expression: strings.TrimSpace(expression)}
+				for _, a := range t.Attr {
+					if !isNamespaceDecl(a) {
+						c.unknown.xmlAttrs = append(c.unknown.xmlAttrs, a)
+					}
+				}
+				*l = append(*l, c)
+			default:
+				if err := dec.Skip(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cyclonedx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	bom, err := Decode([]byte(`
+<bom xmlns="http://cyclonedx.org/schema/bom/1.6" serialNumber="urn:uuid:1" version="2">
+  <components>
+    <component type="library" bom-ref="a">
+      <name>a</name>
+      <licenses>
+        <license><id>MIT</id></license>
+        <expression>Apache-2.0 OR MIT</expression>
+      </licenses>
+      <properties><property name="foo">bar</property></properties>
+      <components><component type="library"><name>b</name></component></components>
+    </component>
+  </components>
+</bom>`))
+	require.NoError(t, err)
+	require.Equal(t, BOMFormat, bom.BOMFormat)
+	require.Equal(t, "1.6", bom.SpecVersion)
+	require.Equal(t, "urn:uuid:1", bom.SerialNumber)
+	require.Equal(t, 2, bom.Version)
+	licenses := bom.Components[0].Licenses
+	require.Len(t, licenses, 2)
+	require.Equal(t, "MIT", licenses[0].License.ID)
+	require.Equal(t, "Apache-2.0 OR MIT", licenses[1].Expression)
+	props := bom.Components[0].Properties
+	require.Len(t, props, 1)
+	require.Equal(t, "foo", props[0].Name)
+	require.Equal(t, "bar", props[0].Value)
+
+	var names []string
+	for _, c := range bom.AllComponents() {
+		names = append(names, c.Name)
+	}
+	require.Equal(t, []string{"a", "b"}, names)
+
+	_, err = Decode([]byte(`<bom xmlns="http://example.com"/>`))
+	require.Error(t, err)
+	_, err = Decode([]byte(`{"bomFormat": "SPDX"}`))
+	require.Error(t, err)
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	bom := &BOM{
+		BOMFormat:   BOMFormat,
+		SpecVersion: "1.5",
+		Version:     1,
+		Components: []Component{{
+			Type: "library", BOMRef: "a", Name: "a", PURL: "pkg:npm/a@1.0.0",
+			Licenses:   Licenses{{License: &License{ID: "MIT"}}, {Expression: "MIT OR ISC"}},
+			Properties: []Property{{Name: "trusty:score", Value: "5"}},
+		}},
+		Dependencies:    []Dependency{{Ref: "app", DependsOn: []string{"a"}}},
+		Vulnerabilities: []Vulnerability{{ID: "X-1", Affects: []Affects{{Ref: "a"}}}},
+	}
+
+	for _, format := range []Format{FormatJSON, FormatXML} {
+		format := format
+		t.Run(string(format), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, Encode(&buf, bom, format))
+			require.Equal(t, format, DetectFormat(buf.Bytes()))
+
+			decoded, err := Decode(buf.Bytes())
+			require.NoError(t, err)
+			require.Equal(t, "MIT", decoded.Components[0].Licenses[0].License.ID)
+			require.Equal(t, "MIT OR ISC", decoded.Components[0].Licenses[1].Expression)
+			require.Equal(t, "trusty:score", decoded.Components[0].Properties[0].Name)
+			require.Equal(t, "5", decoded.Components[0].Properties[0].Value)
+			require.Equal(t, "app", decoded.Dependencies[0].Ref)
+			require.Equal(t, []string{"a"}, decoded.Dependencies[0].DependsOn)
+			require.Equal(t, bom.Vulnerabilities[0].Affects[0].Ref, decoded.Vulnerabilities[0].Affects[0].Ref)
+
+			var again bytes.Buffer
+			require.NoError(t, Encode(&again, decoded, format))
+			require.Equal(t, buf.String(), again.String())
+		})
+	}
+}
+
+func TestEncodeKeepsUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"unknown.json", "unknown.xml"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(filepath.Join("testdata", name))
+			require.NoError(t, err)
+			bom, err := Decode(data)
+			require.NoError(t, err)
+			require.Equal(t, "left-pad", bom.Components[0].Name)
+
+			var buf bytes.Buffer
+			require.NoError(t, Encode(&buf, bom, DetectFormat(data)))
+			require.Equal(t, string(data), buf.String())
+
+			// Modelled fields changed in memory are written in their place
+			bom.Components[0].Properties = []Property{{Name: "trusty:score", Value: "5"}}
+			buf.Reset()
+			require.NoError(t, Encode(&buf, bom, DetectFormat(data)))
+			decoded, err := Decode(buf.Bytes())
+			require.NoError(t, err)
+			require.Equal(t, "trusty:score", decoded.Components[0].Properties[0].Name)
+			require.Equal(t, "5", decoded.Components[0].Properties[0].Value)
+			require.Contains(t, buf.String(), "Copyright 2024 Acme")
+			require.Contains(t, buf.String(), "https://api.example.com")
+		})
+	}
+}
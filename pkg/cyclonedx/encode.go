@@ -0,0 +1,284 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cyclonedx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Format is an encoding of CycloneDX documents.
+type Format string
+
+const (
+	// FormatJSON is the JSON encoding.
+	FormatJSON Format = "json"
+	// FormatXML is the XML encoding.
+	FormatXML Format = "xml"
+)
+
+// DetectFormat returns the encoding of a document.
+func DetectFormat(data []byte) Format {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '<' {
+		return FormatXML
+	}
+	return FormatJSON
+}
+
+// Encode writes a document to w in the given format.
+func Encode(w io.Writer, bom *BOM, format Format) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(bom)
+	case FormatXML:
+		doc := *bom
+		doc.XMLNS = xmlNamespacePrefix + bom.SpecVersion
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(&doc); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// MarshalJSON writes a document with the members not modelled it was read
+// with.
+func (b BOM) MarshalJSON() ([]byte, error) {
+	type plain BOM
+	return encodeJSON(plain(b), b.unknown)
+}
+
+// MarshalXML writes a document with the attributes and elements not
+// modelled it was read with.
+func (b BOM) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain BOM
+	start.Name = xml.Name{Local: "bom"}
+	return encodeXML(enc, start, plain(b), b.unknown)
+}
+
+// MarshalJSON writes a metadata with the members not modelled it was read
+// with.
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	type plain Metadata
+	return encodeJSON(plain(m), m.unknown)
+}
+
+// MarshalXML writes a metadata with the attributes and elements not
+// modelled it was read with.
+func (m Metadata) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Metadata
+	return encodeXML(enc, start, plain(m), m.unknown)
+}
+
+// MarshalJSON writes a component with the members not modelled it was read
+// with.
+func (c Component) MarshalJSON() ([]byte, error) {
+	type plain Component
+	return encodeJSON(plain(c), c.unknown)
+}
+
+// MarshalXML writes a component with the attributes and elements not
+// modelled it was read with.
+func (c Component) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Component
+	return encodeXML(enc, start, plain(c), c.unknown)
+}
+
+// MarshalJSON writes a license choice with the members not modelled it was
+// read with.
+func (c LicenseChoice) MarshalJSON() ([]byte, error) {
+	type plain LicenseChoice
+	return encodeJSON(plain(c), c.unknown)
+}
+
+// MarshalJSON writes a license with the members not modelled it was read
+// with.
+func (l License) MarshalJSON() ([]byte, error) {
+	type plain License
+	return encodeJSON(plain(l), l.unknown)
+}
+
+// MarshalXML writes a license with the attributes and elements not
+// modelled it was read with.
+func (l License) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain License
+	return encodeXML(enc, start, plain(l), l.unknown)
+}
+
+// MarshalJSON writes a hash with the members not modelled it was read
+// with.
+func (h Hash) MarshalJSON() ([]byte, error) {
+	type plain Hash
+	return encodeJSON(plain(h), h.unknown)
+}
+
+// MarshalXML writes a hash with the attributes and elements not modelled
+// it was read with.
+func (h Hash) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Hash
+	return encodeXML(enc, start, plain(h), h.unknown)
+}
+
+// MarshalJSON writes a property with the members not modelled it was read
+// with.
+func (p Property) MarshalJSON() ([]byte, error) {
+	type plain Property
+	return encodeJSON(plain(p), p.unknown)
+}
+
+// MarshalXML writes a property with the attributes and elements not
+// modelled it was read with.
+func (p Property) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Property
+	return encodeXML(enc, start, plain(p), p.unknown)
+}
+
+// MarshalJSON writes a source with the members not modelled it was read
+// with.
+func (s Source) MarshalJSON() ([]byte, error) {
+	type plain Source
+	return encodeJSON(plain(s), s.unknown)
+}
+
+// MarshalXML writes a source with the attributes and elements not modelled
+// it was read with.
+func (s Source) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Source
+	return encodeXML(enc, start, plain(s), s.unknown)
+}
+
+// MarshalJSON writes a reference with the members not modelled it was read
+// with.
+func (r ExternalReference) MarshalJSON() ([]byte, error) {
+	type plain ExternalReference
+	return encodeJSON(plain(r), r.unknown)
+}
+
+// MarshalXML writes a reference with the attributes and elements not
+// modelled it was read with.
+func (r ExternalReference) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain ExternalReference
+	return encodeXML(enc, start, plain(r), r.unknown)
+}
+
+// MarshalJSON writes a vulnerability with the members not modelled it was read
+// with.
+func (v Vulnerability) MarshalJSON() ([]byte, error) {
+	type plain Vulnerability
+	return encodeJSON(plain(v), v.unknown)
+}
+
+// MarshalXML writes a vulnerability with the attributes and elements not
+// modelled it was read with.
+func (v Vulnerability) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Vulnerability
+	return encodeXML(enc, start, plain(v), v.unknown)
+}
+
+// MarshalJSON writes a rating with the members not modelled it was read
+// with.
+func (r Rating) MarshalJSON() ([]byte, error) {
+	type plain Rating
+	return encodeJSON(plain(r), r.unknown)
+}
+
+// MarshalXML writes a rating with the attributes and elements not
+// modelled it was read with.
+func (r Rating) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Rating
+	return encodeXML(enc, start, plain(r), r.unknown)
+}
+
+// MarshalJSON writes a analysis with the members not modelled it was read
+// with.
+func (a Analysis) MarshalJSON() ([]byte, error) {
+	type plain Analysis
+	return encodeJSON(plain(a), a.unknown)
+}
+
+// MarshalXML writes a analysis with the attributes and elements not
+// modelled it was read with.
+func (a Analysis) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Analysis
+	return encodeXML(enc, start, plain(a), a.unknown)
+}
+
+// MarshalJSON writes a affected component with the members not modelled it was read
+// with.
+func (a Affects) MarshalJSON() ([]byte, error) {
+	type plain Affects
+	return encodeJSON(plain(a), a.unknown)
+}
+
+// MarshalXML writes a affected component with the attributes and elements not
+// modelled it was read with.
+func (a Affects) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type plain Affects
+	return encodeXML(enc, start, plain(a), a.unknown)
+}
+
+// MarshalJSON writes a dependency with the members not modelled it was read
+// with.
+func (d Dependency) MarshalJSON() ([]byte, error) {
+	type plain Dependency
+	return encodeJSON(plain(d), d.unknown)
+}
+
+// MarshalXML writes a dependency in its nested XML representation, with
+// the attributes and elements not modelled it was read with.
+func (d Dependency) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	x := xmlDependency{Ref: d.Ref}
+	for _, ref := range d.DependsOn {
+		x.Dependencies = append(x.Dependencies, xmlDependency{Ref: ref})
+	}
+	return encodeXML(enc, start, x, d.unknown)
+}
+
+// MarshalXML writes the licenses of a component as a sequence of license
+// and expression elements.
+func (l Licenses) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	if len(l) == 0 {
+		return nil
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, c := range l {
+		var err error
+		if c.License != nil {
+			err = enc.EncodeElement(c.License, xml.StartElement{Name: xml.Name{Local: "license"}})
+		} else {
+			err = enc.EncodeElement(c.Expression, xml.StartElement{Name: xml.Name{Local: "expression"}, Attr: c.unknown.xmlAttrs})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
@@ -0,0 +1,432 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cyclonedx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// unknownFields records the members of a decoded element in their order,
+// keeping the ones not modelled by its type verbatim, so that encoding the
+// element again writes them back where they were. It is empty for the
+// elements built in memory.
+type unknownFields struct {
+	// json holds the members of a JSON object. The values of the modelled
+	// members are not kept, only their position.
+	json []jsonMember
+
+	// xmlAttrs holds the attributes of an XML element that are not
+	// modelled.
+	xmlAttrs []xml.Attr
+	// xml holds the child elements of an XML element. The tokens of the
+	// modelled children are not kept, only their position.
+	xml []xmlChild
+}
+
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+type xmlChild struct {
+	name   string
+	tokens []xml.Token
+}
+
+// decodeJSON decodes the JSON object data into v, a pointer to a struct
+// without custom decoding, and records its members in u.
+func decodeJSON(data []byte, v any, u *unknownFields) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	members, err := jsonMembers(data)
+	if err != nil {
+		return err
+	}
+	fields := jsonFields(reflect.TypeOf(v).Elem())
+	u.json = nil
+	for _, m := range members {
+		if fields[m.key] {
+			m.value = nil
+		}
+		u.json = append(u.json, m)
+	}
+	return nil
+}
+
+// encodeJSON encodes v, a struct without custom encoding, as a JSON object
+// holding the unknown members of u in their original position. The
+// modelled members missing from the original object come last.
+func encodeJSON(v any, u unknownFields) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || u.json == nil {
+		return data, err
+	}
+	known, err := jsonMembers(data)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]json.RawMessage, len(known))
+	for _, m := range known {
+		values[m.key] = m.value
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(key string, value json.RawMessage) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	for _, m := range u.json {
+		if m.value != nil {
+			write(m.key, m.value)
+			continue
+		}
+		if value, ok := values[m.key]; ok {
+			write(m.key, value)
+			delete(values, m.key)
+		}
+	}
+	for _, m := range known {
+		if _, ok := values[m.key]; ok {
+			write(m.key, m.value)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonMembers returns the members of a JSON object in their order. It
+// returns nil when data is not an object.
+func jsonMembers(data []byte) ([]jsonMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, nil
+	}
+	var res []jsonMember
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		res = append(res, jsonMember{key: key, value: value})
+	}
+	return res, nil
+}
+
+// decodeXML decodes the XML element starting with start into v, a
+// pointer to a struct without custom decoding, and records its attributes
+// and children in u.
+func decodeXML(dec *xml.Decoder, start xml.StartElement, v any, u *unknownFields) error {
+	tokens, err := readElement(dec, start)
+	if err != nil {
+		return err
+	}
+	if err := xml.NewTokenDecoder(&tokenReader{tokens: tokens}).Decode(v); err != nil {
+		return err
+	}
+
+	elems, attrs := xmlFields(reflect.TypeOf(v).Elem())
+	u.xmlAttrs = nil
+	for _, a := range start.Attr {
+		if !attrs[a.Name.Local] && !isNamespaceDecl(a) {
+			u.xmlAttrs = append(u.xmlAttrs, a)
+		}
+	}
+	u.xml = nil
+	for _, c := range xmlChildren(tokens, start.Name.Space) {
+		if elems[c.name] {
+			c.tokens = nil
+		}
+		u.xml = append(u.xml, c)
+	}
+	return nil
+}
+
+// encodeXML encodes v, a struct without custom encoding, as an XML element
+// holding the unknown attributes and children of u. The children are in
+// their original position, and the modelled ones missing from the
+// original element come last. Empty wrappers of lists, which encoding/xml
+// writes despite omitempty, are left out.
+func encodeXML(enc *xml.Encoder, start xml.StartElement, v any, u unknownFields) error {
+	start.Name.Space = ""
+	var buf bytes.Buffer
+	if err := xml.NewEncoder(&buf).EncodeElement(v, start); err != nil {
+		return err
+	}
+	tokens, err := readElement(xml.NewDecoder(&buf), xml.StartElement{})
+	if err != nil {
+		return err
+	}
+	known := tokens[0].(xml.StartElement)
+	space := known.Name.Space
+	known.Name.Space = ""
+	known.Attr = append(known.Attr, u.xmlAttrs...)
+	wrappers := xmlWrappers(reflect.TypeOf(v))
+	children := map[string][]xmlChild{}
+	var order []string
+	for _, c := range xmlChildren(tokens, space) {
+		if wrappers[c.name] && len(c.tokens) == 2 {
+			continue
+		}
+		if children[c.name] == nil {
+			order = append(order, c.name)
+		}
+		children[c.name] = append(children[c.name], c)
+	}
+
+	res := append([]xml.Token{known}, xmlText(tokens)...)
+	add := func(c xmlChild) {
+		res = append(res, c.tokens...)
+	}
+	for _, c := range u.xml {
+		if c.tokens != nil {
+			add(c)
+			continue
+		}
+		for _, kc := range children[c.name] {
+			add(kc)
+		}
+		delete(children, c.name)
+	}
+	for _, name := range order {
+		for _, kc := range children[name] {
+			add(kc)
+		}
+	}
+	res = append(res, known.End())
+
+	for _, tok := range res {
+		if err := enc.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readElement returns the tokens of an element, from its start to its
+// end. When start is empty, the element starts with the next token.
+func readElement(dec *xml.Decoder, start xml.StartElement) ([]xml.Token, error) {
+	var tokens []xml.Token
+	depth := 0
+	if start.Name.Local != "" {
+		tokens = append(tokens, start.Copy())
+		depth++
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		default:
+			if depth == 0 {
+				// Prolog before the element
+				continue
+			}
+		}
+		tokens = append(tokens, xml.CopyToken(tok))
+		if depth == 0 {
+			return tokens, nil
+		}
+	}
+}
+
+// xmlChildren splits the tokens of an element into its child elements.
+// The names in space, the namespace of the element, are made local, and
+// whitespace between elements is left out: the encoder indents them.
+func xmlChildren(tokens []xml.Token, space string) []xmlChild {
+	var res []xmlChild
+	depth := 0
+	for _, tok := range tokens[1 : len(tokens)-1] {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				res = append(res, xmlChild{name: t.Name.Local})
+			}
+			depth++
+			if t.Name.Space == space {
+				t.Name.Space = ""
+			}
+			var attrs []xml.Attr
+			for _, a := range t.Attr {
+				if !isNamespaceDecl(a) {
+					attrs = append(attrs, a)
+				}
+			}
+			t.Attr = attrs
+			tok = t
+		case xml.EndElement:
+			depth--
+			if t.Name.Space == space {
+				t.Name.Space = ""
+			}
+			tok = t
+		case xml.CharData:
+			if depth == 0 || len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		case xml.Comment, xml.ProcInst, xml.Directive:
+			if depth == 0 {
+				continue
+			}
+		}
+		c := &res[len(res)-1]
+		c.tokens = append(c.tokens, tok)
+	}
+	return res
+}
+
+// xmlText returns the text of an element that is not whitespace between
+// its children, eg the value of an element modelled as chardata.
+func xmlText(tokens []xml.Token) []xml.Token {
+	var res []xml.Token
+	depth := 0
+	for _, tok := range tokens[1 : len(tokens)-1] {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(t)) > 0 {
+				res = append(res, t)
+			}
+		}
+	}
+	return res
+}
+
+// isNamespaceDecl returns true if the attribute declares a namespace. The
+// encoder declares the namespaces of the names it writes.
+func isNamespaceDecl(a xml.Attr) bool {
+	return a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns")
+}
+
+// tokenReader replays tokens.
+type tokenReader struct {
+	tokens []xml.Token
+}
+
+func (r *tokenReader) Token() (xml.Token, error) {
+	if len(r.tokens) == 0 {
+		return nil, io.EOF
+	}
+	tok := r.tokens[0]
+	r.tokens = r.tokens[1:]
+	return tok, nil
+}
+
+var (
+	jsonFieldsCache sync.Map
+	xmlFieldsCache  sync.Map
+)
+
+// jsonFields returns the names of the JSON members modelled by a struct.
+func jsonFields(t reflect.Type) map[string]bool {
+	if fields, ok := jsonFieldsCache.Load(t); ok {
+		return fields.(map[string]bool)
+	}
+	fields := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+		case "":
+			fields[f.Name] = true
+		default:
+			fields[name] = true
+		}
+	}
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+type xmlFieldSet struct {
+	elems, attrs, wrappers map[string]bool
+}
+
+// xmlFields returns the names of the XML child elements and attributes
+// modelled by a struct.
+func xmlFields(t reflect.Type) (elems, attrs map[string]bool) {
+	set := xmlFieldsOf(t)
+	return set.elems, set.attrs
+}
+
+// xmlWrappers returns the names of the XML child elements wrapping the
+// lists modelled by a struct.
+func xmlWrappers(t reflect.Type) map[string]bool {
+	return xmlFieldsOf(t).wrappers
+}
+
+func xmlFieldsOf(t reflect.Type) xmlFieldSet {
+	if fields, ok := xmlFieldsCache.Load(t); ok {
+		return fields.(xmlFieldSet)
+	}
+	set := xmlFieldSet{elems: map[string]bool{}, attrs: map[string]bool{}, wrappers: map[string]bool{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() || f.Name == "XMLName" {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("xml"), ",")
+		if name == "-" || strings.HasPrefix(opts, "inner") || strings.HasPrefix(opts, "chardata") {
+			continue
+		}
+		name, _, wrapped := strings.Cut(name, ">")
+		if wrapped {
+			set.wrappers[name] = true
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.Contains(opts, "attr") {
+			set.attrs[name] = true
+		} else {
+			set.elems[name] = true
+		}
+	}
+	xmlFieldsCache.Store(t, set)
+	return set
+}
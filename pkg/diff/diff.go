@@ -0,0 +1,298 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff compares two sets of dependencies, eg the manifests of the
+// base and head of a pull request, and reports how the Trusty data of the
+// dependencies changed, so that reviewers see whether a change makes the
+// supply chain of a project better or worse.
+package diff
+
+import (
+	"context"
+	"sort"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v2client "github.com/stacklok/trusty-sdk-go/pkg/v2/client"
+	"github.com/stacklok/trusty-sdk-go/pkg/version"
+)
+
+// Kind is the kind of a change to a dependency.
+type Kind string
+
+const (
+	// KindAdded is a dependency only found in the new set.
+	KindAdded Kind = "added"
+	// KindRemoved is a dependency only found in the old set.
+	KindRemoved Kind = "removed"
+	// KindUpgraded is a dependency whose version increased.
+	KindUpgraded Kind = "upgraded"
+	// KindDowngraded is a dependency whose version decreased.
+	KindDowngraded Kind = "downgraded"
+	// KindChanged is a dependency whose version changed, when the old and
+	// new versions cannot be ordered.
+	KindChanged Kind = "changed"
+)
+
+// Change is a change to a dependency between the old and new sets.
+type Change struct {
+	Kind      Kind
+	Ecosystem string
+	Name      string
+
+	// Old is the dependency in the old set. It is nil when added.
+	Old *parser.Dependency
+	// New is the dependency in the new set. It is nil when removed.
+	New *parser.Dependency
+}
+
+// Dependency returns the new dependency, or the old one when removed.
+func (c *Change) Dependency() *parser.Dependency {
+	if c.New != nil {
+		return c.New
+	}
+	return c.Old
+}
+
+// OldVersion returns the version of the old dependency, or the empty
+// string when added. Dependencies without a concrete version are compared
+// by their constraint, which is returned instead.
+func (c *Change) OldVersion() string {
+	if c.Old == nil {
+		return ""
+	}
+	return effectiveVersion(c.Old)
+}
+
+// NewVersion returns the version of the new dependency, or the empty
+// string when removed.
+func (c *Change) NewVersion() string {
+	if c.New == nil {
+		return ""
+	}
+	return effectiveVersion(c.New)
+}
+
+// Dependencies returns the changes between the old and new dependencies,
+// sorted by ecosystem and name. Dependencies are matched by ecosystem and
+// name, and their versions compared following the versioning scheme of
+// their ecosystem.
+//
+// A package can have several versions in a set, eg in lockfiles. The
+// versions only found in the old set are then paired in ascending order
+// with the ones only found in the new set, and reported as upgrades or
+// downgrades. The versions left unpaired are reported as added or removed.
+func Dependencies(oldDeps, newDeps []parser.Dependency) []Change {
+	oldIndex := index(oldDeps)
+	newIndex := index(newDeps)
+
+	var res []Change
+	for k, newVersions := range newIndex {
+		oldVersions := oldIndex[k]
+		removed := missing(oldVersions, newVersions)
+		added := missing(newVersions, oldVersions)
+		sortVersions(k.ecosystem, removed)
+		sortVersions(k.ecosystem, added)
+
+		for i := 0; i < len(removed) && i < len(added); i++ {
+			kind := KindChanged
+			if c, ok := compare(k.ecosystem, removed[i], added[i]); ok {
+				switch c {
+				case -1:
+					kind = KindUpgraded
+				case 1:
+					kind = KindDowngraded
+				}
+			}
+			res = append(res, Change{Kind: kind, Ecosystem: k.ecosystem, Name: k.name, Old: removed[i], New: added[i]})
+		}
+		for i := len(removed); i < len(added); i++ {
+			res = append(res, Change{Kind: KindAdded, Ecosystem: k.ecosystem, Name: k.name, New: added[i]})
+		}
+		for i := len(added); i < len(removed); i++ {
+			res = append(res, Change{Kind: KindRemoved, Ecosystem: k.ecosystem, Name: k.name, Old: removed[i]})
+		}
+	}
+	for k, oldVersions := range oldIndex {
+		if _, ok := newIndex[k]; ok {
+			continue
+		}
+		sortVersions(k.ecosystem, oldVersions)
+		for _, dep := range oldVersions {
+			res = append(res, Change{Kind: KindRemoved, Ecosystem: k.ecosystem, Name: k.name, Old: dep})
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Ecosystem != res[j].Ecosystem {
+			return res[i].Ecosystem < res[j].Ecosystem
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+type key struct {
+	ecosystem, name string
+}
+
+// index groups the dependencies by package, keeping the first occurrence
+// of each version.
+func index(deps []parser.Dependency) map[key][]*parser.Dependency {
+	res := map[key][]*parser.Dependency{}
+	seen := map[[3]string]bool{}
+	for i := range deps {
+		k := key{deps[i].Ecosystem, deps[i].Name}
+		v := [3]string{k.ecosystem, k.name, effectiveVersion(&deps[i])}
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		res[k] = append(res[k], &deps[i])
+	}
+	return res
+}
+
+// missing returns the dependencies of deps whose version is not in other.
+func missing(deps, other []*parser.Dependency) []*parser.Dependency {
+	var res []*parser.Dependency
+	for _, dep := range deps {
+		found := false
+		for _, o := range other {
+			if effectiveVersion(dep) == effectiveVersion(o) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, dep)
+		}
+	}
+	return res
+}
+
+func sortVersions(ecosystem string, deps []*parser.Dependency) {
+	sort.SliceStable(deps, func(i, j int) bool {
+		c, ok := compare(ecosystem, deps[i], deps[j])
+		if !ok {
+			return effectiveVersion(deps[i]) < effectiveVersion(deps[j])
+		}
+		return c < 0
+	})
+}
+
+func effectiveVersion(dep *parser.Dependency) string {
+	if dep.Version != "" {
+		return dep.Version
+	}
+	return dep.Constraint
+}
+
+// compare compares the versions of two dependencies following the scheme
+// of their ecosystem. Constraints are compared by the lower bound of their
+// range, eg 4.17.1 for ^4.17.1 or 1.0 for [1.0,2.0). It returns false when
+// the versions cannot be ordered.
+func compare(ecosystem string, a, b *parser.Dependency) (int, bool) {
+	av, ok := comparableVersion(ecosystem, a)
+	if !ok {
+		return 0, false
+	}
+	bv, ok := comparableVersion(ecosystem, b)
+	if !ok {
+		return 0, false
+	}
+	return av.Compare(bv), true
+}
+
+func comparableVersion(ecosystem string, dep *parser.Dependency) (version.Version, bool) {
+	if dep.Version != "" {
+		v, err := version.ParseFor(ecosystem, dep.Version)
+		return v, err == nil
+	}
+	r, err := version.ParseRangeFor(ecosystem, dep.Constraint)
+	if err != nil {
+		return version.Version{}, false
+	}
+	return r.LowerBound()
+}
+
+// Delta is a change to a dependency together with the Trusty data of its
+// old and new versions.
+type Delta struct {
+	Change
+
+	// Before is the Trusty data of the old dependency. It is nil when the
+	// dependency was added.
+	Before *result.Package
+	// After is the Trusty data of the new dependency. It is nil when the
+	// dependency was removed.
+	After *result.Package
+}
+
+// ScoreDelta returns the difference between the new and old Trusty scores
+// of the dependency, or nil when either is unknown.
+func (d *Delta) ScoreDelta() *float64 {
+	if d.Before == nil || d.After == nil {
+		return nil
+	}
+	before, after := d.Before.Score(), d.After.Score()
+	if before == nil || after == nil {
+		return nil
+	}
+	delta := *after - *before
+	return &delta
+}
+
+// Packages returns the Trusty data of the new dependencies of the deltas,
+// eg to generate findings on what a change introduces.
+func Packages(deltas []Delta) []result.Package {
+	var res []result.Package
+	for _, d := range deltas {
+		if d.After != nil {
+			res = append(res, *d.After)
+		}
+	}
+	return res
+}
+
+// Fetch queries Trusty for the old and new versions of the dependencies
+// changed, and returns the changes in the same order with their data.
+func Fetch(ctx context.Context, client v2client.Trusty, changes []Change, opts result.FetchOptions) []Delta {
+	var deps []parser.Dependency
+	for _, c := range changes {
+		if c.Old != nil {
+			deps = append(deps, *c.Old)
+		}
+		if c.New != nil {
+			deps = append(deps, *c.New)
+		}
+	}
+	packages := result.Fetch(ctx, client, deps, opts)
+
+	res := make([]Delta, len(changes))
+	i := 0
+	for j, c := range changes {
+		res[j].Change = c
+		if c.Old != nil {
+			res[j].Before = &packages[i]
+			i++
+		}
+		if c.New != nil {
+			res[j].After = &packages[i]
+			i++
+		}
+	}
+	return res
+}
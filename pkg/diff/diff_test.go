@@ -0,0 +1,180 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+func TestDependencies(t *testing.T) {
+	t.Parallel()
+
+	oldDeps := []parser.Dependency{
+		{Name: "lodash", Ecosystem: "npm", Version: "4.17.20"},
+		{Name: "express", Ecosystem: "npm", Version: "4.18.2"},
+		{Name: "left-pad", Ecosystem: "npm", Version: "1.3.0"},
+		{Name: "requests", Ecosystem: "pypi", Constraint: "==2.31.0"},
+		{Name: "same", Ecosystem: "npm", Version: "1.0.0"},
+		{Name: "weird", Ecosystem: "npm", Version: "abc"},
+	}
+	newDeps := []parser.Dependency{
+		{Name: "lodash", Ecosystem: "npm", Version: "4.17.21"},
+		{Name: "express", Ecosystem: "npm", Version: "4.18.2-rc.1"},
+		{Name: "requests", Ecosystem: "pypi", Constraint: "==2.32.0"},
+		{Name: "same", Ecosystem: "npm", Version: "1.0.0"},
+		{Name: "weird", Ecosystem: "npm", Version: "def"},
+		{Name: "lodash", Ecosystem: "pypi", Version: "0.1.0"},
+	}
+
+	var got []string
+	for _, c := range Dependencies(oldDeps, newDeps) {
+		got = append(got, string(c.Kind)+" "+c.Ecosystem+"/"+c.Name+" "+c.OldVersion()+" "+c.NewVersion())
+	}
+	require.Equal(t, []string{
+		"downgraded npm/express 4.18.2 4.18.2-rc.1",
+		"removed npm/left-pad 1.3.0 ",
+		"upgraded npm/lodash 4.17.20 4.17.21",
+		"changed npm/weird abc def",
+		"added pypi/lodash  0.1.0",
+		"upgraded pypi/requests ==2.31.0 ==2.32.0",
+	}, got)
+}
+
+func TestDependenciesVersions(t *testing.T) {
+	t.Parallel()
+
+	oldDeps := []parser.Dependency{
+		{Name: "spring-core", Ecosystem: "maven", Version: "5.3.9"},
+		{Name: "django", Ecosystem: "pypi", Version: "4.2rc1"},
+		{Name: "golang.org/x/net", Ecosystem: "go", Version: "v0.0.0-20191109021931-daa7c04131f5"},
+		{Name: "ms", Ecosystem: "npm", Version: "2.0.0"},
+		{Name: "ms", Ecosystem: "npm", Version: "2.1.2"},
+		{Name: "ms", Ecosystem: "npm", Version: "2.1.2"},
+		{Name: "debug", Ecosystem: "npm", Version: "3.2.7"},
+		{Name: "debug", Ecosystem: "npm", Version: "4.3.4"},
+	}
+	newDeps := []parser.Dependency{
+		{Name: "spring-core", Ecosystem: "maven", Version: "5.3.10"},
+		{Name: "django", Ecosystem: "pypi", Version: "4.2"},
+		{Name: "golang.org/x/net", Ecosystem: "go", Version: "v0.1.0"},
+		{Name: "ms", Ecosystem: "npm", Version: "2.1.3"},
+		{Name: "ms", Ecosystem: "npm", Version: "2.0.0"},
+		{Name: "debug", Ecosystem: "npm", Version: "4.3.4"},
+		{Name: "debug", Ecosystem: "npm", Version: "2.6.9"},
+		{Name: "debug", Ecosystem: "npm", Version: "4.4.0"},
+	}
+
+	var got []string
+	for _, c := range Dependencies(oldDeps, newDeps) {
+		got = append(got, string(c.Kind)+" "+c.Ecosystem+"/"+c.Name+" "+c.OldVersion()+" "+c.NewVersion())
+	}
+	require.Equal(t, []string{
+		"upgraded go/golang.org/x/net v0.0.0-20191109021931-daa7c04131f5 v0.1.0",
+		"upgraded maven/spring-core 5.3.9 5.3.10",
+		"downgraded npm/debug 3.2.7 2.6.9",
+		"added npm/debug  4.4.0",
+		"upgraded npm/ms 2.1.2 2.1.3",
+		"upgraded pypi/django 4.2rc1 4.2",
+	}, got)
+}
+
+func TestDependenciesConstraints(t *testing.T) {
+	t.Parallel()
+
+	oldDeps := []parser.Dependency{
+		{Name: "guava", Ecosystem: "maven", Constraint: "[1.0,2.0)"},
+		{Name: "commons-io", Ecosystem: "maven", Constraint: "[2.10,3.0)"},
+		{Name: "serde", Ecosystem: "crates", Constraint: ">= 1.2, < 2"},
+		{Name: "tokio", Ecosystem: "crates", Constraint: "^1.9"},
+		{Name: "log", Ecosystem: "crates", Constraint: "< 0.5"},
+	}
+	newDeps := []parser.Dependency{
+		{Name: "guava", Ecosystem: "maven", Constraint: "[2.0,3.0)"},
+		{Name: "commons-io", Ecosystem: "maven", Constraint: "[2.9,3.0)"},
+		{Name: "serde", Ecosystem: "crates", Constraint: ">= 1.10, < 2"},
+		{Name: "tokio", Ecosystem: "crates", Constraint: "^1.10"},
+		{Name: "log", Ecosystem: "crates", Constraint: "< 0.4"},
+	}
+
+	var got []string
+	for _, c := range Dependencies(oldDeps, newDeps) {
+		got = append(got, string(c.Kind)+" "+c.Ecosystem+"/"+c.Name+" "+c.OldVersion()+" "+c.NewVersion())
+	}
+	require.Equal(t, []string{
+		"changed crates/log < 0.5 < 0.4",
+		"upgraded crates/serde >= 1.2, < 2 >= 1.10, < 2",
+		"upgraded crates/tokio ^1.9 ^1.10",
+		"downgraded maven/commons-io [2.10,3.0) [2.9,3.0)",
+		"upgraded maven/guava [1.0,2.0) [2.0,3.0)",
+	}, got)
+}
+
+type fakeTrusty struct {
+	scores map[string]float64
+}
+
+func (f *fakeTrusty) Summary(_ context.Context, dep *v2types.Dependency) (*v2types.PackageSummaryAnnotation, error) {
+	var version string
+	if dep.PackageVersion != nil {
+		version = *dep.PackageVersion
+	}
+	score, ok := f.scores[dep.PackageName+"@"+version]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &v2types.PackageSummaryAnnotation{Score: &score}, nil
+}
+
+func (*fakeTrusty) PackageMetadata(context.Context, *v2types.Dependency) (*v2types.TrustyPackageData, error) {
+	return &v2types.TrustyPackageData{}, nil
+}
+
+func (*fakeTrusty) Alternatives(context.Context, *v2types.Dependency) (*v2types.PackageAlternatives, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (*fakeTrusty) Provenance(context.Context, *v2types.Dependency) (*v2types.Provenance, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	client := &fakeTrusty{scores: map[string]float64{
+		"lodash@4.17.20": 7, "lodash@4.17.21": 8.5, "left-pad@1.3.0": 3, "new@1.0.0": 6,
+	}}
+	changes := Dependencies(
+		[]parser.Dependency{
+			{Name: "lodash", Ecosystem: "npm", Version: "4.17.20"},
+			{Name: "left-pad", Ecosystem: "npm", Version: "1.3.0"},
+		},
+		[]parser.Dependency{
+			{Name: "lodash", Ecosystem: "npm", Version: "4.17.21"},
+			{Name: "new", Ecosystem: "npm", Version: "1.0.0"},
+		},
+	)
+	deltas := Fetch(context.Background(), client, changes, result.FetchOptions{})
+	require.Len(t, deltas, 3)
+
+	require.Equal(t, KindRemoved, deltas[0].Kind)
+	require.Nil(t, deltas[0].After)
+	require.Equal(t, 3.0, *deltas[0].Before.Score())
+	require.Nil(t, deltas[0].ScoreDelta())
+
+	require.Equal(t, KindUpgraded, deltas[1].Kind)
+	require.Equal(t, 1.5, *deltas[1].ScoreDelta())
+
+	require.Equal(t, KindAdded, deltas[2].Kind)
+	require.Nil(t, deltas[2].Before)
+	require.Equal(t, 6.0, *deltas[2].After.Score())
+
+	packages := Packages(deltas)
+	require.Len(t, packages, 2)
+	require.Equal(t, "lodash", packages[0].Dependency.Name)
+}
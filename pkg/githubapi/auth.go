@@ -0,0 +1,203 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"golang.org/x/oauth2"
+)
+
+// AppCredentials authenticate as an installation of a GitHub App.
+type AppCredentials struct {
+	// AppID is the ID of the GitHub App.
+	AppID int64
+
+	// InstallationID is the ID of the installation of the App on the
+	// account owning the repositories.
+	InstallationID int64
+
+	// PrivateKey is a private key of the App, PEM encoded as downloaded
+	// from GitHub.
+	PrivateKey []byte
+}
+
+const (
+	// jwtLifetime is how long the JSON Web Tokens signed for an App are
+	// valid. GitHub accepts at most 10 minutes.
+	jwtLifetime = 9 * time.Minute
+	// jwtClockSkew backdates the JSON Web Tokens, in case the clock of
+	// GitHub is behind.
+	jwtClockSkew = time.Minute
+)
+
+// parsePrivateKey parses an RSA private key in PKCS #1 or PKCS #8 form.
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// appTransport authenticates requests as a GitHub App, with a JSON Web
+// Token signed with its private key. Tokens are reused until shortly
+// before they expire.
+type appTransport struct {
+	base  http.RoundTripper
+	appID int64
+	key   *rsa.PrivateKey
+	now   func() time.Time
+
+	mu      sync.Mutex
+	jwt     string
+	expires time.Time
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+func (t *appTransport) token() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if t.jwt != "" && now.Add(time.Minute).Before(t.expires) {
+		return t.jwt, nil
+	}
+	expires := now.Add(jwtLifetime)
+	jwt, err := signJWT(t.key, map[string]any{
+		"iat": now.Add(-jwtClockSkew).Unix(),
+		"exp": expires.Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	t.jwt, t.expires = jwt, expires
+	return jwt, nil
+}
+
+// signJWT returns a JSON Web Token with the claims, signed with RS256.
+func signJWT(key *rsa.PrivateKey, claims map[string]any) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing the token of the App: %w", err)
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+// installationTransport authenticates requests as an installation of a
+// GitHub App, with an installation access token created with the client
+// of the App. Tokens are reused until shortly before they expire, and are
+// created with the context of the request needing them, so that the
+// deadline and cancellation of the caller apply to the refresh too.
+type installationTransport struct {
+	base           http.RoundTripper
+	app            *github.Client
+	installationID int64
+
+	// sem serializes the creation of the tokens, while letting the
+	// callers waiting for it give up when their context is done.
+	sem   chan struct{}
+	token *oauth2.Token
+}
+
+func newInstallationTransport(base http.RoundTripper, app *github.Client, installationID int64) *installationTransport {
+	return &installationTransport{base: base, app: app, installationID: installationID, sem: make(chan struct{}, 1)}
+}
+
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return t.base.RoundTrip(req)
+}
+
+// Token returns a valid installation access token, creating one with ctx
+// when there is none.
+func (t *installationTransport) Token(ctx context.Context) (*oauth2.Token, error) {
+	select {
+	case t.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-t.sem }()
+
+	if t.token.Valid() {
+		return t.token, nil
+	}
+	token, _, err := t.app.Apps.CreateInstallationToken(ctx, t.installationID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating an access token for installation %d: %w", t.installationID, err)
+	}
+	t.token = &oauth2.Token{
+		AccessToken: token.GetToken(),
+		TokenType:   "Bearer",
+		Expiry:      token.GetExpiresAt().Time,
+	}
+	return t.token, nil
+}
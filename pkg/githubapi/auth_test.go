@@ -0,0 +1,147 @@
+package githubapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitHubClientWithOptions(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var tokens atomic.Int32
+	repo := fakeRepo{"go.mod@main": "module example.com/m\n"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/5/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		require.True(t, ok)
+		claims := verifyJWT(t, &key.PublicKey, jwt)
+		require.Equal(t, "42", claims["iss"])
+
+		// The first token expires right away, so that it is refreshed
+		expires := time.Now().Add(time.Hour)
+		if tokens.Add(1) == 1 {
+			expires = time.Now()
+		}
+		writeJSON(w, map[string]any{"token": "ghs_installation", "expires_at": expires.Format(time.RFC3339)})
+	})
+	mux.HandleFunc("GET /api/v3/repos/acme/app/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer ghs_installation", r.Header.Get("Authorization"))
+		repo.serveContents(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	g, err := NewGitHubClientWithOptions(Options{
+		BaseURL: srv.URL,
+		App:     &AppCredentials{AppID: 42, InstallationID: 5, PrivateKey: pemKey},
+	})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		content, err := g.GetFileContent(context.Background(), "acme", "app", "go.mod", "main")
+		require.NoError(t, err)
+		require.Equal(t, "module example.com/m\n", content)
+	}
+	require.Equal(t, int32(2), tokens.Load())
+
+	_, err = NewGitHubClientWithOptions(Options{App: &AppCredentials{PrivateKey: []byte("not a key")}})
+	require.ErrorContains(t, err, "not PEM encoded")
+	_, err = NewGitHubClientWithOptions(Options{BaseURL: "://bad"})
+	require.ErrorContains(t, err, "invalid base URL")
+
+	g = NewGitHubClient("ghp_token")
+	require.Equal(t, "https://api.github.com/", g.Client.BaseURL.String())
+}
+
+func TestInstallationTokenCancel(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	cancelled := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v3/app/installations/5/access_tokens", func(_ http.ResponseWriter, r *http.Request) {
+		// The server notices the client going away once the body is read
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+		close(cancelled)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	g, err := NewGitHubClientWithOptions(Options{
+		BaseURL: srv.URL,
+		App:     &AppCredentials{AppID: 42, InstallationID: 5, PrivateKey: pemKey},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = g.GetFileContent(ctx, "acme", "app", "go.mod", "main")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the refresh of the token was not cancelled")
+	}
+
+	// A caller waiting for a refresh in progress gives up with its context
+	it := g.Client.Client().Transport.(*installationTransport)
+	it.sem <- struct{}{}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = it.Token(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	parsed, err := parsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	require.True(t, key.Equal(parsed))
+}
+
+// verifyJWT checks the signature of a JSON Web Token and returns its
+// claims.
+func verifyJWT(t *testing.T, key *rsa.PublicKey, jwt string) map[string]any {
+	t.Helper()
+	parts := strings.Split(jwt, ".")
+	require.Len(t, parts, 3)
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.NoError(t, rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig))
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]any
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	require.Greater(t, claims["exp"], claims["iat"])
+	return claims
+}
@@ -0,0 +1,93 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapi
+
+import "container/list"
+
+// DefaultContentCacheSize is the size of the cache of GetFileContent when
+// Options.ContentCacheSize is zero.
+const DefaultContentCacheSize = 16 << 20
+
+// contentCache holds the files fetched by GetFileContent with their ETag,
+// keyed by the URL of the request. It holds at most maxBytes of URLs,
+// ETags and contents, evicting the least recently used files first. A
+// zero maxBytes means DefaultContentCacheSize, and a negative one disables
+// the cache. It is guarded by the mutex of its client.
+type contentCache struct {
+	maxBytes int64
+	size     int64
+	// lru holds the *cachedContent, the most recently used first.
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// cachedContent is the content of a file with its ETag.
+type cachedContent struct {
+	url     string
+	etag    string
+	content string
+}
+
+func (c *cachedContent) size() int64 {
+	return int64(len(c.url) + len(c.etag) + len(c.content))
+}
+
+func (c *contentCache) limit() int64 {
+	if c.maxBytes == 0 {
+		return DefaultContentCacheSize
+	}
+	return c.maxBytes
+}
+
+// get returns the file fetched from url, marking it as used.
+func (c *contentCache) get(url string) (cachedContent, bool) {
+	e, ok := c.entries[url]
+	if !ok {
+		return cachedContent{}, false
+	}
+	c.lru.MoveToFront(e)
+	return *e.Value.(*cachedContent), true
+}
+
+// add stores the file fetched from url, replacing the previous one, and
+// evicts the least recently used files beyond the size of the cache.
+// Files larger than the cache are not stored.
+func (c *contentCache) add(url, etag, content string) {
+	entry := &cachedContent{url: url, etag: etag, content: content}
+	c.remove(url)
+	if entry.size() > c.limit() {
+		return
+	}
+	if c.entries == nil {
+		c.lru = list.New()
+		c.entries = map[string]*list.Element{}
+	}
+	c.entries[url] = c.lru.PushFront(entry)
+	c.size += entry.size()
+	for c.size > c.limit() {
+		c.remove(c.lru.Back().Value.(*cachedContent).url)
+	}
+}
+
+func (c *contentCache) remove(url string) {
+	e, ok := c.entries[url]
+	if !ok {
+		return
+	}
+	c.lru.Remove(e)
+	delete(c.entries, url)
+	c.size -= e.Value.(*cachedContent).size()
+}
@@ -0,0 +1,44 @@
+package githubapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentCache(t *testing.T) {
+	t.Parallel()
+
+	// Each file takes 1 + 2 + 7 = 10 bytes
+	c := contentCache{maxBytes: 25}
+	c.add("a", "e1", "content")
+	c.add("b", "e1", "content")
+	_, ok := c.get("a")
+	require.True(t, ok)
+
+	// b is the least recently used file
+	c.add("c", "e1", "content")
+	_, ok = c.get("b")
+	require.False(t, ok)
+	require.Equal(t, int64(20), c.size)
+
+	c.add("a", "e2", "changed")
+	got, ok := c.get("a")
+	require.True(t, ok)
+	require.Equal(t, cachedContent{url: "a", etag: "e2", content: "changed"}, got)
+	require.Equal(t, int64(20), c.size)
+
+	// Too large to be cached, it replaces the previous content
+	c.add("c", "e2", "a content larger than the cache")
+	_, ok = c.get("c")
+	require.False(t, ok)
+	require.Equal(t, int64(10), c.size)
+
+	disabled := contentCache{maxBytes: -1}
+	disabled.add("a", "e1", "content")
+	_, ok = disabled.get("a")
+	require.False(t, ok)
+
+	var zero contentCache
+	require.Equal(t, int64(DefaultContentCacheSize), zero.limit())
+}
@@ -0,0 +1,171 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+// DefaultCheckRunName is the name of the check runs published without
+// one.
+const DefaultCheckRunName = "Trusty"
+
+// maxAnnotations is the number of annotations GitHub accepts in a single
+// request. More annotations are added by updating the check run.
+const maxAnnotations = 50
+
+// maxCheckRunSummary is the maximum length of the summary of a check run.
+const maxCheckRunSummary = 65535
+
+// CheckRunOptions configures PublishCheckRun.
+type CheckRunOptions struct {
+	// Name is the name of the check run. It defaults to
+	// DefaultCheckRunName.
+	Name string
+
+	// HeadSHA is the commit the check run reports on, eg the head of the
+	// pull request scanned. It is required.
+	HeadSHA string
+
+	// DetailsURL links to the full results, eg the logs of the job.
+	DetailsURL string
+}
+
+// PublishCheckRun publishes a completed check run with the findings of a
+// report. The check run fails when a finding is an error, is neutral when
+// there are other findings, and succeeds otherwise. The findings about
+// dependencies with a known line are annotated on that line of their
+// manifest.
+func (g *GitHubClient) PublishCheckRun(
+	ctx context.Context, owner, repo string, r *report.Report, opts CheckRunOptions,
+) (*github.CheckRun, error) {
+	if opts.HeadSHA == "" {
+		return nil, fmt.Errorf("publishing a check run requires the head commit")
+	}
+	if opts.Name == "" {
+		opts.Name = DefaultCheckRunName
+	}
+
+	title := r.Counts().String()
+	summary, err := ReportComment(r)
+	if err != nil || len(summary) > maxCheckRunSummary {
+		summary = title + "."
+	}
+	annotations := checkRunAnnotations(r.Findings)
+	first := annotations
+	if len(first) > maxAnnotations {
+		first = first[:maxAnnotations]
+	}
+
+	create := github.CreateCheckRunOptions{
+		Name:        opts.Name,
+		HeadSHA:     opts.HeadSHA,
+		Status:      github.String("completed"),
+		Conclusion:  github.String(checkRunConclusion(r.Findings)),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output: &github.CheckRunOutput{
+			Title:       &title,
+			Summary:     &summary,
+			Annotations: first,
+		},
+	}
+	if opts.DetailsURL != "" {
+		create.DetailsURL = &opts.DetailsURL
+	}
+	var run *github.CheckRun
+	err = g.retry(ctx, func() (resp *github.Response, err error) {
+		run, resp, err = g.Client.Checks.CreateCheckRun(ctx, owner, repo, create)
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating check run: %w", err)
+	}
+
+	id := run.GetID()
+	for i := len(first); i < len(annotations); i += maxAnnotations {
+		batch := annotations[i:min(i+maxAnnotations, len(annotations))]
+		update := github.UpdateCheckRunOptions{
+			Name: opts.Name,
+			Output: &github.CheckRunOutput{
+				Title:       &title,
+				Summary:     &summary,
+				Annotations: batch,
+			},
+		}
+		err = g.retry(ctx, func() (resp *github.Response, err error) {
+			run, resp, err = g.Client.Checks.UpdateCheckRun(ctx, owner, repo, id, update)
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("annotating check run %d: %w", id, err)
+		}
+	}
+	return run, nil
+}
+
+// checkRunConclusion returns the conclusion of a check run reporting the
+// findings.
+func checkRunConclusion(findings []result.Finding) string {
+	conclusion := "success"
+	for _, f := range findings {
+		if f.Severity == result.SeverityError {
+			return "failure"
+		}
+		conclusion = "neutral"
+	}
+	return conclusion
+}
+
+var annotationLevels = map[result.Severity]string{
+	result.SeverityError:   "failure",
+	result.SeverityWarning: "warning",
+	result.SeverityNote:    "notice",
+}
+
+// checkRunAnnotations annotates the findings on the lines declaring their
+// dependency. Findings without a line are only in the summary.
+func checkRunAnnotations(findings []result.Finding) []*github.CheckRunAnnotation {
+	var res []*github.CheckRunAnnotation
+	for _, f := range findings {
+		if f.Package == nil {
+			continue
+		}
+		dep := &f.Package.Dependency
+		if dep.Location.Path == "" || dep.Location.Line == 0 {
+			continue
+		}
+		level, ok := annotationLevels[f.Severity]
+		if !ok {
+			level = "warning"
+		}
+		res = append(res, &github.CheckRunAnnotation{
+			Path:            github.String(dep.Location.Path),
+			StartLine:       github.Int(dep.Location.Line),
+			EndLine:         github.Int(dep.Location.Line),
+			AnnotationLevel: github.String(level),
+			Title:           github.String(string(f.Rule)),
+			Message:         github.String(f.Message),
+		})
+	}
+	return res
+}
@@ -0,0 +1,128 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+)
+
+// CommentMarker is the hidden marker identifying the comments posted by
+// PostComment when no other marker is given.
+const CommentMarker = "<!-- trusty-sdk-go -->"
+
+// maxCommentLength is the maximum length of an issue comment accepted by
+// GitHub.
+const maxCommentLength = 65536
+
+// PostComment creates or updates the sticky comment of a pull request or
+// issue: the first comment whose body contains the marker, an HTML comment
+// hidden when rendered. The marker defaults to CommentMarker; use another
+// one to keep several sticky comments on the same pull request. It is
+// added to the body when missing.
+func (g *GitHubClient) PostComment(
+	ctx context.Context, owner, repo string, number int, marker, body string,
+) (*github.IssueComment, error) {
+	if marker == "" {
+		marker = CommentMarker
+	}
+	if !strings.Contains(body, marker) {
+		body = marker + "\n" + body
+	}
+
+	existing, err := g.findComment(ctx, owner, repo, number, marker)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.GetBody() == body {
+			return existing, nil
+		}
+		var comment *github.IssueComment
+		err := g.retry(ctx, func() (resp *github.Response, err error) {
+			comment, resp, err = g.Client.Issues.EditComment(ctx, owner, repo, existing.GetID(), &github.IssueComment{Body: &body})
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("updating comment %d: %w", existing.GetID(), err)
+		}
+		return comment, nil
+	}
+	var comment *github.IssueComment
+	err = g.retry(ctx, func() (resp *github.Response, err error) {
+		comment, resp, err = g.Client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &body})
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("commenting on %s/%s#%d: %w", owner, repo, number, err)
+	}
+	return comment, nil
+}
+
+// findComment returns the first comment of an issue containing marker, or
+// nil if there is none.
+func (g *GitHubClient) findComment(
+	ctx context.Context, owner, repo string, number int, marker string,
+) (*github.IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		var comments []*github.IssueComment
+		var resp *github.Response
+		err := g.retry(ctx, func() (_ *github.Response, err error) {
+			comments, resp, err = g.Client.Issues.ListComments(ctx, owner, repo, number, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing the comments of %s/%s#%d: %w", owner, repo, number, err)
+		}
+		for _, c := range comments {
+			if strings.Contains(c.GetBody(), marker) {
+				return c, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ReportComment renders a report as the Markdown body of a comment. When
+// it is too long for GitHub, the folded table of the packages checked is
+// left out.
+func ReportComment(r *report.Report) (string, error) {
+	var buf bytes.Buffer
+	if err := r.WriteMarkdown(&buf); err != nil {
+		return "", err
+	}
+	body := buf.String()
+	if len(body)+len(CommentMarker)+1 <= maxCommentLength {
+		return body, nil
+	}
+	if i := strings.Index(body, "\n<details>"); i >= 0 {
+		body = body[:i] + "\n_The packages checked are not listed, the comment would be too long._\n"
+	}
+	if len(body)+len(CommentMarker)+1 > maxCommentLength {
+		return "", fmt.Errorf("the report is too long for a comment: %d bytes", len(body))
+	}
+	return body, nil
+}
@@ -19,36 +19,146 @@ package githubapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-github/v66/github" // Make sure to use the version of go-github you need
 	"golang.org/x/oauth2"
 )
 
-// GitHubClient wraps the GitHub client and its context
+// GitHubClient wraps the GitHub client. Its methods take the context of
+// the requests they send, and wait for the rate limits of the API to reset
+// when they are reached, until the context is done.
 type GitHubClient struct {
 	Client *github.Client
-	Ctx    context.Context
+
+	mu   sync.Mutex
+	rate github.Rate
+	// contents caches the files fetched by GetFileContent.
+	contents contentCache
 }
 
-// NewGitHubClient initializes and returns a new GitHubClient
+// Options configures NewGitHubClientWithOptions.
+type Options struct {
+	// BaseURL is the URL of a GitHub Enterprise Server, eg
+	// "https://github.example.com/" or the URL of its REST API,
+	// "https://github.example.com/api/v3/". When empty, the client talks to
+	// github.com.
+	BaseURL string
+
+	// Token is a personal access token, or any other OAuth token. It is
+	// ignored when App is set. Requests are anonymous when both are empty.
+	Token string
+
+	// App authenticates as an installation of a GitHub App. Installation
+	// tokens are created and refreshed when they expire.
+	App *AppCredentials
+
+	// HTTPClient sends the requests. Its transport is wrapped to
+	// authenticate them. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// ContentCacheSize is the maximum number of bytes of the files kept by
+	// GetFileContent to send conditional requests, counting their content,
+	// URL and ETag. The memory held by the cache grows up to about this
+	// size, and the least recently used files are evicted first. Zero
+	// means DefaultContentCacheSize, and a negative size disables the
+	// cache.
+	ContentCacheSize int64
+}
+
+// NewGitHubClient initializes and returns a new GitHubClient for github.com,
+// authenticated with a personal access token.
 func NewGitHubClient(token string) *GitHubClient {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	// Errors are about the base URL and the App, which are not set
+	g, _ := NewGitHubClientWithOptions(Options{Token: token})
+	return g
+}
+
+// NewGitHubClientWithOptions returns a GitHubClient configured by opts. It
+// fails when the base URL or the private key of the App are invalid.
+func NewGitHubClientWithOptions(opts Options) (*GitHubClient, error) {
+	base := http.DefaultTransport
+	if opts.HTTPClient != nil && opts.HTTPClient.Transport != nil {
+		base = opts.HTTPClient.Transport
+	}
+	newClient := func(transport http.RoundTripper) (*github.Client, error) {
+		httpClient := &http.Client{Transport: transport}
+		if opts.HTTPClient != nil {
+			httpClient.Timeout = opts.HTTPClient.Timeout
+		}
+		client := github.NewClient(httpClient)
+		if opts.BaseURL == "" {
+			return client, nil
+		}
+		client, err := client.WithEnterpriseURLs(opts.BaseURL, opts.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base URL %q: %w", opts.BaseURL, err)
+		}
+		return client, nil
+	}
 
-	return &GitHubClient{
-		Client: client,
-		Ctx:    ctx,
+	transport := base
+	switch {
+	case opts.App != nil:
+		key, err := parsePrivateKey(opts.App.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		app, err := newClient(&appTransport{base: base, appID: opts.App.AppID, key: key, now: time.Now})
+		if err != nil {
+			return nil, err
+		}
+		transport = newInstallationTransport(base, app, opts.App.InstallationID)
+	case opts.Token != "":
+		transport = &oauth2.Transport{Source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: opts.Token}), Base: base}
 	}
+
+	client, err := newClient(transport)
+	if err != nil {
+		return nil, err
+	}
+	return &GitHubClient{Client: client, contents: contentCache{maxBytes: opts.ContentCacheSize}}, nil
 }
 
-// GetFileContent fetches the content of a file from a specific branch in a repository
-func (g *GitHubClient) GetFileContent(owner, repo, path, ref string) (string, error) {
-	opts := &github.RepositoryContentGetOptions{Ref: ref}
-	fileContent, _, _, err := g.Client.Repositories.GetContents(g.Ctx, owner, repo, path, opts)
+// GetFileContent fetches the content of a file from a specific branch in a repository.
+// Files are cached with their ETag: fetching a file again sends a
+// conditional request, which does not count against the rate limit when
+// the file did not change. The size of the cache is set by
+// Options.ContentCacheSize.
+func (g *GitHubClient) GetFileContent(ctx context.Context, owner, repo, path, ref string) (string, error) {
+	if strings.Contains(path, "..") {
+		return "", github.ErrPathForbidden
+	}
+	u := fmt.Sprintf("repos/%s/%s/contents/%s", owner, repo, (&url.URL{Path: strings.TrimSuffix(path, "/")}).String())
+	if ref != "" {
+		u += "?ref=" + url.QueryEscape(ref)
+	}
+	g.mu.Lock()
+	cached, isCached := g.contents.get(u)
+	g.mu.Unlock()
+
+	var fileContent *github.RepositoryContent
+	var resp *github.Response
+	err := g.retry(ctx, func() (_ *github.Response, err error) {
+		req, err := g.Client.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if isCached {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		resp, err = g.Client.Do(ctx, req, &fileContent)
+		return resp, err
+	})
+	if isCached && resp != nil && resp.StatusCode == http.StatusNotModified {
+		return cached.content, nil
+	}
 	if err != nil {
 		return "", err
 	}
@@ -58,5 +168,17 @@ func (g *GitHubClient) GetFileContent(owner, repo, path, ref string) (string, er
 		return "", err
 	}
 
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		g.mu.Lock()
+		g.contents.add(u, etag, content)
+		g.mu.Unlock()
+	}
 	return content, nil
 }
+
+// IsNotFound returns true if err is a response of the GitHub API saying
+// that the resource requested does not exist.
+func IsNotFound(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound
+}
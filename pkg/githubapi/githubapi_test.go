@@ -0,0 +1,328 @@
+package githubapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/diff"
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/report"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+// fakeRepo serves files of a repository at commits, keyed by "path@ref",
+// with the GitHub contents API.
+type fakeRepo map[string]string
+
+func (f fakeRepo) serveContents(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("path") + "@" + r.URL.Query().Get("ref")
+	content, ok := f[key]
+	if !ok {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]string{
+		"type":     "file",
+		"encoding": "base64",
+		"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// newTestClient returns a client of a fake GitHub API served by mux.
+func newTestClient(t *testing.T, mux *http.ServeMux) *GitHubClient {
+	t.Helper()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	client := github.NewClient(nil)
+	u, err := url.Parse(srv.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = u
+	return &GitHubClient{Client: client}
+}
+
+func TestGetFileContent(t *testing.T) {
+	t.Parallel()
+
+	repo := fakeRepo{"go.mod@main": "module example.com/m\n"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/app/contents/{path...}", repo.serveContents)
+	g := newTestClient(t, mux)
+
+	content, err := g.GetFileContent(context.Background(), "acme", "app", "go.mod", "main")
+	require.NoError(t, err)
+	require.Equal(t, "module example.com/m\n", content)
+
+	_, err = g.GetFileContent(context.Background(), "acme", "app", "go.sum", "main")
+	require.True(t, IsNotFound(err))
+}
+
+func TestGetFileContentETag(t *testing.T) {
+	t.Parallel()
+
+	content := "module example.com/m\n"
+	var requests, notModified int
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/app/contents/go.mod", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := `"` + strconv.Itoa(len(content)) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		writeJSON(w, map[string]string{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+		})
+	})
+	g := newTestClient(t, mux)
+	ctx := context.Background()
+
+	for range 2 {
+		got, err := g.GetFileContent(ctx, "acme", "app", "go.mod", "main")
+		require.NoError(t, err)
+		require.Equal(t, "module example.com/m\n", got)
+	}
+	require.Equal(t, 2, requests)
+	require.Equal(t, 1, notModified)
+
+	content = "module example.com/m\n\ngo 1.23\n"
+	got, err := g.GetFileContent(ctx, "acme", "app", "go.mod", "main")
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+	require.Equal(t, 1, notModified)
+}
+
+func TestScanPullRequest(t *testing.T) {
+	t.Parallel()
+
+	repo := fakeRepo{
+		"package.json@base":         `{"dependencies": {"lodash": "4.17.20", "left-pad": "1.3.0"}}`,
+		"package.json@head":         `{"dependencies": {"lodash": "4.17.21", "evil": "1.0.0"}}`,
+		"requirements.txt@head":     "requests==2.31.0\n",
+		"old/requirements.txt@base": "flask==2.0.0\n",
+		"new/requirements.txt@head": "flask==2.0.0\nclick==8.1.7\n",
+		"removed/go.mod@base":       "module example.com/m\n\nrequire github.com/pkg/errors v0.9.1\n",
+		"broken/package.json@base":  `{}`,
+		"broken/package.json@head":  `{`,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/app/contents/{path...}", repo.serveContents)
+	mux.HandleFunc("GET /repos/acme/app/pulls/7", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, map[string]any{
+			"number": 7,
+			"base":   map[string]string{"sha": "base"},
+			"head":   map[string]string{"sha": "head"},
+		})
+	})
+	mux.HandleFunc("GET /repos/acme/app/pulls/7/files", func(w http.ResponseWriter, r *http.Request) {
+		// Two pages, to check that all the files are listed
+		if r.URL.Query().Get("page") == "2" {
+			writeJSON(w, []map[string]string{
+				{"filename": "removed/go.mod", "status": "removed"},
+				{"filename": "broken/package.json", "status": "modified"},
+			})
+			return
+		}
+		w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next"`)
+		writeJSON(w, []map[string]string{
+			{"filename": "package.json", "status": "modified"},
+			{"filename": "README.md", "status": "modified"},
+			{"filename": "requirements.txt", "status": "added"},
+			{"filename": "new/requirements.txt", "previous_filename": "old/requirements.txt", "status": "renamed"},
+		})
+	})
+	g := newTestClient(t, mux)
+
+	scan, err := g.ScanPullRequest(context.Background(), "acme", "app", 7)
+	require.NoError(t, err)
+	require.Equal(t, "base", scan.Base)
+	require.Equal(t, "head", scan.Head)
+
+	var paths []string
+	for _, f := range scan.Files {
+		paths = append(paths, f.Path)
+	}
+	require.Equal(t, []string{"package.json", "requirements.txt", "new/requirements.txt", "removed/go.mod", "broken/package.json"}, paths)
+	require.ErrorContains(t, scan.Files[4].Err, "parsing broken/package.json at head")
+
+	type change struct {
+		kind                diff.Kind
+		name, before, after string
+	}
+	var changes []change
+	for _, c := range scan.Changes {
+		changes = append(changes, change{c.Kind, c.Name, c.OldVersion(), c.NewVersion()})
+	}
+	require.Equal(t, []change{
+		{diff.KindAdded, "evil", "", "1.0.0"},
+		{diff.KindRemoved, "left-pad", "1.3.0", ""},
+		{diff.KindUpgraded, "lodash", "4.17.20", "4.17.21"},
+		{diff.KindAdded, "requests", "", "2.31.0"},
+		{diff.KindAdded, "click", "", "8.1.7"},
+		{diff.KindRemoved, "github.com/pkg/errors", "v0.9.1", ""},
+	}, changes)
+
+	var introduced []string
+	for _, dep := range scan.Introduced() {
+		introduced = append(introduced, dep.Location.Path+":"+dep.Name)
+	}
+	require.Equal(t, []string{
+		"package.json:evil", "package.json:lodash", "requirements.txt:requests", "new/requirements.txt:click",
+	}, introduced)
+}
+
+func TestScanPullRequestNotFound(t *testing.T) {
+	t.Parallel()
+
+	g := newTestClient(t, http.NewServeMux())
+	_, err := g.ScanPullRequest(context.Background(), "acme", "app", 1)
+	require.ErrorContains(t, err, "getting pull request acme/app#1")
+	require.True(t, IsNotFound(err))
+}
+
+func TestPostComment(t *testing.T) {
+	t.Parallel()
+
+	var created, edited []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/app/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("number") == "7" {
+			writeJSON(w, []map[string]any{
+				{"id": 1, "body": "LGTM"},
+				{"id": 2, "body": CommentMarker + "\nold findings"},
+			})
+			return
+		}
+		writeJSON(w, []map[string]any{})
+	})
+	mux.HandleFunc("POST /repos/acme/app/issues/{number}/comments", func(w http.ResponseWriter, r *http.Request) {
+		var c github.IssueComment
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+		created = append(created, r.PathValue("number")+": "+c.GetBody())
+		writeJSON(w, map[string]any{"id": 3, "body": c.GetBody()})
+	})
+	mux.HandleFunc("PATCH /repos/acme/app/issues/comments/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var c github.IssueComment
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&c))
+		edited = append(edited, r.PathValue("id")+": "+c.GetBody())
+		writeJSON(w, map[string]any{"id": 2, "body": c.GetBody()})
+	})
+	g := newTestClient(t, mux)
+
+	c, err := g.PostComment(context.Background(), "acme", "app", 7, "", "new findings")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), c.GetID())
+	require.Equal(t, []string{"2: " + CommentMarker + "\nnew findings"}, edited)
+
+	// Unchanged comments are not edited
+	_, err = g.PostComment(context.Background(), "acme", "app", 7, "", CommentMarker+"\nold findings")
+	require.NoError(t, err)
+	require.Len(t, edited, 1)
+
+	c, err = g.PostComment(context.Background(), "acme", "app", 8, "<!-- other -->", "findings")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), c.GetID())
+	require.Equal(t, []string{"8: <!-- other -->\nfindings"}, created)
+}
+
+func TestReportComment(t *testing.T) {
+	t.Parallel()
+
+	r := &report.Report{}
+	for i := 0; i < 2000; i++ {
+		r.Packages = append(r.Packages, result.Package{Dependency: parser.Dependency{
+			Name: strings.Repeat("x", 40) + strconv.Itoa(i), Ecosystem: "npm", Version: "1.0.0",
+		}})
+	}
+	body, err := ReportComment(r)
+	require.NoError(t, err)
+	require.Contains(t, body, "2000 packages checked")
+	require.Contains(t, body, "are not listed")
+	require.NotContains(t, body, "<details>")
+
+	r.Packages = r.Packages[:2]
+	body, err = ReportComment(r)
+	require.NoError(t, err)
+	require.Contains(t, body, "<details>")
+}
+
+func TestPublishCheckRun(t *testing.T) {
+	t.Parallel()
+
+	var requests []github.CreateCheckRunOptions
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /repos/acme/app/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		var opts github.CreateCheckRunOptions
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&opts))
+		requests = append(requests, opts)
+		writeJSON(w, map[string]any{"id": 42})
+	})
+	mux.HandleFunc("PATCH /repos/acme/app/check-runs/42", func(w http.ResponseWriter, r *http.Request) {
+		var opts github.CreateCheckRunOptions
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&opts))
+		requests = append(requests, opts)
+		writeJSON(w, map[string]any{"id": 42})
+	})
+	g := newTestClient(t, mux)
+
+	_, err := g.PublishCheckRun(context.Background(), "acme", "app", &report.Report{}, CheckRunOptions{})
+	require.Error(t, err)
+
+	pkg := &result.Package{Dependency: parser.Dependency{
+		Name: "evil", Ecosystem: "npm", Version: "1.0.0", Location: parser.Location{Path: "package.json", Line: 3},
+	}}
+	r := &report.Report{Packages: []result.Package{*pkg}}
+	for i := 0; i < 60; i++ {
+		r.Findings = append(r.Findings, result.Finding{
+			Rule: result.RuleLowScore, Severity: result.SeverityWarning, Package: pkg, Message: "evil@1.0.0 has a low score",
+		})
+	}
+	r.Findings = append(r.Findings, result.Finding{
+		Rule: result.RuleMalicious, Severity: result.SeverityError, Package: pkg, Message: "evil@1.0.0 is malicious",
+	})
+	run, err := g.PublishCheckRun(context.Background(), "acme", "app", r, CheckRunOptions{HeadSHA: "head"})
+	require.NoError(t, err)
+	require.Equal(t, int64(42), run.GetID())
+
+	require.Len(t, requests, 2)
+	require.Equal(t, DefaultCheckRunName, requests[0].Name)
+	require.Equal(t, "head", requests[0].HeadSHA)
+	require.Equal(t, "failure", requests[0].GetConclusion())
+	require.Len(t, requests[0].Output.Annotations, 50)
+	require.Len(t, requests[1].Output.Annotations, 11)
+	last := requests[1].Output.Annotations[10]
+	require.Equal(t, "package.json", last.GetPath())
+	require.Equal(t, 3, last.GetStartLine())
+	require.Equal(t, "failure", last.GetAnnotationLevel())
+	require.Equal(t, "evil@1.0.0 is malicious", last.GetMessage())
+	require.Contains(t, requests[0].Output.GetSummary(), "1 package checked, 61 findings")
+}
+
+func TestCheckRunConclusion(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "success", checkRunConclusion(nil))
+	require.Equal(t, "neutral", checkRunConclusion([]result.Finding{{Severity: result.SeverityNote}}))
+	require.Equal(t, "failure", checkRunConclusion([]result.Finding{
+		{Severity: result.SeverityWarning}, {Severity: result.SeverityError},
+	}))
+}
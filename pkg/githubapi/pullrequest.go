@@ -0,0 +1,173 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v66/github"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/diff"
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+)
+
+// PullRequestScan is the result of scanning the dependency changes of a
+// pull request.
+type PullRequestScan struct {
+	// Base and Head are the commits compared.
+	Base string
+	Head string
+
+	// Files are the manifests and lockfiles changed by the pull request.
+	Files []ManifestChange
+
+	// Changes are the dependency changes of all the files, in the order of
+	// Files.
+	Changes []diff.Change
+}
+
+// ManifestChange is a manifest or lockfile changed by a pull request.
+type ManifestChange struct {
+	// Path is the path of the file at the head commit. It is the path at the
+	// base commit when the file is removed.
+	Path string
+	// PreviousPath is the path at the base commit of a renamed file.
+	PreviousPath string
+	// Status is the status of the file reported by GitHub, eg "added",
+	// "modified", "renamed" or "removed".
+	Status string
+	// Changes are the dependency changes of the file.
+	Changes []diff.Change
+	// Err is the error fetching or parsing the file, when it could not be
+	// compared. The other files are still compared.
+	Err error
+}
+
+// Introduced returns the dependencies added by the pull request, or whose
+// version it changed, in their version at the head commit. A dependency
+// found in several files is only returned once, with the location of its
+// first occurrence.
+func (s *PullRequestScan) Introduced() []parser.Dependency {
+	type key struct{ ecosystem, name, version string }
+	seen := map[key]bool{}
+	var res []parser.Dependency
+	for i := range s.Changes {
+		c := &s.Changes[i]
+		if c.New == nil {
+			continue
+		}
+		k := key{c.Ecosystem, c.Name, c.NewVersion()}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		res = append(res, *c.New)
+	}
+	return res
+}
+
+// ScanPullRequest compares the manifests and lockfiles changed by a pull
+// request between its base and head commits. Only the files supported by
+// parser.ParseDetailed are fetched, from the base repository, so that pull
+// requests from forks are scanned too. Errors about a single file are
+// reported in its ManifestChange.
+func (g *GitHubClient) ScanPullRequest(ctx context.Context, owner, repo string, number int) (*PullRequestScan, error) {
+	var pr *github.PullRequest
+	err := g.retry(ctx, func() (resp *github.Response, err error) {
+		pr, resp, err = g.Client.PullRequests.Get(ctx, owner, repo, number)
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting pull request %s/%s#%d: %w", owner, repo, number, err)
+	}
+	scan := &PullRequestScan{
+		Base: pr.GetBase().GetSHA(),
+		Head: pr.GetHead().GetSHA(),
+	}
+
+	files, err := g.pullRequestFiles(ctx, owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		change := ManifestChange{
+			Path:         f.GetFilename(),
+			PreviousPath: f.GetPreviousFilename(),
+			Status:       f.GetStatus(),
+		}
+		basePath := change.Path
+		if change.PreviousPath != "" {
+			basePath = change.PreviousPath
+		}
+		if !parser.Supported(change.Path) && !parser.Supported(basePath) {
+			continue
+		}
+
+		var baseDeps, headDeps []parser.Dependency
+		if change.Status != "added" {
+			baseDeps, change.Err = g.readDependencies(ctx, owner, repo, basePath, scan.Base)
+		}
+		if change.Err == nil && change.Status != "removed" {
+			headDeps, change.Err = g.readDependencies(ctx, owner, repo, change.Path, scan.Head)
+		}
+		if change.Err == nil {
+			change.Changes = diff.Dependencies(baseDeps, headDeps)
+			scan.Changes = append(scan.Changes, change.Changes...)
+		}
+		scan.Files = append(scan.Files, change)
+	}
+	return scan, nil
+}
+
+// pullRequestFiles lists all the files changed by a pull request.
+func (g *GitHubClient) pullRequestFiles(ctx context.Context, owner, repo string, number int) ([]*github.CommitFile, error) {
+	var res []*github.CommitFile
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var files []*github.CommitFile
+		var resp *github.Response
+		err := g.retry(ctx, func() (_ *github.Response, err error) {
+			files, resp, err = g.Client.PullRequests.ListFiles(ctx, owner, repo, number, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing the files of pull request %s/%s#%d: %w", owner, repo, number, err)
+		}
+		res = append(res, files...)
+		if resp.NextPage == 0 {
+			return res, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// readDependencies fetches and parses a file at a commit. A missing file
+// has no dependencies.
+func (g *GitHubClient) readDependencies(ctx context.Context, owner, repo, path, ref string) ([]parser.Dependency, error) {
+	content, err := g.GetFileContent(ctx, owner, repo, path, ref)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s at %s: %w", path, ref, err)
+	}
+	deps, _, err := parser.ParseDetailed(path, content, parser.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s at %s: %w", path, ref, err)
+	}
+	return deps, nil
+}
@@ -0,0 +1,71 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// Rate returns the rate limit of the API reported by the last response,
+// eg to schedule work before the quota runs out. It is zero until a
+// request is sent.
+func (g *GitHubClient) Rate() github.Rate {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rate
+}
+
+// retry calls fn, which sends a request, until it does not fail because of
+// a rate limit. Between the calls, it waits for the primary rate limit to
+// reset, or for the delay asked by a secondary rate limit, unless ctx is
+// done first. The rate limit reported by the response is recorded.
+func (g *GitHubClient) retry(ctx context.Context, fn func() (*github.Response, error)) error {
+	for {
+		resp, err := fn()
+		if resp != nil && resp.Rate.Limit > 0 {
+			g.mu.Lock()
+			g.rate = resp.Rate
+			g.mu.Unlock()
+		}
+		wait, limited := rateLimitWait(err)
+		if !limited {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// rateLimitWait returns how long to wait before retrying a request that
+// failed with err, when it failed because of a rate limit.
+func rateLimitWait(err error) (time.Duration, bool) {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return max(time.Until(rateErr.Rate.Reset.Time), time.Second), true
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		return max(abuseErr.GetRetryAfter(), time.Second), true
+	}
+	return 0, false
+}
@@ -0,0 +1,76 @@
+package githubapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	repo := fakeRepo{"go.mod@main": "module example.com/m\n"}
+	var limited atomic.Int32
+	limited.Store(1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/acme/app/contents/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+		if limited.Add(-1) >= 0 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			writeJSON(w, map[string]string{"message": "API rate limit exceeded"})
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		repo.serveContents(w, r)
+	})
+	g := newTestClient(t, mux)
+	require.Zero(t, g.Rate())
+
+	content, err := g.GetFileContent(context.Background(), "acme", "app", "go.mod", "main")
+	require.NoError(t, err)
+	require.Equal(t, "module example.com/m\n", content)
+	require.Equal(t, 5000, g.Rate().Limit)
+	require.Equal(t, 4999, g.Rate().Remaining)
+
+	limited.Store(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = g.GetFileContent(ctx, "acme", "app", "go.mod", "main")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	var rateErr *github.RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	require.Equal(t, 0, g.Rate().Remaining)
+}
+
+func TestRateLimitWait(t *testing.T) {
+	t.Parallel()
+
+	reset := github.Timestamp{Time: time.Now().Add(time.Hour)}
+	wait, ok := rateLimitWait(&github.RateLimitError{Rate: github.Rate{Reset: reset}})
+	require.True(t, ok)
+	require.InDelta(t, time.Hour, wait, float64(time.Minute))
+
+	wait, ok = rateLimitWait(&github.AbuseRateLimitError{RetryAfter: ptr(30 * time.Second)})
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, wait)
+
+	wait, ok = rateLimitWait(&github.AbuseRateLimitError{})
+	require.True(t, ok)
+	require.Equal(t, time.Second, wait)
+
+	_, ok = rateLimitWait(errors.New("boom"))
+	require.False(t, ok)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,362 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package githubapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+)
+
+// defaultTreeWorkers is the number of blobs fetched concurrently by
+// ReadTree when TreeOptions.Workers is not set.
+const defaultTreeWorkers = 4
+
+// TreeOptions configures ReadTree.
+type TreeOptions struct {
+	// Include selects the files to fetch by their slash separated path. It
+	// defaults to parser.Supported, which selects the manifests and
+	// lockfiles the parsers understand.
+	Include func(path string) bool
+
+	// Ignore holds the glob patterns of the files and directories to
+	// skip, as understood by parser.ScanOptions. When nil,
+	// parser.DefaultIgnore is used.
+	Ignore []string
+
+	// Workers is the number of files fetched concurrently.
+	Workers int
+}
+
+// ReadTree fetches the files of a repository at a ref (a branch, a tag or
+// a commit SHA) without cloning it, and returns them as a read-only file
+// system, eg to run parser.Scan on it. Only the files selected by the
+// options are fetched, the file system has no other file.
+//
+// The tree is listed with a single request when GitHub can return it
+// whole, and directory by directory when the listing is truncated. Files
+// are fetched concurrently; when the rate limit of the API is reached, the
+// workers wait for it to reset.
+func (g *GitHubClient) ReadTree(ctx context.Context, owner, repo, ref string, opts TreeOptions) (fs.FS, error) {
+	if opts.Include == nil {
+		opts.Include = parser.Supported
+	}
+	if opts.Ignore == nil {
+		opts.Ignore = parser.DefaultIgnore
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultTreeWorkers
+	}
+
+	entries, err := g.treeEntries(ctx, owner, repo, ref, opts.Ignore)
+	if err != nil {
+		return nil, err
+	}
+	var blobs []*github.TreeEntry
+	for _, e := range entries {
+		if e.GetType() == "blob" && opts.Include(e.GetPath()) && !ignoredPath(e.GetPath(), opts.Ignore) {
+			blobs = append(blobs, e)
+		}
+	}
+
+	contents, err := g.fetchBlobs(ctx, owner, repo, blobs, opts.Workers)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(blobs))
+	for _, b := range blobs {
+		files[b.GetPath()] = contents[b.GetSHA()]
+	}
+	return newTreeFS(files), nil
+}
+
+// treeEntries lists the entries of the tree at ref, recursively.
+func (g *GitHubClient) treeEntries(ctx context.Context, owner, repo, ref string, ignore []string) ([]*github.TreeEntry, error) {
+	tree, err := g.tree(ctx, owner, repo, ref, true)
+	if err != nil {
+		return nil, fmt.Errorf("listing the tree of %s/%s at %s: %w", owner, repo, ref, err)
+	}
+	if !tree.GetTruncated() {
+		return tree.Entries, nil
+	}
+
+	// The tree is too big to be listed at once: list the directories one
+	// by one, skipping the ignored ones.
+	var res []*github.TreeEntry
+	type dir struct{ path, sha string }
+	pending := []dir{{sha: ref}}
+	for len(pending) > 0 {
+		d := pending[0]
+		pending = pending[1:]
+		tree, err := g.tree(ctx, owner, repo, d.sha, false)
+		if err != nil {
+			return nil, fmt.Errorf("listing the tree of %s/%s at %s: %w", owner, repo, path.Join(ref, d.path), err)
+		}
+		for _, e := range tree.Entries {
+			p := e.GetPath()
+			if d.path != "" {
+				p = d.path + "/" + p
+			}
+			if parser.Ignored(p, ignore) {
+				continue
+			}
+			e.Path = &p
+			if e.GetType() == "tree" {
+				pending = append(pending, dir{path: p, sha: e.GetSHA()})
+			}
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+// tree lists the entries of a tree, waiting for the rate limit to reset
+// when it is reached.
+func (g *GitHubClient) tree(ctx context.Context, owner, repo, sha string, recursive bool) (*github.Tree, error) {
+	var tree *github.Tree
+	err := g.retry(ctx, func() (resp *github.Response, err error) {
+		tree, resp, err = g.Client.Git.GetTree(ctx, owner, repo, sha, recursive)
+		return resp, err
+	})
+	return tree, err
+}
+
+// ignoredPath returns true if the path or one of its parent directories is
+// ignored.
+func ignoredPath(p string, ignore []string) bool {
+	for i := 0; i <= len(p); i++ {
+		if (i == len(p) || p[i] == '/') && parser.Ignored(p[:i], ignore) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchBlobs fetches the content of blobs with concurrent workers. Blobs
+// are fetched once per SHA, files with the same content share it.
+func (g *GitHubClient) fetchBlobs(
+	ctx context.Context, owner, repo string, blobs []*github.TreeEntry, workers int,
+) (map[string][]byte, error) {
+	shas := make(chan string)
+	var mu sync.Mutex
+	res := map[string][]byte{}
+	var errs []error
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sha := range shas {
+				content, err := g.blob(ctx, owner, repo, sha)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					res[sha] = content
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := map[string]bool{}
+	for _, b := range blobs {
+		if seen[b.GetSHA()] {
+			continue
+		}
+		seen[b.GetSHA()] = true
+		select {
+		case shas <- b.GetSHA():
+		case <-ctx.Done():
+		}
+	}
+	close(shas)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return res, errors.Join(errs...)
+}
+
+// blob fetches the content of a blob, waiting for the rate limit to reset
+// when it is reached.
+func (g *GitHubClient) blob(ctx context.Context, owner, repo, sha string) ([]byte, error) {
+	var content []byte
+	err := g.retry(ctx, func() (resp *github.Response, err error) {
+		content, resp, err = g.Client.Git.GetBlobRaw(ctx, owner, repo, sha)
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetching blob %s: %w", sha, err)
+	}
+	return content, nil
+}
+
+// treeFS is a read-only file system holding files in memory. Directories
+// are the parents of the files.
+type treeFS struct {
+	files map[string][]byte
+	dirs  map[string][]fs.DirEntry
+}
+
+func newTreeFS(files map[string][]byte) *treeFS {
+	t := &treeFS{files: files, dirs: map[string][]fs.DirEntry{".": nil}}
+	children := map[string]map[string]bool{}
+	add := func(dir, name string, isDir bool) {
+		if children[dir] == nil {
+			children[dir] = map[string]bool{}
+		}
+		if children[dir][name] {
+			return
+		}
+		children[dir][name] = true
+		t.dirs[dir] = append(t.dirs[dir], &treeEntry{name: name, isDir: isDir, size: int64(len(files[path.Join(dir, name)]))})
+	}
+	for p := range files {
+		name, isDir := p, false
+		for {
+			dir := path.Dir(name)
+			add(dir, path.Base(name), isDir)
+			if dir == "." {
+				break
+			}
+			name, isDir = dir, true
+		}
+	}
+	for dir := range t.dirs {
+		entries := t.dirs[dir]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	}
+	return t
+}
+
+func (t *treeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if content, ok := t.files[name]; ok {
+		return &treeFile{
+			entry:  treeEntry{name: path.Base(name), size: int64(len(content))},
+			Reader: strings.NewReader(string(content)),
+		}, nil
+	}
+	if entries, ok := t.dirs[name]; ok {
+		return &treeDir{entry: treeEntry{name: path.Base(name), isDir: true}, entries: entries}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (t *treeFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	content, ok := t.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), content...), nil
+}
+
+func (t *treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, ok := t.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]fs.DirEntry(nil), entries...), nil
+}
+
+// treeEntry describes a file or directory of a treeFS. It is both its
+// fs.FileInfo and its fs.DirEntry.
+type treeEntry struct {
+	name  string
+	isDir bool
+	size  int64
+}
+
+func (e *treeEntry) Name() string { return e.name }
+
+func (e *treeEntry) Size() int64 { return e.size }
+
+func (e *treeEntry) Mode() fs.FileMode {
+	if e.isDir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+func (e *treeEntry) ModTime() time.Time { return time.Time{} }
+
+func (e *treeEntry) IsDir() bool { return e.isDir }
+
+func (e *treeEntry) Sys() any { return nil }
+
+func (e *treeEntry) Type() fs.FileMode { return e.Mode().Type() }
+
+func (e *treeEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+type treeFile struct {
+	entry treeEntry
+	*strings.Reader
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) { return &f.entry, nil }
+
+func (f *treeFile) Close() error { return nil }
+
+type treeDir struct {
+	entry   treeEntry
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *treeDir) Stat() (fs.FileInfo, error) { return &d.entry, nil }
+
+func (d *treeDir) Close() error { return nil }
+
+func (d *treeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.entry.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *treeDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return append([]fs.DirEntry(nil), rest...), nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return append([]fs.DirEntry(nil), rest[:n]...), nil
+}
@@ -0,0 +1,158 @@
+package githubapi
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+)
+
+// fakeTree serves a git tree, whose directories are keyed by their SHA,
+// and the blobs it references.
+type fakeTree struct {
+	trees map[string][]map[string]string
+	blobs map[string]string
+	// truncated makes the recursive listing truncated.
+	truncated bool
+	// limited is the number of blob requests answered with a secondary
+	// rate limit error.
+	limited atomic.Int32
+	fetched atomic.Int32
+}
+
+func (f *fakeTree) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /repos/acme/app/git/trees/{sha}", func(w http.ResponseWriter, r *http.Request) {
+		sha := r.PathValue("sha")
+		if r.URL.Query().Get("recursive") == "" {
+			writeJSON(w, map[string]any{"sha": sha, "tree": f.trees[sha]})
+			return
+		}
+		var entries []map[string]string
+		var walk func(prefix, sha string)
+		walk = func(prefix, sha string) {
+			for _, e := range f.trees[sha] {
+				entry := map[string]string{"path": prefix + e["path"], "type": e["type"], "sha": e["sha"]}
+				entries = append(entries, entry)
+				if e["type"] == "tree" {
+					walk(prefix+e["path"]+"/", e["sha"])
+				}
+			}
+		}
+		walk("", sha)
+		if f.truncated {
+			entries = entries[:1]
+		}
+		writeJSON(w, map[string]any{"sha": sha, "tree": entries, "truncated": f.truncated})
+	})
+	mux.HandleFunc("GET /repos/acme/app/git/blobs/{sha}", func(w http.ResponseWriter, r *http.Request) {
+		if f.limited.Add(-1) >= 0 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			writeJSON(w, map[string]string{
+				"message":           "You have exceeded a secondary rate limit.",
+				"documentation_url": "https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits",
+			})
+			return
+		}
+		f.fetched.Add(1)
+		_, _ = w.Write([]byte(f.blobs[r.PathValue("sha")]))
+	})
+}
+
+func newFakeTree() *fakeTree {
+	return &fakeTree{
+		trees: map[string][]map[string]string{
+			"main": {
+				{"path": "README.md", "type": "blob", "sha": "readme"},
+				{"path": "go.mod", "type": "blob", "sha": "gomod"},
+				{"path": "web", "type": "tree", "sha": "web"},
+				{"path": "vendor", "type": "tree", "sha": "vendor"},
+			},
+			"web": {
+				{"path": "package.json", "type": "blob", "sha": "pkg"},
+				{"path": "package-lock.json", "type": "blob", "sha": "lock"},
+				{"path": "legacy", "type": "tree", "sha": "legacy"},
+			},
+			"legacy": {
+				{"path": "package.json", "type": "blob", "sha": "pkg"},
+			},
+			"vendor": {
+				{"path": "go.mod", "type": "blob", "sha": "vendored"},
+			},
+		},
+		blobs: map[string]string{
+			"readme":   "# App\n",
+			"gomod":    "module example.com/app\n\nrequire github.com/pkg/errors v0.9.1\n",
+			"pkg":      `{"dependencies": {"lodash": "^4.17.21"}}`,
+			"lock":     `{"lockfileVersion": 3, "packages": {"": {}, "node_modules/lodash": {"version": "4.17.21"}}}`,
+			"vendored": "module example.com/vendored\n",
+		},
+	}
+}
+
+func TestReadTree(t *testing.T) {
+	t.Parallel()
+
+	for _, truncated := range []bool{false, true} {
+		t.Run("truncated="+strconv.FormatBool(truncated), func(t *testing.T) {
+			t.Parallel()
+
+			tree := newFakeTree()
+			tree.truncated = truncated
+			mux := http.NewServeMux()
+			tree.register(mux)
+			g := newTestClient(t, mux)
+
+			fsys, err := g.ReadTree(context.Background(), "acme", "app", "main", TreeOptions{})
+			require.NoError(t, err)
+			require.NoError(t, fstest.TestFS(fsys, "go.mod", "web/package.json", "web/package-lock.json", "web/legacy/package.json"))
+
+			_, err = fs.Stat(fsys, "README.md")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+			_, err = fs.Stat(fsys, "vendor/go.mod")
+			require.ErrorIs(t, err, fs.ErrNotExist)
+			// The two package.json files have the same content, fetched once
+			require.Equal(t, int32(3), tree.fetched.Load())
+
+			projects, err := parser.Scan(fsys, parser.ScanOptions{})
+			require.NoError(t, err)
+			require.Len(t, projects, 3)
+			require.Equal(t, ".", projects[0].Root)
+			require.Equal(t, "github.com/pkg/errors", projects[0].Dependencies[0].Name)
+		})
+	}
+}
+
+func TestReadTreeRateLimit(t *testing.T) {
+	t.Parallel()
+
+	tree := newFakeTree()
+	tree.limited.Store(2)
+	mux := http.NewServeMux()
+	tree.register(mux)
+	g := newTestClient(t, mux)
+
+	fsys, err := g.ReadTree(context.Background(), "acme", "app", "main", TreeOptions{
+		Include: func(p string) bool { return p == "go.mod" },
+		Workers: 1,
+	})
+	require.NoError(t, err)
+	content, err := fs.ReadFile(fsys, "go.mod")
+	require.NoError(t, err)
+	require.Contains(t, string(content), "example.com/app")
+	require.Equal(t, int32(-1), tree.limited.Load())
+
+	tree.limited.Store(100)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = g.ReadTree(ctx, "acme", "app", "main", TreeOptions{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
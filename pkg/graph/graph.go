@@ -0,0 +1,215 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graph builds dependency graphs out of the parser output to
+// explain how a package ends up in a project, and which of the project's
+// direct dependencies brings it in.
+package graph
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+)
+
+// Root is the ID of the node representing the project itself.
+const Root = ""
+
+// MaxPaths caps the number of paths returned by Graph.Paths, as large
+// trees with many shared dependencies can have an exponential number.
+const MaxPaths = 1000
+
+// Graph is a directed graph of the dependencies of a project. Its nodes
+// are identified by the ID of the dependencies, and edges go from a
+// dependency to the ones it requires.
+type Graph struct {
+	nodes    map[string]*parser.Dependency
+	children map[string][]string
+	parents  map[string][]string
+	byName   map[string][]string
+}
+
+// New builds the graph of the given dependencies, as returned by
+// parser.ParseDetailed for a lockfile. Direct dependencies hang from the
+// root, as do dependencies whose parents are unknown. Lockfiles without a
+// graph, such as go.sum, therefore give a flat graph, whose paths and
+// depths say nothing about how the packages are pulled in.
+func New(deps []parser.Dependency) *Graph {
+	g := &Graph{
+		nodes:    map[string]*parser.Dependency{},
+		children: map[string][]string{},
+		parents:  map[string][]string{},
+		byName:   map[string][]string{},
+	}
+	for i := range deps {
+		id := deps[i].ID()
+		if _, ok := g.nodes[id]; ok {
+			continue
+		}
+		g.nodes[id] = &deps[i]
+		g.byName[deps[i].Name] = append(g.byName[deps[i].Name], id)
+	}
+
+	for id, dep := range g.nodes {
+		attached := false
+		for _, parent := range dep.Parents {
+			if _, ok := g.nodes[parent]; ok && parent != id {
+				g.addEdge(parent, id)
+				attached = true
+			}
+		}
+		if dep.Direct || !attached {
+			g.addEdge(Root, id)
+		}
+	}
+
+	for _, edges := range []map[string][]string{g.children, g.parents, g.byName} {
+		for _, ids := range edges {
+			sort.Strings(ids)
+		}
+	}
+	return g
+}
+
+// FromFile parses a manifest or lockfile and builds the graph of its
+// dependencies.
+func FromFile(path, content string) (*Graph, error) {
+	deps, _, err := parser.ParseDetailed(path, content, parser.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return New(deps), nil
+}
+
+func (g *Graph) addEdge(from, to string) {
+	g.children[from] = append(g.children[from], to)
+	g.parents[to] = append(g.parents[to], from)
+}
+
+// Dependency returns the dependency with the given ID.
+func (g *Graph) Dependency(id string) (*parser.Dependency, bool) {
+	dep, ok := g.nodes[id]
+	return dep, ok
+}
+
+// Lookup returns the IDs of all the versions of a package in the graph.
+func (g *Graph) Lookup(name string) []string {
+	return g.byName[name]
+}
+
+// Children returns the IDs of the dependencies required by the node with
+// the given ID. Use Root to get the direct dependencies of the project.
+func (g *Graph) Children(id string) []string {
+	return g.children[id]
+}
+
+// Paths returns every path from the root project to the dependency with
+// the given ID, up to MaxPaths. Each path starts with a direct dependency
+// and ends with id.
+func (g *Graph) Paths(id string) [][]string {
+	if _, ok := g.nodes[id]; !ok {
+		return nil
+	}
+
+	var paths [][]string
+	onPath := map[string]bool{}
+	var walk func(node string, suffix []string)
+	walk = func(node string, suffix []string) {
+		if len(paths) >= MaxPaths {
+			return
+		}
+		onPath[node] = true
+		defer delete(onPath, node)
+
+		suffix = append([]string{node}, suffix...)
+		for _, parent := range g.parents[node] {
+			if parent == Root {
+				paths = append(paths, suffix)
+				continue
+			}
+			if !onPath[parent] {
+				walk(parent, suffix)
+			}
+		}
+	}
+	walk(id, nil)
+
+	sort.Slice(paths, func(i, j int) bool {
+		if len(paths[i]) != len(paths[j]) {
+			return len(paths[i]) < len(paths[j])
+		}
+		return fmt.Sprint(paths[i]) < fmt.Sprint(paths[j])
+	})
+	return paths
+}
+
+// Depth returns the length of the shortest path from the root project to
+// the dependency with the given ID: 1 for direct dependencies, 2 for their
+// own dependencies and so on. It returns -1 when the dependency is not in
+// the graph.
+func (g *Graph) Depth(id string) int {
+	if _, ok := g.nodes[id]; !ok {
+		return -1
+	}
+
+	depth := map[string]int{Root: 0}
+	queue := []string{Root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, child := range g.children[node] {
+			if _, seen := depth[child]; seen {
+				continue
+			}
+			depth[child] = depth[node] + 1
+			if child == id {
+				return depth[child]
+			}
+			queue = append(queue, child)
+		}
+	}
+	return -1
+}
+
+// DirectDependencies returns the IDs of the direct dependencies of the
+// project that bring in the dependency with the given ID. These are the
+// ones a developer can change to get rid of it. A direct dependency is
+// reported as responsible for itself.
+func (g *Graph) DirectDependencies(id string) []string {
+	if _, ok := g.nodes[id]; !ok {
+		return nil
+	}
+
+	var direct []string
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, parent := range g.parents[node] {
+			if parent == Root {
+				direct = append(direct, node)
+				continue
+			}
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	sort.Strings(direct)
+	return direct
+}
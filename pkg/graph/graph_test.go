@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+)
+
+func TestGraph(t *testing.T) {
+	t.Parallel()
+
+	// app -> a -> c -> evil
+	// app -> b -> evil
+	// app -> b -> c
+	deps := []parser.Dependency{
+		{Name: "a", Version: "1.0.0", Direct: true},
+		{Name: "b", Version: "2.0.0", Direct: true},
+		{Name: "c", Version: "3.0.0", Parents: []string{"a@1.0.0", "b@2.0.0"}},
+		{Name: "evil", Version: "6.6.6", Parents: []string{"b@2.0.0", "c@3.0.0"}},
+		{Name: "orphan", Version: "0.1.0", Parents: []string{"unknown@1.0.0"}},
+	}
+	g := New(deps)
+
+	require.Equal(t, []string{"a@1.0.0", "b@2.0.0", "orphan@0.1.0"}, g.Children(Root))
+	require.Equal(t, []string{"evil@6.6.6"}, g.Lookup("evil"))
+
+	dep, ok := g.Dependency("evil@6.6.6")
+	require.True(t, ok)
+	require.Equal(t, "evil", dep.Name)
+
+	require.Equal(t, [][]string{
+		{"b@2.0.0", "evil@6.6.6"},
+		{"a@1.0.0", "c@3.0.0", "evil@6.6.6"},
+		{"b@2.0.0", "c@3.0.0", "evil@6.6.6"},
+	}, g.Paths("evil@6.6.6"))
+	require.Equal(t, [][]string{{"a@1.0.0"}}, g.Paths("a@1.0.0"))
+	require.Nil(t, g.Paths("missing"))
+
+	require.Equal(t, 1, g.Depth("a@1.0.0"))
+	require.Equal(t, 2, g.Depth("evil@6.6.6"))
+	require.Equal(t, 1, g.Depth("orphan@0.1.0"))
+	require.Equal(t, -1, g.Depth("missing"))
+
+	require.Equal(t, []string{"a@1.0.0", "b@2.0.0"}, g.DirectDependencies("evil@6.6.6"))
+	require.Equal(t, []string{"b@2.0.0"}, g.DirectDependencies("b@2.0.0"))
+	require.Nil(t, g.DirectDependencies("missing"))
+}
+
+func TestFromFile(t *testing.T) {
+	t.Parallel()
+
+	content := `[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = ["rand"]
+
+[[package]]
+name = "rand"
+version = "0.8.5"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+dependencies = ["rand_core"]
+
+[[package]]
+name = "rand_core"
+version = "0.6.4"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`
+	g, err := FromFile("Cargo.lock", content)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"rand@0.8.5", "rand_core@0.6.4"}}, g.Paths("rand_core@0.6.4"))
+	require.Equal(t, []string{"rand@0.8.5"}, g.DirectDependencies("rand_core@0.6.4"))
+
+	_, err = FromFile("Cargo.lock", "[[package]")
+	require.Error(t, err)
+}
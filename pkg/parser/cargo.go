@@ -16,6 +16,9 @@
 package parser
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/BurntSushi/toml"
 
 	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
@@ -24,17 +27,91 @@ import (
 // ParseCargoToml parses the content of a Cargo.toml file and returns a slice of dependencies.
 // It takes a string parameter `content` which represents the content of the Cargo.toml file.
 // The function returns a slice of `types.Dependency` and an error if any occurred during parsing.
+// Only the entries of the dependencies table that are version strings are returned; ParseDetailed
+// also returns the development and build dependencies, and the ones declared with a table.
 func ParseCargoToml(content string) ([]types.Dependency, error) {
+	deps, err := parseCargoToml(content)
+	if err != nil {
+		return nil, err
+	}
 	var conf struct {
-		Dependencies map[string]string `toml:"dependencies"`
+		Dependencies map[string]any `toml:"dependencies"`
 	}
 	if _, err := toml.Decode(content, &conf); err != nil {
 		return nil, err
 	}
+	var legacy []Dependency
+	for _, dep := range deps {
+		if _, ok := conf.Dependencies[dep.Name].(string); ok && dep.DeclaredScope == "" {
+			legacy = append(legacy, dep)
+		}
+	}
+	return toV1Dependencies(legacy), nil
+}
 
-	var deps []types.Dependency
-	for name, version := range conf.Dependencies {
-		deps = append(deps, types.Dependency{Name: name, Version: version})
+// parseCargoToml parses the dependencies, dev-dependencies and
+// build-dependencies tables of a Cargo.toml file. Dependencies can be
+// declared either with a version string or with a table.
+func parseCargoToml(content string) ([]Dependency, error) {
+	var conf struct {
+		Dependencies      map[string]any `toml:"dependencies"`
+		DevDependencies   map[string]any `toml:"dev-dependencies"`
+		BuildDependencies map[string]any `toml:"build-dependencies"`
+	}
+	if _, err := toml.Decode(content, &conf); err != nil {
+		return nil, err
+	}
+
+	loc := newLocator(content)
+	var deps []Dependency
+	for _, table := range []struct {
+		name    string
+		entries map[string]any
+		scope   Scope
+		kind    string
+	}{
+		{name: "dependencies", entries: conf.Dependencies, scope: ScopeRuntime},
+		{name: "dev-dependencies", entries: conf.DevDependencies, scope: ScopeDev, kind: "dev"},
+		{name: "build-dependencies", entries: conf.BuildDependencies, scope: ScopeBuild, kind: "build"},
+	} {
+		keys := make([]string, 0, len(table.entries))
+		for key := range table.entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			name, version, err := cargoDependency(key, table.entries[key])
+			if err != nil {
+				return nil, err
+			}
+			deps = append(deps, Dependency{
+				Name:          name,
+				Constraint:    version,
+				Scope:         table.scope,
+				DeclaredScope: table.kind,
+				Direct:        true,
+				Location:      loc.findKey("["+table.name+"]", key),
+			})
+		}
 	}
 	return deps, nil
 }
+
+// cargoDependency returns the package name and version requirement of a
+// dependency entry, honoring renames through the package key.
+func cargoDependency(key string, entry any) (name, version string, err error) {
+	switch e := entry.(type) {
+	case string:
+		return key, e, nil
+	case map[string]any:
+		name = key
+		if pkg, ok := e["package"].(string); ok {
+			name = pkg
+		}
+		version, _ = e["version"].(string)
+		return name, version, nil
+	default:
+		return "", "", fmt.Errorf("invalid definition for dependency %q", key)
+	}
+}
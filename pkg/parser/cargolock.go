@@ -0,0 +1,102 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+type cargoLockPackage struct {
+	Name         string   `toml:"name"`
+	Version      string   `toml:"version"`
+	Source       string   `toml:"source"`
+	Dependencies []string `toml:"dependencies"`
+}
+
+// ParseCargoLock parses the content of a Cargo.lock file and returns the
+// locked crates. The crates of the project itself are not returned.
+func ParseCargoLock(content string) ([]types.Dependency, error) {
+	deps, err := parseCargoLock(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseCargoLock parses a Cargo.lock file. Packages without a source are
+// the crates of the project (or its workspace members), and the crates
+// they depend on are its direct dependencies.
+func parseCargoLock(content string) ([]Dependency, error) {
+	var lock struct {
+		Packages []cargoLockPackage `toml:"package"`
+	}
+	if _, err := toml.Decode(content, &lock); err != nil {
+		return nil, err
+	}
+
+	locations := tomlTableLocations(content, "[[package]]")
+	versions := map[string][]string{}
+	b := newLockBuilder()
+	for i, pkg := range lock.Packages {
+		key := pkg.Name + " " + pkg.Version
+		versions[pkg.Name] = append(versions[pkg.Name], pkg.Version)
+		if pkg.Source == "" {
+			continue
+		}
+		dep := Dependency{Name: pkg.Name, Version: pkg.Version, Scope: ScopeRuntime}
+		if i < len(locations) {
+			dep.Location = locations[i]
+		}
+		b.add(key, dep)
+	}
+
+	for _, pkg := range lock.Packages {
+		for _, ref := range pkg.Dependencies {
+			child, err := resolveCargoRef(ref, versions)
+			if err != nil {
+				return nil, fmt.Errorf("package %s %s: %w", pkg.Name, pkg.Version, err)
+			}
+			if pkg.Source == "" {
+				b.markDirect(child)
+			} else {
+				b.link(pkg.Name+" "+pkg.Version, child)
+			}
+		}
+	}
+	return b.dependencies(), nil
+}
+
+// resolveCargoRef returns the "name version" key of a dependency entry,
+// which is written as "name", "name version" or "name version (source)".
+// The version is only present when more than one is locked.
+func resolveCargoRef(ref string, versions map[string][]string) (string, error) {
+	parts := strings.Fields(ref)
+	if len(parts) == 0 {
+		return "", fmt.Errorf("empty dependency reference")
+	}
+	if len(parts) > 1 {
+		return parts[0] + " " + parts[1], nil
+	}
+	if len(versions[parts[0]]) != 1 {
+		return "", fmt.Errorf("ambiguous dependency reference %q", ref)
+	}
+	return parts[0] + " " + versions[parts[0]][0], nil
+}
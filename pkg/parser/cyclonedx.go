@@ -0,0 +1,96 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/cyclonedx"
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+// ParseCycloneDX parses a CycloneDX SBOM in its JSON or XML encoding and
+// returns the components identified by a package URL of an ecosystem
+// supported by Trusty. Unlike the manifest parsers, the dependencies
+// returned have their ecosystem set as an SBOM mixes several of them.
+func ParseCycloneDX(content string) ([]types.Dependency, error) {
+	deps, err := parseCycloneDX(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseCycloneDX reads the components of an SBOM, including the nested
+// ones, and links them using the dependencies section. The components the
+// main component of the document depends on are direct. When the document
+// does not describe them, components no other depends on are.
+func parseCycloneDX(content string) ([]Dependency, error) {
+	bom, err := cyclonedx.Decode([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+
+	b := newLockBuilder()
+	for _, c := range bom.AllComponents() {
+		if c.PURL == "" {
+			continue
+		}
+		dep, err := DependencyFromPurl(c.PURL)
+		if errors.Is(err, ErrUnsupportedPurl) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		switch c.Scope {
+		case "optional":
+			dep.DeclaredScope = c.Scope
+		case "excluded":
+			dep.Scope, dep.DeclaredScope = ScopeBuild, c.Scope
+		}
+		b.add(cycloneDXRef(c), dep)
+	}
+
+	var root string
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		root = cycloneDXRef(bom.Metadata.Component)
+	}
+	hasRoot := false
+	for _, d := range bom.Dependencies {
+		for _, child := range d.DependsOn {
+			if root != "" && d.Ref == root {
+				hasRoot = true
+				b.markDirect(child)
+				continue
+			}
+			b.link(d.Ref, child)
+		}
+	}
+
+	if !hasRoot {
+		b.markOrphansDirect()
+	}
+	return b.dependencies(), nil
+}
+
+// cycloneDXRef returns the reference used to point to a component from
+// the dependencies section.
+func cycloneDXRef(c *cyclonedx.Component) string {
+	if c.BOMRef != "" {
+		return c.BOMRef
+	}
+	return c.PURL
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCycloneDX(t *testing.T) {
+	t.Parallel()
+
+	expected := []Dependency{
+		{
+			Name: "@babel/core", Ecosystem: "npm", Version: "7.24.0", Scope: ScopeBuild, DeclaredScope: "excluded",
+			Parents: []string{"express@4.18.2"},
+		},
+		{Name: "express", Ecosystem: "npm", Version: "4.18.2", Scope: ScopeRuntime, Direct: true},
+		{
+			Name: "org.slf4j:slf4j-api", Ecosystem: "maven", Version: "2.0.9", Scope: ScopeRuntime,
+			DeclaredScope: "optional", Direct: true,
+		},
+		{
+			Name: "pyyaml", Ecosystem: "pypi", Version: "6.0.1", Scope: ScopeRuntime,
+			Parents: []string{"express@4.18.2"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "json",
+			content: `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "version": 1,
+  "metadata": {
+    "tools": {"components": [{"type": "application", "name": "cdxgen"}]},
+    "component": {"type": "application", "bom-ref": "app", "name": "app"}
+  },
+  "components": [
+    {
+      "type": "library", "bom-ref": "express", "name": "express", "version": "4.18.2",
+      "purl": "pkg:npm/express@4.18.2",
+      "components": [
+        {"type": "library", "name": "core", "group": "@babel", "version": "7.24.0",
+         "scope": "excluded", "purl": "pkg:npm/%40babel/core@7.24.0"}
+      ]
+    },
+    {"type": "library", "bom-ref": "yaml", "name": "PyYAML", "version": "6.0.1", "purl": "pkg:pypi/PyYAML@6.0.1"},
+    {"type": "library", "bom-ref": "slf4j", "name": "slf4j-api", "version": "2.0.9", "scope": "optional",
+     "purl": "pkg:maven/org.slf4j/slf4j-api@2.0.9"},
+    {"type": "library", "bom-ref": "deb", "name": "curl", "purl": "pkg:deb/debian/curl@7.88.1"},
+    {"type": "file", "name": "README.md"}
+  ],
+  "dependencies": [
+    {"ref": "app", "dependsOn": ["express", "slf4j"]},
+    {"ref": "express", "dependsOn": ["pkg:npm/%40babel/core@7.24.0", "yaml", "deb"]}
+  ]
+}`,
+		},
+		{
+			name: "xml",
+			content: `<?xml version="1.0" encoding="UTF-8"?>
+<bom xmlns="http://cyclonedx.org/schema/bom/1.4" version="1">
+  <metadata>
+    <tools><tool><name>cdxgen</name></tool></tools>
+    <component type="application" bom-ref="app"><name>app</name></component>
+  </metadata>
+  <components>
+    <component type="library" bom-ref="express">
+      <name>express</name>
+      <version>4.18.2</version>
+      <licenses><license><id>MIT</id></license></licenses>
+      <purl>pkg:npm/express@4.18.2</purl>
+      <components>
+        <component type="library">
+          <group>@babel</group>
+          <name>core</name>
+          <version>7.24.0</version>
+          <scope>excluded</scope>
+          <purl>pkg:npm/%40babel/core@7.24.0</purl>
+        </component>
+      </components>
+    </component>
+    <component type="library" bom-ref="yaml">
+      <name>PyYAML</name>
+      <version>6.0.1</version>
+      <purl>pkg:pypi/PyYAML@6.0.1</purl>
+    </component>
+    <component type="library" bom-ref="slf4j">
+      <name>slf4j-api</name>
+      <version>2.0.9</version>
+      <scope>optional</scope>
+      <purl>pkg:maven/org.slf4j/slf4j-api@2.0.9</purl>
+    </component>
+  </components>
+  <dependencies>
+    <dependency ref="app">
+      <dependency ref="express"/>
+      <dependency ref="slf4j"/>
+    </dependency>
+    <dependency ref="express">
+      <dependency ref="pkg:npm/%40babel/core@7.24.0"/>
+      <dependency ref="yaml"/>
+    </dependency>
+  </dependencies>
+</bom>`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			deps, err := parseCycloneDX(tc.content)
+			require.NoError(t, err)
+			require.Equal(t, expected, deps)
+		})
+	}
+}
+
+func TestParseCycloneDXWithoutRoot(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parseCycloneDX(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "components": [
+    {"type": "library", "bom-ref": "a", "name": "a", "purl": "pkg:golang/github.com/foo/a@v1.0.0"},
+    {"type": "library", "bom-ref": "b", "name": "b", "purl": "pkg:cargo/b@0.1.0"}
+  ],
+  "dependencies": [{"ref": "a", "dependsOn": ["b"]}]
+}`)
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{Name: "b", Ecosystem: "crates", Version: "0.1.0", Scope: ScopeRuntime, Parents: []string{"github.com/foo/a@v1.0.0"}},
+		{Name: "github.com/foo/a", Ecosystem: "go", Version: "v1.0.0", Scope: ScopeRuntime, Direct: true},
+	}, deps)
+
+	_, err = parseCycloneDX(`{"bomFormat": "SPDX"}`)
+	require.Error(t, err)
+}
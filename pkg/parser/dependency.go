@@ -0,0 +1,290 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+	"github.com/stacklok/trusty-sdk-go/pkg/version"
+)
+
+// Scope classifies what a dependency is needed for, normalized across
+// ecosystems.
+type Scope string
+
+const (
+	// ScopeRuntime is a dependency needed to run the project.
+	ScopeRuntime Scope = "runtime"
+	// ScopeDev is a dependency only needed while developing the project.
+	ScopeDev Scope = "dev"
+	// ScopeTest is a dependency only needed to run the project's tests.
+	ScopeTest Scope = "test"
+	// ScopeBuild is a dependency only needed to build the project, or
+	// provided by the environment at runtime.
+	ScopeBuild Scope = "build"
+)
+
+// Location points to where a dependency was declared. Line and Column are
+// 1-based and zero when the position is unknown, eg for dependencies
+// inherited from a parent manifest.
+type Location struct {
+	Path   string
+	Line   int
+	Column int
+}
+
+// Dependency is a dependency as found in a manifest or lockfile, carrying
+// the details the parser could recover about it.
+type Dependency struct {
+	Name string
+
+	// Ecosystem is the ecosystem the dependency belongs to, as returned by
+	// Parse (eg "npm", "pypi" or "maven").
+	Ecosystem string
+
+	// Constraint is the version requirement as declared in a manifest,
+	// eg "^4.17.1". It is empty for dependencies read from lockfiles.
+	Constraint string
+
+	// Version is the concrete version of the dependency. It is empty when
+	// the manifest only declares a range.
+	Version string
+
+	// Scope is the normalized scope of the dependency.
+	Scope Scope
+
+	// DeclaredScope is the scope or kind as written in the manifest, eg
+	// "provided" or "testImplementation".
+	DeclaredScope string
+
+	// Direct is true when the project itself depends on the package, and
+	// false when it is only pulled in by other dependencies.
+	Direct bool
+
+	// Location is where the dependency was declared.
+	Location Location
+
+	// Parents holds the IDs of the dependencies that depend on this one.
+	// It is only populated when parsing lockfiles.
+	Parents []string
+}
+
+// ID returns an identifier of the dependency, unique within a project,
+// made from its name and concrete version.
+func (d *Dependency) ID() string {
+	if d.Version == "" {
+		return d.Name
+	}
+	return d.Name + "@" + d.Version
+}
+
+// PinnedVersion returns the concrete version of the dependency, or the
+// single version its constraint allows, such as "==2.31.0" in a
+// requirements file or "[1.2.3]" in a POM. It is empty when the
+// constraint is a range.
+func (d *Dependency) PinnedVersion() string {
+	if d.Version != "" || d.Constraint == "" {
+		return d.Version
+	}
+	r, err := version.ParseRangeFor(d.Ecosystem, d.Constraint)
+	if err != nil {
+		return ""
+	}
+	v, ok := r.Exact()
+	if !ok {
+		return ""
+	}
+	return v.String()
+}
+
+// ToV1 converts the dependency to the type used by the v1 Trusty client.
+// The converted dependency matches the one returned by the legacy parsing
+// functions: its version is the concrete version when known and the
+// declared constraint otherwise.
+func (d *Dependency) ToV1() types.Dependency {
+	version := d.Version
+	if version == "" {
+		version = d.Constraint
+	}
+	return types.Dependency{
+		Name:      d.Name,
+		Version:   version,
+		Ecosystem: v1Ecosystem(d.Ecosystem),
+		Scope:     d.DeclaredScope,
+	}
+}
+
+// ToV2 converts the dependency to the type used by the v2 Trusty client.
+// The package version is only set when the dependency is pinned to a
+// version, see PinnedVersion.
+func (d *Dependency) ToV2() *v2types.Dependency {
+	dep := &v2types.Dependency{
+		PackageName: d.Name,
+		PackageType: d.Ecosystem,
+	}
+	if pinned := d.PinnedVersion(); pinned != "" {
+		dep.PackageVersion = &pinned
+	}
+	return dep
+}
+
+// FromV1 converts dependencies of the v1 Trusty client to the type
+// returned by the parsers. The version of a v1 dependency can be a
+// constraint, it is kept as the constraint when it is not a plain version.
+func FromV1(deps []types.Dependency) []Dependency {
+	res := make([]Dependency, len(deps))
+	for i, dep := range deps {
+		res[i] = Dependency{
+			Name:          dep.Name,
+			Ecosystem:     strings.ToLower(dep.Ecosystem.AsString()),
+			Scope:         ScopeRuntime,
+			DeclaredScope: dep.Scope,
+		}
+		if strings.ContainsAny(dep.Version, "^~<>=!*, ") {
+			res[i].Constraint = dep.Version
+		} else {
+			res[i].Version = dep.Version
+		}
+	}
+	return res
+}
+
+// v1Ecosystem maps an ecosystem name to its v1 constant. Ecosystems not
+// supported by the v1 API map to zero.
+func v1Ecosystem(ecosystem string) types.Ecosystem {
+	switch ecosystem {
+	case "npm":
+		return types.ECOSYSTEM_NPM
+	case "go":
+		return types.ECOSYSTEM_GO
+	case "pypi":
+		return types.ECOSYSTEM_PYPI
+	default:
+		return types.Ecosystem(0)
+	}
+}
+
+// toV1Dependencies converts the output of a detailed parser into the
+// legacy format.
+func toV1Dependencies(deps []Dependency) []types.Dependency {
+	if deps == nil {
+		return nil
+	}
+	res := make([]types.Dependency, 0, len(deps))
+	for i := range deps {
+		res = append(res, deps[i].ToV1())
+	}
+	return res
+}
+
+// locator computes the line and column of text in a file's content.
+type locator struct {
+	content    string
+	lineStarts []int
+}
+
+func newLocator(content string) *locator {
+	l := &locator{content: content, lineStarts: []int{0}}
+	for i, c := range content {
+		if c == '\n' {
+			l.lineStarts = append(l.lineStarts, i+1)
+		}
+	}
+	return l
+}
+
+// position returns the 1-based line and column of a byte offset.
+func (l *locator) position(offset int) Location {
+	line := sort.Search(len(l.lineStarts), func(i int) bool {
+		return l.lineStarts[i] > offset
+	})
+	return Location{Line: line, Column: offset - l.lineStarts[line-1] + 1}
+}
+
+// index returns the offset of the first occurrence of needle at or after
+// from, or -1 if it is not present.
+func (l *locator) index(needle string, from int) int {
+	if from < 0 || from > len(l.content) {
+		return -1
+	}
+	i := strings.Index(l.content[from:], needle)
+	if i < 0 {
+		return -1
+	}
+	return from + i
+}
+
+// find returns the location of the first occurrence of needle after the
+// first occurrence of section. An empty section searches the whole file.
+func (l *locator) find(section, needle string) Location {
+	from := 0
+	if section != "" {
+		if from = l.index(section, 0); from < 0 {
+			return Location{}
+		}
+	}
+	i := l.index(needle, from)
+	if i < 0 {
+		return Location{}
+	}
+	return l.position(i)
+}
+
+// findKey returns the location of the first line after section that
+// starts with key followed by something other than a name character. It
+// is used to find keys in line oriented formats such as TOML.
+func (l *locator) findKey(section, key string) Location {
+	from := 0
+	if section != "" {
+		if from = l.index(section, 0); from < 0 {
+			return Location{}
+		}
+	}
+	for i := l.position(from).Line; i <= len(l.lineStarts); i++ {
+		line := l.line(i)
+		trimmed := strings.TrimLeft(line, " \t")
+		rest, ok := strings.CutPrefix(trimmed, key)
+		if !ok || (rest != "" && isNameChar(rest[0])) {
+			continue
+		}
+		return Location{Line: i, Column: len(line) - len(trimmed) + 1}
+	}
+	return Location{}
+}
+
+// line returns the content of the given 1-based line.
+func (l *locator) line(n int) string {
+	start := l.lineStarts[n-1]
+	end := len(l.content)
+	if n < len(l.lineStarts) {
+		end = l.lineStarts[n] - 1
+	}
+	return strings.TrimSuffix(l.content[start:end], "\r")
+}
+
+func isNameChar(c byte) bool {
+	return c == '-' || c == '_' || c == '.' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// lineLocation returns the location of a line read by a line oriented
+// parser, pointing at its first non blank character.
+func lineLocation(n int, line string) Location {
+	return Location{Line: n, Column: len(line) - len(strings.TrimLeft(line, " \t")) + 1}
+}
@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+func TestParseDetailed(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		path      string
+		content   string
+		expected  []Dependency
+		ecosystem string
+		// notLegacy are the dependencies the legacy parser does not return
+		notLegacy []string
+	}{
+		{
+			name:      "unsupported",
+			path:      "README.md",
+			content:   "# hello",
+			ecosystem: "none",
+		},
+		{
+			name:    "go.mod",
+			path:    "svc/go.mod",
+			content: "module example.com\n\nrequire (\n\tgithub.com/google/uuid v1.6.0\n\tgolang.org/x/sys v0.28.0 // indirect\n)\n",
+			expected: []Dependency{
+				{
+					Name: "github.com/google/uuid", Ecosystem: "go", Constraint: "v1.6.0", Version: "v1.6.0",
+					Scope: ScopeRuntime, Direct: true, Location: Location{Path: "svc/go.mod", Line: 4, Column: 2},
+				},
+				{
+					Name: "golang.org/x/sys", Ecosystem: "go", Constraint: "v0.28.0", Version: "v0.28.0",
+					Scope: ScopeRuntime, Location: Location{Path: "svc/go.mod", Line: 5, Column: 2},
+				},
+			},
+			ecosystem: "go",
+		},
+		{
+			name: "package.json",
+			path: "package.json",
+			content: `{
+  "dependencies": {
+    "react": "^18.2.0"
+  },
+  "devDependencies": {
+    "react": "18.2.0"
+  }
+}`,
+			expected: []Dependency{
+				{
+					Name: "react", Ecosystem: "npm", Constraint: "^18.2.0", Scope: ScopeRuntime, Direct: true,
+					Location: Location{Path: "package.json", Line: 3, Column: 5},
+				},
+				{
+					Name: "react", Ecosystem: "npm", Constraint: "18.2.0", Scope: ScopeDev, DeclaredScope: "dev", Direct: true,
+					Location: Location{Path: "package.json", Line: 6, Column: 5},
+				},
+			},
+			ecosystem: "npm",
+		},
+		{
+			name:    "Cargo.toml",
+			path:    "Cargo.toml",
+			content: "[dependencies]\nrand = \"0.8.4\"\nserde = { version = \"1.0\", features = [\"derive\"] }\n\n[dev-dependencies]\n  rand_chacha = \"0.3\"\n",
+			expected: []Dependency{
+				{
+					Name: "rand", Ecosystem: "crates", Constraint: "0.8.4", Scope: ScopeRuntime, Direct: true,
+					Location: Location{Path: "Cargo.toml", Line: 2, Column: 1},
+				},
+				{
+					Name: "serde", Ecosystem: "crates", Constraint: "1.0", Scope: ScopeRuntime, Direct: true,
+					Location: Location{Path: "Cargo.toml", Line: 3, Column: 1},
+				},
+				{
+					Name: "rand_chacha", Ecosystem: "crates", Constraint: "0.3", Scope: ScopeDev, DeclaredScope: "dev", Direct: true,
+					Location: Location{Path: "Cargo.toml", Line: 6, Column: 3},
+				},
+			},
+			ecosystem: "crates",
+			notLegacy: []string{"serde", "rand_chacha"},
+		},
+		{
+			name: "pom.xml",
+			path: "pom.xml",
+			content: `<project>
+  <artifactId>app</artifactId>
+  <dependencyManagement><dependencies><dependency>
+    <groupId>junit</groupId><artifactId>junit</artifactId><version>4.13.2</version>
+  </dependency></dependencies></dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`,
+			expected: []Dependency{
+				{
+					Name: "junit:junit", Ecosystem: "maven", Constraint: "4.13.2", Version: "4.13.2",
+					Scope: ScopeTest, DeclaredScope: "test", Direct: true,
+					Location: Location{Path: "pom.xml", Line: 9, Column: 7},
+				},
+			},
+			ecosystem: "maven",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			deps, ecosystem, err := ParseDetailed(tc.path, tc.content, Options{})
+			require.NoError(t, err)
+			require.Equal(t, tc.ecosystem, ecosystem)
+			require.Equal(t, tc.expected, deps)
+
+			// Converting back yields what the legacy parser returns
+			legacy, _, err := Parse(tc.path, tc.content)
+			require.NoError(t, err)
+			var converted []types.Dependency
+			for i := range deps {
+				if slices.Contains(tc.notLegacy, deps[i].Name) {
+					continue
+				}
+				v1dep := deps[i].ToV1()
+				v1dep.Ecosystem = 0
+				converted = append(converted, v1dep)
+			}
+			require.Len(t, legacy, len(converted))
+			for i := range converted {
+				require.Equal(t, legacy[i], converted[i])
+			}
+		})
+	}
+}
+
+func TestDependencyConversion(t *testing.T) {
+	t.Parallel()
+
+	pinned := Dependency{Name: "requests", Ecosystem: "pypi", Constraint: "==2.25.1", Version: "2.25.1", Scope: ScopeRuntime}
+	require.Equal(t, types.Dependency{Name: "requests", Version: "2.25.1", Ecosystem: types.ECOSYSTEM_PYPI}, pinned.ToV1())
+	version := "2.25.1"
+	require.Equal(t, &v2types.Dependency{PackageName: "requests", PackageType: "pypi", PackageVersion: &version}, pinned.ToV2())
+	require.Equal(t, "requests@2.25.1", pinned.ID())
+
+	ranged := Dependency{Name: "serde", Ecosystem: "crates", Constraint: "1.0", DeclaredScope: "dev", Scope: ScopeDev}
+	require.Equal(t, types.Dependency{Name: "serde", Version: "1.0", Scope: "dev"}, ranged.ToV1())
+	require.Equal(t, &v2types.Dependency{PackageName: "serde", PackageType: "crates"}, ranged.ToV2())
+	require.Equal(t, "serde", ranged.ID())
+
+	exact := Dependency{Name: "lodash", Ecosystem: "npm", Constraint: "4.17.21"}
+	version = "4.17.21"
+	require.Equal(t, &v2types.Dependency{PackageName: "lodash", PackageType: "npm", PackageVersion: &version}, exact.ToV2())
+}
+
+func TestPinnedVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		dep  Dependency
+		want string
+	}{
+		{Dependency{Ecosystem: "npm", Constraint: "^4.17.1"}, ""},
+		{Dependency{Ecosystem: "npm", Constraint: "4.17.21"}, "4.17.21"},
+		{Dependency{Ecosystem: "crates", Constraint: "1.0"}, ""},
+		{Dependency{Ecosystem: "crates", Constraint: "=1.0.3"}, "1.0.3"},
+		{Dependency{Ecosystem: "pypi", Constraint: "~=2.0"}, ""},
+		{Dependency{Ecosystem: "pypi", Constraint: "==2.31.0"}, "2.31.0"},
+		{Dependency{Ecosystem: "maven", Constraint: "[1.0,2.0)"}, ""},
+		{Dependency{Ecosystem: "maven", Constraint: "[1.2.3]"}, "1.2.3"},
+		{Dependency{Ecosystem: "npm", Constraint: "latest"}, ""},
+		{Dependency{Ecosystem: "go", Version: "v1.2.3"}, "v1.2.3"},
+	} {
+		require.Equal(t, tc.want, tc.dep.PinnedVersion(), "%s %q", tc.dep.Ecosystem, tc.dep.Constraint)
+	}
+}
+
+func TestFromV1(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []Dependency{
+		{Name: "lodash", Ecosystem: "npm", Version: "4.17.21", Scope: ScopeRuntime},
+		{Name: "requests", Ecosystem: "pypi", Constraint: ">=2.0, <3", Scope: ScopeRuntime, DeclaredScope: "dev"},
+		{Name: "golang.org/x/net", Ecosystem: "go", Version: "v0.1.0", Scope: ScopeRuntime},
+	}, FromV1([]types.Dependency{
+		{Name: "lodash", Version: "4.17.21", Ecosystem: types.ECOSYSTEM_NPM},
+		{Name: "requests", Version: ">=2.0, <3", Ecosystem: types.ECOSYSTEM_PYPI, Scope: "dev"},
+		{Name: "golang.org/x/net", Version: "v0.1.0", Ecosystem: types.ECOSYSTEM_GO},
+	}))
+}
+
+func TestDependencyPurl(t *testing.T) {
+	t.Parallel()
+
+	for _, purl := range []string{
+		"pkg:npm/%40babel/core@7.24.0",
+		"pkg:npm/express",
+		"pkg:golang/github.com/stacklok/trusty-sdk-go@v0.1.0",
+		"pkg:pypi/requests@2.31.0",
+		"pkg:cargo/serde@1.0.0",
+		"pkg:maven/org.slf4j/slf4j-api@2.0.9",
+	} {
+		purl := purl
+		t.Run(purl, func(t *testing.T) {
+			t.Parallel()
+			dep, err := DependencyFromPurl(purl)
+			require.NoError(t, err)
+			require.Equal(t, purl, dep.Purl())
+		})
+	}
+
+	_, err := DependencyFromPurl("pkg:deb/debian/curl@7.88.1")
+	require.ErrorIs(t, err, ErrUnsupportedPurl)
+	require.Empty(t, (&Dependency{Name: "curl", Ecosystem: "deb"}).Purl())
+}
@@ -24,11 +24,21 @@ import (
 // ParseGoMod parses the content of a go.mod file and returns a slice of dependencies.
 // Each dependency is represented by a types.Dependency struct, containing the name and version.
 func ParseGoMod(content string) ([]types.Dependency, error) {
-	var deps []types.Dependency
+	deps, err := parseGoMod(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseGoMod parses the requirements of a go.mod file. Requirements marked
+// with an "// indirect" comment are returned as transitive dependencies.
+func parseGoMod(content string) ([]Dependency, error) {
+	var deps []Dependency
 	lines := strings.Split(content, "\n")
 	inRequireBlock := false // Flag to track whether we are inside a require block
 
-	for _, line := range lines {
+	for i, line := range lines {
 		trimmedLine := strings.TrimSpace(line)
 		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "//") {
 			continue // Skip empty lines and comments
@@ -58,7 +68,18 @@ func ParseGoMod(content string) ([]types.Dependency, error) {
 					depName = parts[1]
 					depVersion = parts[2]
 				}
-				deps = append(deps, types.Dependency{Name: depName, Version: depVersion})
+
+				loc := lineLocation(i+1, line)
+				loc.Column = strings.Index(line, depName) + 1
+				_, comment, _ := strings.Cut(trimmedLine, "//")
+				deps = append(deps, Dependency{
+					Name:       depName,
+					Constraint: depVersion,
+					Version:    depVersion,
+					Scope:      ScopeRuntime,
+					Direct:     strings.TrimSpace(comment) != "indirect",
+					Location:   loc,
+				})
 			}
 		}
 	}
@@ -0,0 +1,64 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+// ParseGoSum parses the content of a go.sum file and returns the modules
+// whose source code is checksummed in it. Modules listed only for their
+// go.mod file take part in version selection but are not built, so they
+// are skipped. go.sum also keeps the checksums of versions the build no
+// longer selects, so a module can be returned at several versions; go.mod
+// tells which one is used.
+func ParseGoSum(content string) ([]types.Dependency, error) {
+	deps, err := parseGoSum(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseGoSum parses a go.sum file. go.sum has no dependency graph: it
+// does not tell which modules the project requires, nor which versions
+// are selected, so none are reported as direct and none have parents.
+func parseGoSum(content string) ([]Dependency, error) {
+	b := newLockBuilder()
+	for i, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid go.sum line %d: %q", i+1, line)
+		}
+		if strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		dep := Dependency{
+			Name:     fields[0],
+			Version:  fields[1],
+			Scope:    ScopeRuntime,
+			Location: lineLocation(i+1, line),
+		}
+		b.add(dep.ID(), dep)
+	}
+	return b.dependencies(), nil
+}
@@ -0,0 +1,306 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+var (
+	// gradleCoordinateRe matches group:artifact[:version[:classifier]][@ext]
+	gradleCoordinateRe = regexp.MustCompile(`^([\w.\-]+):([\w.\-]+)(?::([^:@]+))?(?::[^:@]*)?(?:@\w+)?$`)
+
+	// gradleStringDeclRe matches string notation declarations such as
+	// implementation 'g:a:v', api("g:a:v") or implementation platform("g:a:v")
+	gradleStringDeclRe = regexp.MustCompile(
+		`^(\w+)\s*\(?\s*(?:(?:platform|enforcedPlatform)\s*\(\s*)?["']([^"']+)["']`,
+	)
+
+	// gradleMapDeclRe matches the configuration of map notation declarations
+	// such as implementation group: 'g', name: 'a' or api(group = "g", ...)
+	gradleMapDeclRe = regexp.MustCompile(`^(\w+)\s*\(?\s*group\s*[:=]`)
+
+	gradleMapEntryRe = regexp.MustCompile(`\b(group|name|version)\s*[:=]\s*["']([^"']+)["']`)
+
+	gradleDependenciesRe = regexp.MustCompile(`^dependencies\s*\{`)
+
+	// gradleBuildscriptRe matches the buildscript block, whose dependencies
+	// are the plugins of the build rather than dependencies of the project
+	gradleBuildscriptRe = regexp.MustCompile(`^buildscript\s*\{`)
+)
+
+// ParseGradleLockfile parses the content of a gradle.lockfile and returns
+// the locked dependencies. The configurations each dependency is locked
+// for are set in its Scope as a comma separated list.
+func ParseGradleLockfile(content string) ([]types.Dependency, error) {
+	deps, err := parseGradleLockfile(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseGradleLockfile parses a gradle.lockfile. Lockfiles do not tell
+// direct and transitive dependencies apart, so all are reported as direct.
+func parseGradleLockfile(content string) ([]Dependency, error) {
+	var deps []Dependency
+	for i, rawLine := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		coordinate, configurations, _ := strings.Cut(line, "=")
+		if coordinate == "empty" {
+			continue // Lists configurations without dependencies
+		}
+
+		parts := strings.Split(coordinate, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid lockfile entry: %q", line)
+		}
+		deps = append(deps, Dependency{
+			Name:          parts[0] + ":" + parts[1],
+			Version:       parts[2],
+			Scope:         gradleScope(strings.Split(configurations, ",")...),
+			DeclaredScope: configurations,
+			Direct:        true,
+			Location:      lineLocation(i+1, rawLine),
+		})
+	}
+	return deps, nil
+}
+
+// ParseBuildGradle parses the dependencies blocks of a build.gradle or
+// build.gradle.kts file. Both the string ("group:name:version") and map
+// (group/name/version) notations are supported. The Scope of each
+// dependency is set to the configuration it was declared for, eg
+// implementation or testImplementation.
+//
+// Project dependencies, version catalog references and coordinates built
+// from variables, eg "g:a:$version", are skipped as they cannot be resolved
+// from the build file alone. The dependencies of the buildscript block are
+// the classpath of the build, not of the project, and are skipped too.
+func ParseBuildGradle(content string) ([]types.Dependency, error) {
+	deps, err := parseBuildGradle(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseBuildGradle parses the dependencies blocks of a Gradle build file.
+func parseBuildGradle(content string) ([]Dependency, error) {
+	var deps []Dependency
+	depth := 0          // Current brace depth
+	blockAt := -1       // Depth at which the dependencies block was opened
+	buildscriptAt := -1 // Depth at which the buildscript block was opened
+
+	for i, line := range strings.Split(content, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "//") {
+			continue
+		}
+
+		if buildscriptAt < 0 && blockAt < 0 && gradleBuildscriptRe.MatchString(trimmedLine) {
+			buildscriptAt = depth
+		} else if buildscriptAt < 0 && blockAt < 0 && gradleDependenciesRe.MatchString(trimmedLine) {
+			blockAt = depth
+		} else if blockAt >= 0 && depth == blockAt+1 {
+			if dep, ok := parseGradleDeclaration(trimmedLine); ok {
+				dep.Location = lineLocation(i+1, line)
+				deps = append(deps, dep)
+			}
+		}
+
+		depth += strings.Count(trimmedLine, "{") - strings.Count(trimmedLine, "}")
+		if blockAt >= 0 && depth <= blockAt {
+			blockAt = -1 // We're leaving the dependencies block
+		}
+		if buildscriptAt >= 0 && depth <= buildscriptAt {
+			buildscriptAt = -1
+		}
+	}
+	return deps, nil
+}
+
+// parseGradleDeclaration parses a single dependency declaration line from
+// a dependencies block.
+func parseGradleDeclaration(line string) (Dependency, bool) {
+	var configuration, name, version string
+	if m := gradleMapDeclRe.FindStringSubmatch(line); m != nil {
+		entries := map[string]string{}
+		for _, e := range gradleMapEntryRe.FindAllStringSubmatch(line, -1) {
+			entries[e[1]] = e[2]
+		}
+		if entries["group"] == "" || entries["name"] == "" {
+			return Dependency{}, false
+		}
+		configuration, name, version = m[1], entries["group"]+":"+entries["name"], entries["version"]
+	} else {
+		m := gradleStringDeclRe.FindStringSubmatch(line)
+		if m == nil {
+			return Dependency{}, false
+		}
+		c := gradleCoordinateRe.FindStringSubmatch(m[2])
+		if c == nil {
+			return Dependency{}, false
+		}
+		configuration, name, version = m[1], c[1]+":"+c[2], c[3]
+	}
+	if strings.Contains(name, "$") || strings.Contains(version, "$") {
+		// Interpolated from variables
+		return Dependency{}, false
+	}
+
+	return Dependency{
+		Name:          name,
+		Constraint:    version,
+		Version:       mavenSoftVersion(version),
+		Scope:         gradleScope(configuration),
+		DeclaredScope: configuration,
+		Direct:        true,
+	}, true
+}
+
+// gradleScope normalizes a set of Gradle configurations. A dependency is
+// considered runtime if any of its configurations is not test or build only.
+func gradleScope(configurations ...string) Scope {
+	scope := ScopeTest
+	for _, c := range configurations {
+		lower := strings.ToLower(c)
+		switch {
+		case strings.HasPrefix(lower, "test") || strings.Contains(lower, "androidtest"):
+			continue
+		case strings.HasPrefix(lower, "compileonly") || strings.Contains(lower, "annotationprocessor") ||
+			strings.HasPrefix(lower, "kapt") || strings.HasPrefix(lower, "ksp") || lower == "classpath":
+			scope = ScopeBuild
+		default:
+			return ScopeRuntime
+		}
+	}
+	return scope
+}
+
+// ParseGradleVersionCatalog parses a libs.versions.toml version catalog and
+// returns the libraries it defines, resolving version references against
+// its [versions] table. Rich versions use their strictly, require or prefer
+// constraint, in that order.
+func ParseGradleVersionCatalog(content string) ([]types.Dependency, error) {
+	deps, err := parseGradleVersionCatalog(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseGradleVersionCatalog parses the libraries of a version catalog.
+// Catalogs do not say how libraries are used, so all are runtime.
+func parseGradleVersionCatalog(content string) ([]Dependency, error) {
+	var catalog struct {
+		Versions  map[string]any `toml:"versions"`
+		Libraries map[string]any `toml:"libraries"`
+	}
+	if _, err := toml.Decode(content, &catalog); err != nil {
+		return nil, err
+	}
+
+	aliases := make([]string, 0, len(catalog.Libraries))
+	for alias := range catalog.Libraries {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	loc := newLocator(content)
+	var deps []Dependency
+	for _, alias := range aliases {
+		var name, version string
+		switch lib := catalog.Libraries[alias].(type) {
+		case string:
+			c := gradleCoordinateRe.FindStringSubmatch(lib)
+			if c == nil {
+				return nil, fmt.Errorf("invalid coordinates for library %q: %q", alias, lib)
+			}
+			name, version = c[1]+":"+c[2], c[3]
+		case map[string]any:
+			var err error
+			name, version, err = catalogLibrary(alias, lib, catalog.Versions)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid definition for library %q", alias)
+		}
+		deps = append(deps, Dependency{
+			Name:       name,
+			Constraint: version,
+			Version:    mavenSoftVersion(version),
+			Scope:      ScopeRuntime,
+			Direct:     true,
+			Location:   loc.findKey("[libraries]", alias),
+		})
+	}
+	return deps, nil
+}
+
+// catalogLibrary returns the coordinates and version of a library declared
+// as a table.
+func catalogLibrary(alias string, lib map[string]any, versions map[string]any) (name, version string, err error) {
+	name, _ = lib["module"].(string)
+	if name == "" {
+		group, _ := lib["group"].(string)
+		artifact, _ := lib["name"].(string)
+		if group == "" || artifact == "" {
+			return "", "", fmt.Errorf("library %q has no module defined", alias)
+		}
+		name = group + ":" + artifact
+	}
+
+	switch v := lib["version"].(type) {
+	case string:
+		version = v
+	case map[string]any:
+		if ref, ok := v["ref"].(string); ok {
+			version = catalogVersion(versions[ref])
+		} else {
+			version = catalogVersion(v)
+		}
+	}
+	return name, version, nil
+}
+
+// catalogVersion returns the version of a catalog version entry, which
+// may be a plain string or a rich version table.
+func catalogVersion(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]any:
+		for _, k := range []string{"strictly", "require", "prefer"} {
+			if s, ok := v[k].(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+func TestParseGradleLockfile(t *testing.T) {
+	t.Parallel()
+
+	content := `# This is a Gradle generated file for dependency locking.
+# Manual edits can break the build and are not advised.
+# This file is expected to be part of source control.
+com.google.guava:guava:31.1-jre=compileClasspath,runtimeClasspath
+junit:junit:4.13.2=testCompileClasspath,testRuntimeClasspath
+empty=annotationProcessor
+`
+	deps, err := ParseGradleLockfile(content)
+	require.NoError(t, err)
+	require.Equal(t, []types.Dependency{
+		{Name: "com.google.guava:guava", Version: "31.1-jre", Scope: "compileClasspath,runtimeClasspath"},
+		{Name: "junit:junit", Version: "4.13.2", Scope: "testCompileClasspath,testRuntimeClasspath"},
+	}, deps)
+
+	_, err = ParseGradleLockfile("not-a-coordinate=compileClasspath\n")
+	require.Error(t, err)
+}
+
+func TestParseBuildGradle(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected []types.Dependency
+	}{
+		{
+			name: "groovy",
+			content: `buildscript {
+    repositories {
+        mavenCentral()
+    }
+    dependencies {
+        classpath 'com.android.tools.build:gradle:8.2.0'
+    }
+}
+
+plugins {
+    id 'java'
+}
+
+repositories {
+    maven { url 'https://repo.example.com/maven2' }
+}
+
+dependencies {
+    implementation 'com.google.guava:guava:32.1.2-jre'
+    implementation platform("org.springframework.boot:spring-boot-dependencies:3.2.0")
+    implementation project(':core')
+    // implementation 'commented:out:1.0'
+    testImplementation group: 'org.junit.jupiter', name: 'junit-jupiter', version: '5.10.0'
+    runtimeOnly('org.postgresql:postgresql:42.7.0') {
+        exclude group: 'org.checkerframework', module: 'checker-qual'
+    }
+    compileOnly "org.projectlombok:lombok"
+    implementation "io.netty:netty-all:$nettyVersion"
+    implementation "io.grpc:grpc-core:${versions.grpc}"
+    implementation group: 'com.example', name: 'lib', version: "$libVersion"
+}
+`,
+			expected: []types.Dependency{
+				{Name: "com.google.guava:guava", Version: "32.1.2-jre", Scope: "implementation"},
+				{Name: "org.springframework.boot:spring-boot-dependencies", Version: "3.2.0", Scope: "implementation"},
+				{Name: "org.junit.jupiter:junit-jupiter", Version: "5.10.0", Scope: "testImplementation"},
+				{Name: "org.postgresql:postgresql", Version: "42.7.0", Scope: "runtimeOnly"},
+				{Name: "org.projectlombok:lombok", Scope: "compileOnly"},
+			},
+		},
+		{
+			name: "kotlin",
+			content: `dependencies {
+    implementation(libs.okhttp)
+    implementation(kotlin("stdlib"))
+    implementation("com.squareup.retrofit2:retrofit:2.9.0")
+    testImplementation(group = "io.mockk", name = "mockk", version = "1.13.8")
+    androidTestImplementation("androidx.test.espresso:espresso-core:3.5.1@aar")
+}
+`,
+			expected: []types.Dependency{
+				{Name: "com.squareup.retrofit2:retrofit", Version: "2.9.0", Scope: "implementation"},
+				{Name: "io.mockk:mockk", Version: "1.13.8", Scope: "testImplementation"},
+				{Name: "androidx.test.espresso:espresso-core", Version: "3.5.1", Scope: "androidTestImplementation"},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			deps, err := ParseBuildGradle(tc.content)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, deps)
+		})
+	}
+}
+
+func TestParseGradleVersionCatalog(t *testing.T) {
+	t.Parallel()
+
+	content := `[versions]
+okhttp = "4.12.0"
+kotlin = { strictly = "1.9.22" }
+
+[libraries]
+guava = "com.google.guava:guava:32.1.2-jre"
+okhttp = { module = "com.squareup.okhttp3:okhttp", version.ref = "okhttp" }
+retrofit = { group = "com.squareup.retrofit2", name = "retrofit", version = "2.9.0" }
+kotlin-stdlib = { module = "org.jetbrains.kotlin:kotlin-stdlib", version.ref = "kotlin" }
+unversioned = { module = "org.slf4j:slf4j-api" }
+
+[plugins]
+android = { id = "com.android.application", version = "8.2.0" }
+`
+	deps, err := ParseGradleVersionCatalog(content)
+	require.NoError(t, err)
+	require.Equal(t, []types.Dependency{
+		{Name: "com.google.guava:guava", Version: "32.1.2-jre"},
+		{Name: "org.jetbrains.kotlin:kotlin-stdlib", Version: "1.9.22"},
+		{Name: "com.squareup.okhttp3:okhttp", Version: "4.12.0"},
+		{Name: "com.squareup.retrofit2:retrofit", Version: "2.9.0"},
+		{Name: "org.slf4j:slf4j-api"},
+	}, deps)
+}
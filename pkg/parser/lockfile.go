@@ -0,0 +1,132 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// lockBuilder assembles the dependencies read from a lockfile. Packages
+// are registered under the key the lockfile uses for them, and packages
+// installed more than once are merged into a single dependency per ID.
+type lockBuilder struct {
+	ids     map[string]string // lockfile key -> dependency ID
+	deps    map[string]*Dependency
+	parents map[string]map[string]bool
+}
+
+func newLockBuilder() *lockBuilder {
+	return &lockBuilder{
+		ids:     map[string]string{},
+		deps:    map[string]*Dependency{},
+		parents: map[string]map[string]bool{},
+	}
+}
+
+// add registers a package. When the same package was already added under
+// another key, it is kept in the runtime scope if any copy is.
+func (b *lockBuilder) add(key string, dep Dependency) {
+	id := dep.ID()
+	b.ids[key] = id
+	existing, ok := b.deps[id]
+	if !ok {
+		b.deps[id] = &dep
+		return
+	}
+	if existing.Scope != ScopeRuntime && dep.Scope == ScopeRuntime {
+		existing.Scope, existing.DeclaredScope = dep.Scope, dep.DeclaredScope
+	}
+	if existing.Location.Line == 0 || (dep.Location.Line != 0 && dep.Location.Line < existing.Location.Line) {
+		existing.Location = dep.Location
+	}
+}
+
+// link records that the package registered as parent depends on child.
+func (b *lockBuilder) link(parent, child string) {
+	parentID, ok := b.ids[parent]
+	if !ok {
+		return
+	}
+	childID, ok := b.ids[child]
+	if !ok || childID == parentID {
+		return
+	}
+	if b.parents[childID] == nil {
+		b.parents[childID] = map[string]bool{}
+	}
+	b.parents[childID][parentID] = true
+}
+
+// markDirect flags the package registered as key as a direct dependency.
+func (b *lockBuilder) markDirect(key string) {
+	if id, ok := b.ids[key]; ok {
+		b.deps[id].Direct = true
+	}
+}
+
+// markOrphansDirect flags as direct every package no other depends on.
+// It is used for lockfiles that do not record the project's requirements.
+func (b *lockBuilder) markOrphansDirect() {
+	for id, dep := range b.deps {
+		if len(b.parents[id]) == 0 {
+			dep.Direct = true
+		}
+	}
+}
+
+// dependencies returns the assembled dependencies sorted by name and
+// version.
+func (b *lockBuilder) dependencies() []Dependency {
+	if len(b.deps) == 0 {
+		return nil
+	}
+	deps := make([]Dependency, 0, len(b.deps))
+	for id, dep := range b.deps {
+		for parent := range b.parents[id] {
+			dep.Parents = append(dep.Parents, parent)
+		}
+		sort.Strings(dep.Parents)
+		deps = append(deps, *dep)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		return deps[i].Version < deps[j].Version
+	})
+	return deps
+}
+
+// tomlTableLocations returns the location of the name key of each array
+// table with the given header (eg [[package]]), in declaration order.
+func tomlTableLocations(content, header string) []Location {
+	var locations []Location
+	inTable := false
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == header:
+			inTable = true
+			locations = append(locations, Location{})
+		case strings.HasPrefix(trimmed, "["):
+			inTable = false
+		case inTable && strings.HasPrefix(trimmed, "name") && locations[len(locations)-1].Line == 0:
+			locations[len(locations)-1] = lineLocation(i+1, line)
+		}
+	}
+	return locations
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePackageLockJSON(t *testing.T) {
+	t.Parallel()
+
+	content := `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {
+      "name": "app",
+      "dependencies": {"express": "^4.18.0"},
+      "devDependencies": {"jest": "^29.0.0"}
+    },
+    "node_modules/express": {
+      "version": "4.18.2",
+      "dependencies": {"debug": "2.6.9", "ms": "2.0.0"}
+    },
+    "node_modules/debug": {
+      "version": "2.6.9",
+      "dependencies": {"ms": "2.0.0"}
+    },
+    "node_modules/ms": {
+      "version": "2.0.0"
+    },
+    "node_modules/jest": {
+      "version": "29.7.0",
+      "dev": true,
+      "dependencies": {"ms": "^2.1.0"}
+    },
+    "node_modules/jest/node_modules/ms": {
+      "version": "2.1.3",
+      "dev": true
+    }
+  }
+}`
+
+	deps, err := parsePackageLockJSON(content)
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{
+			Name: "debug", Version: "2.6.9", Scope: ScopeRuntime,
+			Parents: []string{"express@4.18.2"}, Location: Location{Line: 14, Column: 5},
+		},
+		{
+			Name: "express", Version: "4.18.2", Scope: ScopeRuntime, Direct: true,
+			Location: Location{Line: 10, Column: 5},
+		},
+		{
+			Name: "jest", Version: "29.7.0", Scope: ScopeDev, DeclaredScope: "dev", Direct: true,
+			Location: Location{Line: 21, Column: 5},
+		},
+		{
+			Name: "ms", Version: "2.0.0", Scope: ScopeRuntime,
+			Parents: []string{"debug@2.6.9", "express@4.18.2"}, Location: Location{Line: 18, Column: 5},
+		},
+		{
+			Name: "ms", Version: "2.1.3", Scope: ScopeDev, DeclaredScope: "dev",
+			Parents: []string{"jest@29.7.0"}, Location: Location{Line: 26, Column: 5},
+		},
+	}, deps)
+}
+
+func TestParsePackageLockJSONV1(t *testing.T) {
+	t.Parallel()
+
+	content := `{
+  "lockfileVersion": 1,
+  "dependencies": {
+    "a": {"version": "1.0.0", "requires": {"b": "^2.0.0"}},
+    "b": {"version": "2.1.0"}
+  }
+}`
+	deps, err := parsePackageLockJSON(content)
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{Name: "a", Version: "1.0.0", Scope: ScopeRuntime, Direct: true},
+		{Name: "b", Version: "2.1.0", Scope: ScopeRuntime, Parents: []string{"a@1.0.0"}},
+	}, deps)
+}
+
+func TestParseCargoLock(t *testing.T) {
+	t.Parallel()
+
+	content := `version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = [
+ "rand",
+ "getrandom 0.2.10",
+]
+
+[[package]]
+name = "getrandom"
+version = "0.1.16"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "getrandom"
+version = "0.2.10"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "rand"
+version = "0.7.3"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+dependencies = [
+ "getrandom 0.1.16",
+]
+`
+	deps, err := parseCargoLock(content)
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{
+			Name: "getrandom", Version: "0.1.16", Scope: ScopeRuntime,
+			Parents: []string{"rand@0.7.3"}, Location: Location{Line: 12, Column: 1},
+		},
+		{
+			Name: "getrandom", Version: "0.2.10", Scope: ScopeRuntime, Direct: true,
+			Location: Location{Line: 17, Column: 1},
+		},
+		{
+			Name: "rand", Version: "0.7.3", Scope: ScopeRuntime, Direct: true,
+			Location: Location{Line: 22, Column: 1},
+		},
+	}, deps)
+
+	_, err = parseCargoLock("[[package]]\nname = \"app\"\nversion = \"0.1.0\"\ndependencies = [\"missing\"]\n")
+	require.Error(t, err)
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	t.Parallel()
+
+	content := `[[package]]
+name = "requests"
+version = "2.31.0"
+category = "main"
+
+[package.dependencies]
+charset-normalizer = ">=2,<4"
+urllib3 = {version = ">=1.21.1,<3"}
+
+[[package]]
+name = "charset_normalizer"
+version = "3.3.2"
+category = "main"
+
+[[package]]
+name = "urllib3"
+version = "2.1.0"
+category = "main"
+
+[[package]]
+name = "pytest"
+version = "7.4.3"
+groups = ["dev"]
+`
+	deps, err := parsePoetryLock(content)
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{
+			Name: "charset-normalizer", Version: "3.3.2", Scope: ScopeRuntime,
+			Parents: []string{"requests@2.31.0"}, Location: Location{Line: 11, Column: 1},
+		},
+		{
+			Name: "pytest", Version: "7.4.3", Scope: ScopeDev, DeclaredScope: "dev", Direct: true,
+			Location: Location{Line: 21, Column: 1},
+		},
+		{
+			Name: "requests", Version: "2.31.0", Scope: ScopeRuntime, Direct: true,
+			Location: Location{Line: 2, Column: 1},
+		},
+		{
+			Name: "urllib3", Version: "2.1.0", Scope: ScopeRuntime,
+			Parents: []string{"requests@2.31.0"}, Location: Location{Line: 16, Column: 1},
+		},
+	}, deps)
+}
+
+func TestParseGoSum(t *testing.T) {
+	t.Parallel()
+
+	content := `github.com/google/uuid v1.5.0 h1:b8R4kLRTMt6nUbtoGaM5mZPZlM9a4BWfDUFX7lpzJP8=
+github.com/google/uuid v1.5.0/go.mod h1:TIyPZe4MgqvfeYDBFedMoGGpEw/LqOeaOT+nhxU+yHo=
+github.com/google/uuid v1.6.0 h1:NIvaJDMOsjHA8n1jAhLSgzrAzy1Hgr+hNrb57e+94F0=
+github.com/google/uuid v1.6.0/go.mod h1:TIyPZe4MgqvfeYDBFedMoGGpEw/LqOeaOT+nhxU+yHo=
+golang.org/x/oauth2 v0.26.0/go.mod h1:XYTD2NtWslqkgxebSiOHnXEap4TF09sJSc7H1sXbhtI=
+`
+	deps, err := parseGoSum(content)
+	require.NoError(t, err)
+	// Both versions are checksummed; go.sum does not say which is selected
+	require.Equal(t, []Dependency{
+		{
+			Name: "github.com/google/uuid", Version: "v1.5.0", Scope: ScopeRuntime,
+			Location: Location{Line: 1, Column: 1},
+		},
+		{
+			Name: "github.com/google/uuid", Version: "v1.6.0", Scope: ScopeRuntime,
+			Location: Location{Line: 3, Column: 1},
+		},
+	}, deps)
+
+	_, err = parseGoSum("github.com/google/uuid v1.6.0\n")
+	require.Error(t, err)
+}
@@ -17,22 +17,194 @@ package parser
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
 )
 
-// ParsePackageJSON parses package.json content and extracts dependencies
+type packageJSON struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Overrides            map[string]any    `json:"overrides"`
+	Resolutions          map[string]string `json:"resolutions"`
+	Workspaces           json.RawMessage   `json:"workspaces"`
+}
+
+// ParsePackageJSON parses package.json content and extracts dependencies.
+//
+// Besides the runtime dependencies, it returns the packages listed in
+// devDependencies, peerDependencies and optionalDependencies with their
+// Scope set to "dev", "peer" and "optional" respectively, and the versions
+// forced by npm overrides and yarn resolutions with the "override" and
+// "resolution" scopes.
 func ParsePackageJSON(content string) ([]types.Dependency, error) {
-	var parsedContent struct {
-		Dependencies map[string]string `json:"dependencies"`
+	deps, err := parsePackageJSON(content)
+	if err != nil {
+		return nil, err
 	}
+	return toV1Dependencies(deps), nil
+}
+
+// packageJSONEntry is a dependency read from a package.json section along
+// with the key it was declared with.
+type packageJSONEntry struct {
+	name, version, key string
+	// parent is the path of the override specs a nested override is
+	// declared under, eg "/foo/bar".
+	parent string
+}
+
+// parsePackageJSON parses all the dependency sections of a package.json
+// file. Peer, optional and forced dependencies are considered runtime ones.
+func parsePackageJSON(content string) ([]Dependency, error) {
+	var parsedContent packageJSON
 	if err := json.Unmarshal([]byte(content), &parsedContent); err != nil {
 		return nil, err
 	}
 
-	var deps []types.Dependency
-	for name, version := range parsedContent.Dependencies {
-		deps = append(deps, types.Dependency{Name: name, Version: version})
+	var overrides, resolutions []packageJSONEntry
+	flattenNpmOverrides(parsedContent.Overrides, "", parsedContent.Dependencies, &overrides)
+	for pattern, version := range parsedContent.Resolutions {
+		resolutions = append(resolutions, packageJSONEntry{
+			name: resolutionPackageName(pattern), version: version, key: pattern,
+		})
+	}
+
+	loc := newLocator(content)
+	var deps []Dependency
+	for _, section := range []struct {
+		name    string
+		entries []packageJSONEntry
+		scope   Scope
+		kind    string
+	}{
+		{name: "dependencies", entries: packageJSONEntries(parsedContent.Dependencies), scope: ScopeRuntime},
+		{name: "devDependencies", entries: packageJSONEntries(parsedContent.DevDependencies), scope: ScopeDev, kind: "dev"},
+		{name: "peerDependencies", entries: packageJSONEntries(parsedContent.PeerDependencies), scope: ScopeRuntime, kind: "peer"},
+		{
+			name: "optionalDependencies", entries: packageJSONEntries(parsedContent.OptionalDependencies),
+			scope: ScopeRuntime, kind: "optional",
+		},
+		{name: "overrides", entries: overrides, scope: ScopeRuntime, kind: "override"},
+		{name: "resolutions", entries: resolutions, scope: ScopeRuntime, kind: "resolution"},
+	} {
+		// Sort by name so the output is stable. A package can be overridden
+		// under several parents, which break the ties.
+		sort.Slice(section.entries, func(i, j int) bool {
+			a, b := section.entries[i], section.entries[j]
+			if a.name != b.name {
+				return a.name < b.name
+			}
+			if a.key != b.key {
+				return a.key < b.key
+			}
+			return a.parent < b.parent
+		})
+		for _, e := range section.entries {
+			deps = append(deps, Dependency{
+				Name:          e.name,
+				Constraint:    e.version,
+				Scope:         section.scope,
+				DeclaredScope: section.kind,
+				Direct:        true,
+				Location:      loc.find(`"`+section.name+`"`, `"`+e.key+`"`),
+			})
+		}
 	}
 	return deps, nil
 }
+
+// PackageJSONWorkspaces returns the workspace globs declared in a
+// package.json file. Both the npm (array) and the yarn (object with a
+// packages key) forms are supported.
+func PackageJSONWorkspaces(content string) ([]string, error) {
+	var parsedContent packageJSON
+	if err := json.Unmarshal([]byte(content), &parsedContent); err != nil {
+		return nil, err
+	}
+	if len(parsedContent.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var globs []string
+	if err := json.Unmarshal(parsedContent.Workspaces, &globs); err == nil {
+		return globs, nil
+	}
+
+	var yarnWorkspaces struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(parsedContent.Workspaces, &yarnWorkspaces); err != nil {
+		return nil, fmt.Errorf("invalid workspaces definition: %w", err)
+	}
+	return yarnWorkspaces.Packages, nil
+}
+
+func packageJSONEntries(m map[string]string) []packageJSONEntry {
+	entries := make([]packageJSONEntry, 0, len(m))
+	for name, version := range m {
+		entries = append(entries, packageJSONEntry{name: name, version: version, key: name})
+	}
+	return entries
+}
+
+// flattenNpmOverrides collects the versions set in an npm overrides
+// object into dst. Nested overrides apply to the dependencies of a given
+// package and the "." key sets the version of the package itself.
+// References to direct dependencies ($name) are resolved against direct
+// and parent is the path of the specs the overrides are nested under.
+func flattenNpmOverrides(overrides map[string]any, parent string, direct map[string]string, dst *[]packageJSONEntry) {
+	for spec, value := range overrides {
+		name := overridePackageName(spec)
+		switch v := value.(type) {
+		case string:
+			*dst = append(*dst, packageJSONEntry{name: name, version: resolveOverrideRef(v, direct), key: spec, parent: parent})
+		case map[string]any:
+			if self, ok := v["."].(string); ok {
+				*dst = append(*dst, packageJSONEntry{
+					name: name, version: resolveOverrideRef(self, direct), key: spec, parent: parent,
+				})
+			}
+			nested := map[string]any{}
+			for k, nv := range v {
+				if k != "." {
+					nested[k] = nv
+				}
+			}
+			flattenNpmOverrides(nested, parent+"/"+spec, direct, dst)
+		}
+	}
+}
+
+func resolveOverrideRef(version string, direct map[string]string) string {
+	if ref, ok := strings.CutPrefix(version, "$"); ok {
+		if v, ok := direct[ref]; ok {
+			return v
+		}
+	}
+	return version
+}
+
+// overridePackageName strips the version selector from an override key,
+// eg "foo@1.x" or "@scope/bar@^2".
+func overridePackageName(spec string) string {
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i]
+	}
+	return spec
+}
+
+// resolutionPackageName returns the package a yarn resolution pattern
+// applies to, eg "**/lodash" or "webpack/@babel/core".
+func resolutionPackageName(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	last := parts[len(parts)-1]
+	if len(parts) > 1 && strings.HasPrefix(parts[len(parts)-2], "@") {
+		return parts[len(parts)-2] + "/" + last
+	}
+	return last
+}
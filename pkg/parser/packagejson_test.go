@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+func TestParsePackageJSON(t *testing.T) {
+	t.Parallel()
+
+	content := `{
+  "name": "app",
+  "dependencies": {"react": "^18.2.0", "express": "^4.17.1"},
+  "devDependencies": {"jest": "^29.0.0", "react": "18.2.0"},
+  "peerDependencies": {"react-dom": ">=17"},
+  "optionalDependencies": {"fsevents": "~2.3.2"},
+  "overrides": {
+    "semver": "7.5.4",
+    "express": "$express",
+    "@babel/core@^7": {".": "7.23.0", "json5": "2.2.3"}
+  },
+  "resolutions": {"**/lodash": "4.17.21", "webpack/@types/node": "20.0.0"}
+}`
+
+	deps, err := ParsePackageJSON(content)
+	require.NoError(t, err)
+	require.Equal(t, []types.Dependency{
+		{Name: "express", Version: "^4.17.1"},
+		{Name: "react", Version: "^18.2.0"},
+		{Name: "jest", Version: "^29.0.0", Scope: "dev"},
+		{Name: "react", Version: "18.2.0", Scope: "dev"},
+		{Name: "react-dom", Version: ">=17", Scope: "peer"},
+		{Name: "fsevents", Version: "~2.3.2", Scope: "optional"},
+		{Name: "@babel/core", Version: "7.23.0", Scope: "override"},
+		{Name: "express", Version: "^4.17.1", Scope: "override"},
+		{Name: "json5", Version: "2.2.3", Scope: "override"},
+		{Name: "semver", Version: "7.5.4", Scope: "override"},
+		{Name: "@types/node", Version: "20.0.0", Scope: "resolution"},
+		{Name: "lodash", Version: "4.17.21", Scope: "resolution"},
+	}, deps)
+}
+
+func TestParsePackageJSONNestedOverrides(t *testing.T) {
+	t.Parallel()
+
+	content := `{
+  "overrides": {
+    "webpack": {"minimist": "1.2.8", "glob": {"minimist": "1.2.6"}},
+    "mocha": {"minimist": "1.2.7"}
+  }
+}`
+
+	// The order of the overrides of a package does not depend on the order
+	// of the maps they were read from.
+	for i := 0; i < 20; i++ {
+		deps, err := ParsePackageJSON(content)
+		require.NoError(t, err)
+		require.Equal(t, []types.Dependency{
+			{Name: "minimist", Version: "1.2.7", Scope: "override"},
+			{Name: "minimist", Version: "1.2.8", Scope: "override"},
+			{Name: "minimist", Version: "1.2.6", Scope: "override"},
+		}, deps)
+	}
+}
+
+func TestPackageJSONWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected []string
+		mustErr  bool
+	}{
+		{name: "none", content: `{"name": "app"}`},
+		{name: "npm", content: `{"workspaces": ["packages/*", "apps/web"]}`, expected: []string{"packages/*", "apps/web"}},
+		{name: "yarn", content: `{"workspaces": {"packages": ["libs/**"], "nohoist": ["**/react"]}}`, expected: []string{"libs/**"}},
+		{name: "invalid", content: `{"workspaces": "packages/*"}`, mustErr: true},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			globs, err := PackageJSONWorkspaces(tc.content)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, globs)
+		})
+	}
+}
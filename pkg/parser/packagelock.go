@@ -0,0 +1,198 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+const nodeModules = "node_modules/"
+
+type packageLock struct {
+	Packages     map[string]packageLockEntry  `json:"packages"`
+	Dependencies map[string]packageLockV1Node `json:"dependencies"`
+}
+
+type packageLockEntry struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Dev                  bool              `json:"dev"`
+	Optional             bool              `json:"optional"`
+	DevOptional          bool              `json:"devOptional"`
+	Link                 bool              `json:"link"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+type packageLockV1Node struct {
+	Version      string                       `json:"version"`
+	Dev          bool                         `json:"dev"`
+	Optional     bool                         `json:"optional"`
+	Requires     map[string]string            `json:"requires"`
+	Dependencies map[string]packageLockV1Node `json:"dependencies"`
+}
+
+// ParsePackageLockJSON parses the content of a package-lock.json or
+// npm-shrinkwrap.json file and returns every installed package. Packages
+// only installed for development are returned with their Scope set to
+// "dev", optional ones with "optional".
+func ParsePackageLockJSON(content string) ([]types.Dependency, error) {
+	deps, err := parsePackageLockJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parsePackageLockJSON parses a package-lock.json file resolving the edges
+// between packages with the node_modules lookup rules. Lockfiles in the
+// legacy v1 format carry no information about the root project, so their
+// top level packages not required by any other are considered direct.
+func parsePackageLockJSON(content string) ([]Dependency, error) {
+	var lock packageLock
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return nil, err
+	}
+
+	packages := lock.Packages
+	locations := map[string]Location{}
+	if packages == nil {
+		packages = map[string]packageLockEntry{}
+		flattenPackageLockV1(lock.Dependencies, "", packages)
+	} else {
+		locations = jsonKeyLocations(content)
+	}
+
+	b := newLockBuilder()
+	for p, entry := range packages {
+		if !strings.Contains(p, nodeModules) || entry.Link {
+			continue
+		}
+		name := entry.Name
+		if name == "" {
+			name = p[strings.LastIndex(p, nodeModules)+len(nodeModules):]
+		}
+		dep := Dependency{Name: name, Version: entry.Version, Scope: ScopeRuntime}
+		switch {
+		case entry.Dev || entry.DevOptional:
+			dep.Scope, dep.DeclaredScope = ScopeDev, "dev"
+		case entry.Optional:
+			dep.DeclaredScope = "optional"
+		}
+		dep.Location = locations[p]
+		b.add(p, dep)
+	}
+
+	_, hasRoot := packages[""]
+	for p, entry := range packages {
+		if entry.Link {
+			continue
+		}
+		for _, requirements := range []map[string]string{
+			entry.Dependencies, entry.DevDependencies, entry.OptionalDependencies, entry.PeerDependencies,
+		} {
+			for name := range requirements {
+				child := resolveNodeModule(packages, p, name)
+				if child == "" {
+					continue // Not installed, eg an optional or peer dependency
+				}
+				if strings.Contains(p, nodeModules) {
+					b.link(p, child)
+				} else {
+					b.markDirect(child) // Required by the root project or a workspace
+				}
+			}
+		}
+	}
+
+	if !hasRoot {
+		b.markOrphansDirect()
+	}
+	return b.dependencies(), nil
+}
+
+// flattenPackageLockV1 converts the nested dependencies of a v1 lockfile
+// into the path keyed layout used by later versions.
+func flattenPackageLockV1(nodes map[string]packageLockV1Node, prefix string, dst map[string]packageLockEntry) {
+	for name, node := range nodes {
+		p := prefix + nodeModules + name
+		dst[p] = packageLockEntry{
+			Version:      node.Version,
+			Dev:          node.Dev,
+			Optional:     node.Optional,
+			Dependencies: node.Requires,
+		}
+		flattenPackageLockV1(node.Dependencies, p+"/", dst)
+	}
+}
+
+// resolveNodeModule returns the path of the package that a package
+// installed at from gets when requiring name, following the node_modules
+// lookup algorithm. It returns an empty string if none is installed.
+func resolveNodeModule(packages map[string]packageLockEntry, from, name string) string {
+	dir := from
+	for {
+		candidate := nodeModules + name
+		if dir != "" {
+			candidate = dir + "/" + candidate
+		}
+		if _, ok := packages[candidate]; ok {
+			return candidate
+		}
+		if dir == "" {
+			return ""
+		}
+
+		if i := strings.LastIndex(dir, "/"+nodeModules); i >= 0 {
+			dir = dir[:i]
+		} else if strings.HasPrefix(dir, nodeModules) {
+			dir = ""
+		} else if dir = path.Dir(dir); dir == "." {
+			dir = ""
+		}
+	}
+}
+
+// jsonKeyLocations returns the location of each object key that opens an
+// object on its own line. It is used to locate the entries of large
+// lockfiles without searching their content repeatedly.
+func jsonKeyLocations(content string) map[string]Location {
+	locations := map[string]Location{}
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, `"`) || !strings.HasSuffix(trimmed, "{") {
+			continue
+		}
+		end := strings.Index(trimmed[1:], `"`)
+		if end < 0 {
+			continue
+		}
+		var key string
+		if err := json.Unmarshal([]byte(trimmed[:end+2]), &key); err != nil {
+			continue
+		}
+		if _, ok := locations[key]; !ok {
+			locations[key] = lineLocation(i+1, line)
+		}
+	}
+	return locations
+}
@@ -29,11 +29,65 @@ import (
 type ParsingFunction func(string) ([]types.Dependency, error)
 
 var parsingFunctions = map[string]ParsingFunction{
-	"go.mod":           ParseGoMod,
-	"Cargo.toml":       ParseCargoToml,
-	"requirements.txt": ParseRequirementsTxt,
-	"pom.xml":          ParsePomXml,
-	"package.json":     ParsePackageJSON,
+	"go.mod":              ParseGoMod,
+	"Cargo.toml":          ParseCargoToml,
+	"requirements.txt":    ParseRequirementsTxt,
+	"pom.xml":             ParsePomXml,
+	"package.json":        ParsePackageJSON,
+	"gradle.lockfile":     ParseGradleLockfile,
+	"build.gradle":        ParseBuildGradle,
+	"build.gradle.kts":    ParseBuildGradle,
+	"libs.versions.toml":  ParseGradleVersionCatalog,
+	"package-lock.json":   ParsePackageLockJSON,
+	"npm-shrinkwrap.json": ParsePackageLockJSON,
+	"Cargo.lock":          ParseCargoLock,
+	"poetry.lock":         ParsePoetryLock,
+	"go.sum":              ParseGoSum,
+	"bom.json":            ParseCycloneDX,
+	"bom.xml":             ParseCycloneDX,
+	".cdx.json":           ParseCycloneDX,
+	".cdx.xml":            ParseCycloneDX,
+	".spdx.json":          ParseSPDX,
+	".spdx":               ParseSPDX,
+}
+
+// Options configures how ParseDetailed reads manifests.
+type Options struct {
+	// Pom configures the parsing of pom.xml files.
+	Pom PomOptions
+}
+
+type detailedParsingFunction func(content string, opts *Options) ([]Dependency, error)
+
+var detailedParsingFunctions = map[string]detailedParsingFunction{
+	"go.mod":           withoutOptions(parseGoMod),
+	"Cargo.toml":       withoutOptions(parseCargoToml),
+	"requirements.txt": withoutOptions(parseRequirementsTxt),
+	"pom.xml": func(content string, opts *Options) ([]Dependency, error) {
+		return parsePomXml(content, opts.Pom)
+	},
+	"package.json":        withoutOptions(parsePackageJSON),
+	"gradle.lockfile":     withoutOptions(parseGradleLockfile),
+	"build.gradle":        withoutOptions(parseBuildGradle),
+	"build.gradle.kts":    withoutOptions(parseBuildGradle),
+	"libs.versions.toml":  withoutOptions(parseGradleVersionCatalog),
+	"package-lock.json":   withoutOptions(parsePackageLockJSON),
+	"npm-shrinkwrap.json": withoutOptions(parsePackageLockJSON),
+	"Cargo.lock":          withoutOptions(parseCargoLock),
+	"poetry.lock":         withoutOptions(parsePoetryLock),
+	"go.sum":              withoutOptions(parseGoSum),
+	"bom.json":            withoutOptions(parseCycloneDX),
+	"bom.xml":             withoutOptions(parseCycloneDX),
+	".cdx.json":           withoutOptions(parseCycloneDX),
+	".cdx.xml":            withoutOptions(parseCycloneDX),
+	".spdx.json":          withoutOptions(parseSPDX),
+	".spdx":               withoutOptions(parseSPDX),
+}
+
+func withoutOptions(f func(string) ([]Dependency, error)) detailedParsingFunction {
+	return func(content string, _ *Options) ([]Dependency, error) {
+		return f(content)
+	}
 }
 
 // Parse parses the given filename and content to extract dependencies and
@@ -55,19 +109,68 @@ func Parse(filename string, content string) ([]types.Dependency, string, error)
 	return []types.Dependency{}, "none", nil
 }
 
+// ParseDetailed works like Parse but returns the dependencies with all the
+// information the parser could recover: their normalized scope, whether
+// they are direct or transitive, their location in the file at path, their
+// declared constraint and resolved version, and the dependencies that pull
+// them in. The dependencies can be converted back to the types used by the
+// Trusty clients with their ToV1 and ToV2 methods.
+//
+// If no parser supports the file, it returns no dependencies and "none" as
+// the ecosystem.
+func ParseDetailed(path string, content string, opts Options) ([]Dependency, string, error) {
+	suffix, ok := parserSuffix(path)
+	if !ok {
+		return nil, "none", nil
+	}
+
+	ecosystem := determineEcosystem(suffix)
+	deps, err := detailedParsingFunctions[suffix](content, &opts)
+	if err != nil {
+		return nil, ecosystem, err
+	}
+	for i := range deps {
+		// SBOMs mix ecosystems, their parsers set it on each dependency
+		if deps[i].Ecosystem == "" {
+			deps[i].Ecosystem = ecosystem
+		}
+		deps[i].Location.Path = path
+	}
+	return deps, ecosystem, nil
+}
+
+// Supported returns true if ParseDetailed has a parser for the file at
+// path, which is picked by the name of the file.
+func Supported(path string) bool {
+	_, ok := parserSuffix(path)
+	return ok
+}
+
+// parserSuffix returns the file suffix used to pick the parser of path.
+func parserSuffix(path string) (string, bool) {
+	for suffix := range detailedParsingFunctions {
+		if strings.HasSuffix(path, suffix) {
+			return suffix, true
+		}
+	}
+	return "", false
+}
+
 // determineEcosystem maps a file suffix to its ecosystem
 func determineEcosystem(suffix string) string {
 	switch suffix {
-	case "go.mod":
+	case "go.mod", "go.sum":
 		return "go"
-	case "Cargo.toml":
+	case "Cargo.toml", "Cargo.lock":
 		return "crates"
-	case "requirements.txt":
+	case "requirements.txt", "poetry.lock":
 		return "pypi"
-	case "pom.xml":
+	case "pom.xml", "gradle.lockfile", "build.gradle", "build.gradle.kts", "libs.versions.toml":
 		return "maven"
-	case "package.json":
+	case "package.json", "package-lock.json", "npm-shrinkwrap.json":
 		return "npm"
+	case "bom.json", "bom.xml", ".cdx.json", ".cdx.xml", ".spdx.json", ".spdx":
+		return "sbom"
 	default:
 		return "unknown"
 	}
@@ -44,7 +44,7 @@ func TestParse(t *testing.T) {
 		},
 		{
 			filename: "Cargo.toml",
-			content:  "[dependencies]\nrand = \"0.8.4\"\n",
+			content:  "[dependencies]\nrand = \"0.8.4\"\n\n[dev-dependencies]\nrand_chacha = \"0.3\"\n\n[build-dependencies]\ncc = \"1.0\"\n",
 			expected: []types.Dependency{
 				{Name: "rand", Version: "0.8.4"},
 			},
@@ -69,6 +69,15 @@ func TestParse(t *testing.T) {
 			ecosystem: "maven",
 			err:       nil,
 		},
+		{
+			filename: "app/build.gradle.kts",
+			content:  "dependencies {\n\timplementation(\"com.squareup.okhttp3:okhttp:4.12.0\")\n}\n",
+			expected: []types.Dependency{
+				{Name: "com.squareup.okhttp3:okhttp", Version: "4.12.0", Scope: "implementation"},
+			},
+			ecosystem: "maven",
+			err:       nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -84,3 +93,20 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestSupported(t *testing.T) {
+	t.Parallel()
+
+	for path, want := range map[string]bool{
+		"go.mod":                  true,
+		"web/package-lock.json":   true,
+		"sbom/app.cdx.json":       true,
+		"README.md":               false,
+		"package.json.orig":       false,
+		"requirements-dev.txt.in": false,
+	} {
+		if got := Supported(path); got != want {
+			t.Errorf("Supported(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
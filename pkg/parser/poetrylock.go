@@ -0,0 +1,89 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+var pypiNameSeparatorRe = regexp.MustCompile(`[-_.]+`)
+
+type poetryLockPackage struct {
+	Name         string         `toml:"name"`
+	Version      string         `toml:"version"`
+	Category     string         `toml:"category"`
+	Groups       []string       `toml:"groups"`
+	Dependencies map[string]any `toml:"dependencies"`
+}
+
+// ParsePoetryLock parses the content of a poetry.lock file and returns the
+// locked packages. Packages only used for development are returned with
+// their Scope set to the category or groups they belong to.
+func ParsePoetryLock(content string) ([]types.Dependency, error) {
+	deps, err := parsePoetryLock(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parsePoetryLock parses a poetry.lock file. The requirements of the
+// project live in pyproject.toml, so packages not required by any other
+// locked package are considered direct.
+func parsePoetryLock(content string) ([]Dependency, error) {
+	var lock struct {
+		Packages []poetryLockPackage `toml:"package"`
+	}
+	if _, err := toml.Decode(content, &lock); err != nil {
+		return nil, err
+	}
+
+	locations := tomlTableLocations(content, "[[package]]")
+	b := newLockBuilder()
+	for i, pkg := range lock.Packages {
+		dep := Dependency{Name: normalizePypiName(pkg.Name), Version: pkg.Version, Scope: ScopeRuntime}
+		switch {
+		case pkg.Category != "" && pkg.Category != "main":
+			dep.Scope, dep.DeclaredScope = ScopeDev, pkg.Category
+		case len(pkg.Groups) > 0 && !slices.Contains(pkg.Groups, "main"):
+			dep.Scope, dep.DeclaredScope = ScopeDev, strings.Join(pkg.Groups, ",")
+		}
+		if i < len(locations) {
+			dep.Location = locations[i]
+		}
+		b.add(dep.Name, dep)
+	}
+
+	for _, pkg := range lock.Packages {
+		for name := range pkg.Dependencies {
+			b.link(normalizePypiName(pkg.Name), normalizePypiName(name))
+		}
+	}
+	b.markOrphansDirect()
+	return b.dependencies(), nil
+}
+
+// normalizePypiName normalizes a Python package name as described in
+// PEP 503.
+func normalizePypiName(name string) string {
+	return pypiNameSeparatorRe.ReplaceAllString(strings.ToLower(name), "-")
+}
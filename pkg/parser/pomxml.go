@@ -17,10 +17,86 @@ package parser
 
 import (
 	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
 )
 
+// maxPomDepth bounds the number of parent and imported POMs followed, as
+// well as the rounds of property interpolation, to protect against cycles.
+const maxPomDepth = 16
+
+var pomPropertyRe = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// PomResolver returns the content of the POM identified by the given
+// coordinates. It is called to fetch parent POMs and BOMs imported in the
+// dependencyManagement section. relativePath is the path declared in the
+// <parent> block, if any, and is always empty for imported BOMs.
+type PomResolver func(groupID, artifactID, version, relativePath string) (string, error)
+
+// PomOptions configures how ParsePomXmlWithOptions reads a pom.xml file.
+type PomOptions struct {
+	// Resolver fetches parent and imported POMs. When nil, only the data
+	// present in the parsed file is used.
+	Resolver PomResolver
+
+	// ExcludeOptional drops dependencies marked as <optional>true</optional>.
+	ExcludeOptional bool
+}
+
+type pomProject struct {
+	GroupID              string        `xml:"groupId"`
+	ArtifactID           string        `xml:"artifactId"`
+	Version              string        `xml:"version"`
+	Parent               *pomParent    `xml:"parent"`
+	Properties           pomProperties `xml:"properties"`
+	DependencyManagement struct {
+		Dependencies pomDependencies `xml:"dependencies"`
+	} `xml:"dependencyManagement"`
+	Dependencies pomDependencies `xml:"dependencies"`
+}
+
+type pomParent struct {
+	GroupID      string `xml:"groupId"`
+	ArtifactID   string `xml:"artifactId"`
+	Version      string `xml:"version"`
+	RelativePath string `xml:"relativePath"`
+}
+
+type pomProperties struct {
+	Entries []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+type pomDependencies struct {
+	Dependency []pomDependency `xml:"dependency"`
+}
+
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Type       string `xml:"type"`
+	Scope      string `xml:"scope"`
+	Optional   string `xml:"optional"`
+}
+
+func (d *pomDependency) key() string {
+	return d.GroupID + ":" + d.ArtifactID
+}
+
+// pomModel is the effective view of a project once its parents have been
+// merged in and its properties interpolated.
+type pomModel struct {
+	properties   map[string]string
+	managed      map[string]pomDependency
+	dependencies []pomDependency
+}
+
 // ParsePomXml parses the content of a POM XML file and returns a slice of dependencies
 // along with their group ID, artifact ID, and version.
 // The content parameter is the string representation of the POM XML file.
@@ -29,22 +105,288 @@ import (
 // If an error occurs during parsing, the function returns nil for the slice of dependencies
 // and the error that occurred.
 func ParsePomXml(content string) ([]types.Dependency, error) {
-	var project struct {
-		Dependencies struct {
-			Dependency []struct {
-				GroupId    string `xml:"groupId"`
-				ArtifactId string `xml:"artifactId"`
-				Version    string `xml:"version"`
-			} `xml:"dependency"`
-		} `xml:"dependencies"`
+	return ParsePomXmlWithOptions(content, PomOptions{})
+}
+
+// ParsePomXmlWithOptions parses the content of a POM XML file building its
+// effective model: properties are interpolated, versions and scopes missing
+// from a dependency are taken from the dependencyManagement sections of the
+// file, its parents and imported BOMs, and dependencies declared by parents
+// are inherited. Parents and BOMs are only followed when opts.Resolver is set.
+//
+// Dependencies in the default compile scope are returned with an empty
+// Scope, other scopes (test, provided, runtime, system) are set verbatim.
+func ParsePomXmlWithOptions(content string, opts PomOptions) ([]types.Dependency, error) {
+	deps, err := parsePomXml(content, opts)
+	if err != nil {
+		return nil, err
 	}
-	if err := xml.Unmarshal([]byte(content), &project); err != nil {
+	return toV1Dependencies(deps), nil
+}
+
+// parsePomXml parses a pom.xml file building its effective model.
+// Dependencies inherited from parents are returned without a location.
+func parsePomXml(content string, opts PomOptions) ([]Dependency, error) {
+	project, err := decodePom(content)
+	if err != nil {
 		return nil, err
 	}
 
-	var deps []types.Dependency
-	for _, d := range project.Dependencies.Dependency {
-		deps = append(deps, types.Dependency{Name: d.GroupId + ":" + d.ArtifactId, Version: d.Version})
+	model, err := buildPomModel(project, opts.Resolver, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := newPomLocator(content)
+	var deps []Dependency
+	for _, d := range model.dependencies {
+		if opts.ExcludeOptional && d.Optional == "true" {
+			continue
+		}
+		if managed, ok := model.managed[d.key()]; ok {
+			if d.Version == "" {
+				d.Version = managed.Version
+			}
+			if d.Scope == "" {
+				d.Scope = managed.Scope
+			}
+		}
+		if d.Scope == "compile" {
+			d.Scope = ""
+		}
+		deps = append(deps, Dependency{
+			Name:          d.key(),
+			Constraint:    d.Version,
+			Version:       mavenSoftVersion(d.Version),
+			Scope:         mavenScope(d.Scope),
+			DeclaredScope: d.Scope,
+			Direct:        true,
+			Location:      loc.find(d.ArtifactID),
+		})
 	}
 	return deps, nil
 }
+
+// mavenScope normalizes a Maven dependency scope.
+func mavenScope(scope string) Scope {
+	switch scope {
+	case "test":
+		return ScopeTest
+	case "provided", "system":
+		return ScopeBuild
+	default:
+		return ScopeRuntime
+	}
+}
+
+// mavenSoftVersion returns the version a Maven or Gradle requirement
+// resolves to when it names a single version, or an empty string for
+// ranges, dynamic versions and unresolved properties.
+func mavenSoftVersion(version string) string {
+	if version == "" || strings.ContainsAny(version, "[]()+,") ||
+		strings.Contains(version, "${") || strings.HasPrefix(version, "latest.") {
+		return ""
+	}
+	return version
+}
+
+// pomLocator finds dependency declarations in a pom.xml file, skipping
+// the ones in the dependencyManagement section.
+type pomLocator struct {
+	*locator
+	managementStart, managementEnd int
+}
+
+func newPomLocator(content string) *pomLocator {
+	l := &pomLocator{locator: newLocator(content), managementStart: -1, managementEnd: -1}
+	if start := l.index("<dependencyManagement>", 0); start >= 0 {
+		l.managementStart = start
+		l.managementEnd = l.index("</dependencyManagement>", start)
+	}
+	return l
+}
+
+func (l *pomLocator) find(artifactID string) Location {
+	needle := "<artifactId>" + artifactID + "</artifactId>"
+	from := l.index("<dependencies>", 0)
+	for from >= 0 {
+		i := l.index(needle, from)
+		if i < 0 {
+			return Location{}
+		}
+		if i < l.managementStart || i > l.managementEnd {
+			return l.position(i)
+		}
+		from = i + len(needle)
+	}
+	return Location{}
+}
+
+func decodePom(content string) (*pomProject, error) {
+	var project pomProject
+	if err := xml.Unmarshal([]byte(content), &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// buildPomModel computes the effective model of project. depth counts the
+// BOM imports followed so far.
+func buildPomModel(project *pomProject, resolver PomResolver, depth int) (*pomModel, error) {
+	chain, err := loadPomChain(project, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &pomModel{
+		properties: map[string]string{},
+		managed:    map[string]pomDependency{},
+	}
+
+	// Walk from the root-most parent down so that children override.
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, e := range chain[i].Properties.Entries {
+			m.properties[e.XMLName.Local] = strings.TrimSpace(e.Value)
+		}
+	}
+	m.setProjectProperties(project)
+
+	imported := map[string]pomDependency{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, d := range chain[i].DependencyManagement.Dependencies.Dependency {
+			d = m.interpolateDependency(d)
+			if d.Scope != "import" {
+				m.managed[d.key()] = d
+				continue
+			}
+			if err := importPomBOM(d, resolver, depth, imported); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Versions managed explicitly always win over the imported ones.
+	for k, d := range imported {
+		if _, ok := m.managed[k]; !ok {
+			m.managed[k] = d
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, p := range chain {
+		for _, d := range p.Dependencies.Dependency {
+			d = m.interpolateDependency(d)
+			if seen[d.key()] {
+				continue
+			}
+			seen[d.key()] = true
+			m.dependencies = append(m.dependencies, d)
+		}
+	}
+	return m, nil
+}
+
+// loadPomChain returns project followed by its ancestors, nearest first.
+func loadPomChain(project *pomProject, resolver PomResolver) ([]*pomProject, error) {
+	chain := []*pomProject{project}
+	if resolver == nil {
+		return chain, nil
+	}
+
+	for p := project; p.Parent != nil; p = chain[len(chain)-1] {
+		if len(chain) > maxPomDepth {
+			return nil, fmt.Errorf("parent chain exceeds %d levels", maxPomDepth)
+		}
+		parent := p.Parent
+		content, err := resolver(parent.GroupID, parent.ArtifactID, parent.Version, parent.RelativePath)
+		if err != nil {
+			return nil, fmt.Errorf("resolving parent %s:%s:%s: %w", parent.GroupID, parent.ArtifactID, parent.Version, err)
+		}
+		pp, err := decodePom(content)
+		if err != nil {
+			return nil, fmt.Errorf("parsing parent %s:%s:%s: %w", parent.GroupID, parent.ArtifactID, parent.Version, err)
+		}
+		chain = append(chain, pp)
+	}
+	return chain, nil
+}
+
+// importPomBOM loads the managed dependencies of the BOM referenced by an
+// import-scoped entry into dst, without overwriting earlier imports.
+func importPomBOM(d pomDependency, resolver PomResolver, depth int, dst map[string]pomDependency) error {
+	if resolver == nil {
+		return nil
+	}
+	if depth >= maxPomDepth {
+		return fmt.Errorf("BOM imports exceed %d levels", maxPomDepth)
+	}
+
+	content, err := resolver(d.GroupID, d.ArtifactID, d.Version, "")
+	if err != nil {
+		return fmt.Errorf("resolving BOM %s:%s: %w", d.key(), d.Version, err)
+	}
+	bom, err := decodePom(content)
+	if err != nil {
+		return fmt.Errorf("parsing BOM %s:%s: %w", d.key(), d.Version, err)
+	}
+	bomModel, err := buildPomModel(bom, resolver, depth+1)
+	if err != nil {
+		return err
+	}
+	for k, md := range bomModel.managed {
+		if _, ok := dst[k]; !ok {
+			dst[k] = md
+		}
+	}
+	return nil
+}
+
+// setProjectProperties registers the built-in project.* properties,
+// inheriting the group and version from the parent when not declared.
+func (m *pomModel) setProjectProperties(project *pomProject) {
+	groupID, version := project.GroupID, project.Version
+	if project.Parent != nil {
+		if groupID == "" {
+			groupID = project.Parent.GroupID
+		}
+		if version == "" {
+			version = project.Parent.Version
+		}
+		m.properties["project.parent.groupId"] = project.Parent.GroupID
+		m.properties["project.parent.artifactId"] = project.Parent.ArtifactID
+		m.properties["project.parent.version"] = project.Parent.Version
+	}
+
+	for _, prefix := range []string{"project.", "pom."} {
+		m.properties[prefix+"groupId"] = groupID
+		m.properties[prefix+"artifactId"] = project.ArtifactID
+		m.properties[prefix+"version"] = version
+	}
+}
+
+func (m *pomModel) interpolateDependency(d pomDependency) pomDependency {
+	d.GroupID = m.interpolate(d.GroupID)
+	d.ArtifactID = m.interpolate(d.ArtifactID)
+	d.Version = m.interpolate(d.Version)
+	d.Scope = m.interpolate(d.Scope)
+	d.Optional = m.interpolate(d.Optional)
+	return d
+}
+
+// interpolate replaces ${...} references with the values of the known
+// properties. Unknown properties are left untouched.
+func (m *pomModel) interpolate(s string) string {
+	s = strings.TrimSpace(s)
+	for i := 0; i < maxPomDepth && strings.Contains(s, "${"); i++ {
+		next := pomPropertyRe.ReplaceAllStringFunc(s, func(ref string) string {
+			if v, ok := m.properties[ref[2:len(ref)-1]]; ok {
+				return v
+			}
+			return ref
+		})
+		if next == s {
+			break
+		}
+		s = next
+	}
+	return s
+}
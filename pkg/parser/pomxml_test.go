@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+func TestParsePomXmlWithOptions(t *testing.T) {
+	t.Parallel()
+
+	parentPom := `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>2.0.0</version>
+  <properties>
+    <guava.version>33.0.0-jre</guava.version>
+    <junit.version>5.10.0</junit.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>${guava.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>org.junit.jupiter</groupId>
+        <artifactId>junit-jupiter</artifactId>
+        <version>${junit.version}</version>
+        <scope>test</scope>
+      </dependency>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>bom</artifactId>
+        <version>1.0</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+      <version>2.0.9</version>
+    </dependency>
+  </dependencies>
+</project>`
+
+	bomPom := `<project>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.fasterxml.jackson.core</groupId>
+        <artifactId>jackson-databind</artifactId>
+        <version>2.16.0</version>
+      </dependency>
+      <dependency>
+        <groupId>com.google.guava</groupId>
+        <artifactId>guava</artifactId>
+        <version>1.0-from-bom</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`
+
+	childPom := `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>child</artifactId>
+  <properties>
+    <junit.version>5.11.0</junit.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>${project.groupId}</groupId>
+      <artifactId>sibling</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>org.junit.jupiter</groupId>
+      <artifactId>junit-jupiter</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+      <scope>runtime</scope>
+    </dependency>
+    <dependency>
+      <groupId>javax.servlet</groupId>
+      <artifactId>servlet-api</artifactId>
+      <version>2.5</version>
+      <scope>provided</scope>
+      <optional>true</optional>
+    </dependency>
+  </dependencies>
+</project>`
+
+	resolver := func(groupID, artifactID, version, _ string) (string, error) {
+		switch groupID + ":" + artifactID + ":" + version {
+		case "com.example:parent:2.0.0":
+			return parentPom, nil
+		case "com.example:bom:1.0":
+			return bomPom, nil
+		default:
+			return "", fmt.Errorf("unknown pom")
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		content  string
+		opts     PomOptions
+		expected []types.Dependency
+		mustErr  bool
+	}{
+		{
+			name:    "no-resolver",
+			content: childPom,
+			expected: []types.Dependency{
+				{Name: "com.example:sibling", Version: "2.0.0"},
+				{Name: "com.google.guava:guava"},
+				{Name: "org.junit.jupiter:junit-jupiter"},
+				{Name: "com.fasterxml.jackson.core:jackson-databind", Scope: "runtime"},
+				{Name: "javax.servlet:servlet-api", Version: "2.5", Scope: "provided"},
+			},
+		},
+		{
+			name:    "with-parents",
+			content: childPom,
+			opts:    PomOptions{Resolver: resolver, ExcludeOptional: true},
+			expected: []types.Dependency{
+				{Name: "com.example:sibling", Version: "2.0.0"},
+				{Name: "com.google.guava:guava", Version: "33.0.0-jre"},
+				{Name: "org.junit.jupiter:junit-jupiter", Version: "5.11.0", Scope: "test"},
+				{Name: "com.fasterxml.jackson.core:jackson-databind", Version: "2.16.0", Scope: "runtime"},
+				{Name: "org.slf4j:slf4j-api", Version: "2.0.9"},
+			},
+		},
+		{
+			name:    "unresolvable-parent",
+			content: childPom,
+			opts: PomOptions{Resolver: func(_, _, _, _ string) (string, error) {
+				return "", fmt.Errorf("not found")
+			}},
+			mustErr: true,
+		},
+		{
+			name: "unknown-property",
+			content: `<project><dependencies><dependency>
+  <groupId>a</groupId><artifactId>b</artifactId><version>${missing}</version><scope>compile</scope>
+</dependency></dependencies></project>`,
+			expected: []types.Dependency{
+				{Name: "a:b", Version: "${missing}"},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			deps, err := ParsePomXmlWithOptions(tc.content, tc.opts)
+			if tc.mustErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, deps)
+		})
+	}
+}
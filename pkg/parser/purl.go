@@ -0,0 +1,98 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	packageurl "github.com/package-url/packageurl-go"
+)
+
+// ErrUnsupportedPurl is returned when a package URL does not belong to an
+// ecosystem supported by Trusty.
+var ErrUnsupportedPurl = errors.New("package url type not supported")
+
+// purlEcosystems maps the package URL types to the ecosystem names used
+// by the parsers.
+var purlEcosystems = map[string]string{
+	packageurl.TypeNPM:    "npm",
+	packageurl.TypeGolang: "go",
+	packageurl.TypePyPi:   "pypi",
+	packageurl.TypeCargo:  "crates",
+	packageurl.TypeMaven:  "maven",
+}
+
+// DependencyFromPurl returns the dependency identified by a package URL,
+// named as the manifest parsers of its ecosystem name it. The dependency
+// is runtime scoped and has no location. ErrUnsupportedPurl is returned
+// when the package URL type is not supported.
+func DependencyFromPurl(purl string) (Dependency, error) {
+	p, err := packageurl.FromString(purl)
+	if err != nil {
+		return Dependency{}, fmt.Errorf("unable to parse package url: %w", err)
+	}
+	ecosystem, ok := purlEcosystems[p.Type]
+	if !ok {
+		return Dependency{}, fmt.Errorf("%w: %q", ErrUnsupportedPurl, p.Type)
+	}
+
+	name := p.Name
+	switch {
+	case ecosystem == "pypi":
+		name = normalizePypiName(name)
+	case ecosystem == "maven":
+		name = p.Namespace + ":" + p.Name
+	case p.Namespace != "":
+		name = p.Namespace + "/" + p.Name
+	}
+	return Dependency{
+		Name:      name,
+		Ecosystem: ecosystem,
+		Version:   p.Version,
+		Scope:     ScopeRuntime,
+	}, nil
+}
+
+// Purl returns the package URL identifying the dependency, with its
+// version when a concrete one is known. It returns an empty string when
+// the ecosystem of the dependency has no package URL type.
+func (d *Dependency) Purl() string {
+	var purlType string
+	for t, ecosystem := range purlEcosystems {
+		if ecosystem == d.Ecosystem {
+			purlType = t
+		}
+	}
+	if purlType == "" {
+		return ""
+	}
+
+	var namespace string
+	name := d.Name
+	switch purlType {
+	case packageurl.TypeMaven:
+		if group, artifact, ok := strings.Cut(d.Name, ":"); ok {
+			namespace, name = group, artifact
+		}
+	case packageurl.TypeNPM, packageurl.TypeGolang:
+		if i := strings.LastIndex(d.Name, "/"); i >= 0 {
+			namespace, name = d.Name[:i], d.Name[i+1:]
+		}
+	}
+	return packageurl.NewPackageURL(purlType, namespace, name, d.Version, nil, "").ToString()
+}
@@ -23,16 +23,33 @@ import (
 
 // ParseRequirementsTxt parses requirements.txt content and extracts dependencies
 func ParseRequirementsTxt(content string) ([]types.Dependency, error) {
-	var deps []types.Dependency
+	deps, err := parseRequirementsTxt(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseRequirementsTxt parses the pinned (==) requirements of a
+// requirements.txt file.
+func parseRequirementsTxt(content string) ([]Dependency, error) {
+	var deps []Dependency
 	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	for i, rawLine := range lines {
+		line := strings.TrimSpace(rawLine)
 		if line != "" && !strings.HasPrefix(line, "#") {
 			parts := strings.SplitN(line, "==", 2)
 			if len(parts) == 2 {
 				// Convert package name to lowercase
 				packageName := strings.ToLower(parts[0])
-				deps = append(deps, types.Dependency{Name: packageName, Version: parts[1]})
+				deps = append(deps, Dependency{
+					Name:       packageName,
+					Constraint: "==" + parts[1],
+					Version:    parts[1],
+					Scope:      ScopeRuntime,
+					Direct:     true,
+					Location:   lineLocation(i+1, rawLine),
+				})
 			}
 		}
 	}
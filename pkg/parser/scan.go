@@ -0,0 +1,281 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultIgnore is the list of patterns Scan skips when no other is set.
+var DefaultIgnore = []string{"node_modules", "vendor", "testdata", ".git"}
+
+// filePriority ranks the files of an ecosystem found in the same project.
+// Only the files with the highest priority are parsed, which makes Scan
+// prefer lockfiles over manifests. go.mod pins exact versions and tells
+// direct from indirect requirements, so it is preferred over go.sum.
+var filePriority = map[string]int{
+	"package.json":        1,
+	"package-lock.json":   2,
+	"npm-shrinkwrap.json": 3,
+	"Cargo.toml":          1,
+	"Cargo.lock":          2,
+	"requirements.txt":    1,
+	"poetry.lock":         2,
+	"pom.xml":             1,
+	"build.gradle":        1,
+	"build.gradle.kts":    1,
+	"libs.versions.toml":  1,
+	"gradle.lockfile":     2,
+	"go.sum":              1,
+	"go.mod":              2,
+	"bom.json":            1,
+	"bom.xml":             1,
+	".cdx.json":           1,
+	".cdx.xml":            1,
+	".spdx.json":          1,
+	".spdx":               1,
+}
+
+// ScanOptions configures Scan.
+type ScanOptions struct {
+	// Options configures the parsers.
+	Options
+
+	// Ignore holds the glob patterns of the files and directories to
+	// skip, as understood by path.Match, where a "**" element also matches
+	// any number of directories. Patterns are matched against both the
+	// name and the slash separated path of each entry. When nil,
+	// DefaultIgnore is used.
+	Ignore []string
+}
+
+// Project holds the dependencies of one ecosystem found in a directory.
+type Project struct {
+	// Root is the directory of the project, relative to the scanned tree.
+	Root string
+
+	// Ecosystem is the ecosystem of the dependencies, as returned by Parse.
+	Ecosystem string
+
+	// Files are the paths of the files the dependencies were read from.
+	Files []string
+
+	// Dependencies are the dependencies of the project.
+	Dependencies []Dependency
+}
+
+// scanFile is a supported file found while walking the tree.
+type scanFile struct {
+	path, suffix, root, ecosystem string
+}
+
+// Scan walks fsys looking for the manifests and lockfiles supported by
+// ParseDetailed, and returns their dependencies grouped by project root
+// and ecosystem. When a project has both a lockfile and a manifest, only
+// the lockfile is parsed. npm projects that are workspaces of a project
+// with a lockfile are covered by that lockfile and not reported.
+//
+// Files that fail to parse are skipped, and Scan returns the projects it
+// could read together with an error joining all the parsing errors.
+func Scan(fsys fs.FS, opts ScanOptions) ([]Project, error) {
+	if opts.Ignore == nil {
+		opts.Ignore = DefaultIgnore
+	}
+
+	files, err := findManifests(fsys, opts.Ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[[2]string][]scanFile{}
+	for _, f := range files {
+		key := [2]string{f.root, f.ecosystem}
+		groups[key] = append(groups[key], f)
+	}
+
+	var projects []Project
+	var errs []error
+	for key, group := range groups {
+		p := Project{Root: key[0], Ecosystem: key[1]}
+		for _, f := range preferredFiles(group) {
+			content, err := fs.ReadFile(fsys, f.path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("reading %s: %w", f.path, err))
+				continue
+			}
+			deps, _, err := ParseDetailed(f.path, string(content), opts.Options)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("parsing %s: %w", f.path, err))
+				continue
+			}
+			p.Files = append(p.Files, f.path)
+			p.Dependencies = append(p.Dependencies, deps...)
+		}
+		if len(p.Files) > 0 {
+			projects = append(projects, p)
+		}
+	}
+
+	projects = dropNpmWorkspaces(fsys, projects)
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Root != projects[j].Root {
+			return projects[i].Root < projects[j].Root
+		}
+		return projects[i].Ecosystem < projects[j].Ecosystem
+	})
+	return projects, errors.Join(errs...)
+}
+
+// findManifests walks fsys and returns the supported files not ignored.
+func findManifests(fsys fs.FS, ignore []string) ([]scanFile, error) {
+	var files []scanFile
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && Ignored(p, ignore) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		suffix, ok := parserSuffix(p)
+		if !ok {
+			return nil
+		}
+		root := path.Dir(p)
+		// Version catalogs live in the gradle directory of the project
+		if suffix == "libs.versions.toml" && path.Base(root) == "gradle" {
+			root = path.Dir(root)
+		}
+		files = append(files, scanFile{path: p, suffix: suffix, root: root, ecosystem: determineEcosystem(suffix)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking tree: %w", err)
+	}
+	return files, nil
+}
+
+// Ignored returns true if the file or directory at the slash separated
+// path p matches one of the patterns, as understood by ScanOptions.Ignore.
+func Ignored(p string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, path.Base(p)) || matchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// preferredFiles returns the files of a project with the highest priority,
+// sorted by path.
+func preferredFiles(files []scanFile) []scanFile {
+	top := 0
+	for _, f := range files {
+		top = max(top, filePriority[f.suffix])
+	}
+
+	var res []scanFile
+	for _, f := range files {
+		if filePriority[f.suffix] == top {
+			res = append(res, f)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].path < res[j].path })
+	return res
+}
+
+// dropNpmWorkspaces removes the npm projects that only have a manifest and
+// are workspaces of a project parsed from a lockfile, as their packages
+// are already part of that lockfile.
+func dropNpmWorkspaces(fsys fs.FS, projects []Project) []Project {
+	var globs []string
+	for _, p := range projects {
+		if p.Ecosystem != "npm" || filePriority[path.Base(p.Files[0])] == filePriority["package.json"] {
+			continue
+		}
+		content, err := fs.ReadFile(fsys, path.Join(p.Root, "package.json"))
+		if err != nil {
+			continue
+		}
+		workspaces, err := PackageJSONWorkspaces(string(content))
+		if err != nil {
+			continue
+		}
+		for _, w := range workspaces {
+			globs = append(globs, path.Join(p.Root, w))
+		}
+	}
+	if len(globs) == 0 {
+		return projects
+	}
+
+	res := projects[:0]
+	for _, p := range projects {
+		if p.Ecosystem == "npm" && path.Base(p.Files[0]) == "package.json" && matchesAny(p.Root, globs) {
+			continue
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+func matchesAny(p string, globs []string) bool {
+	for _, g := range globs {
+		if matchGlob(g, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether the slash separated path name matches pattern.
+// Each element of the pattern is matched with path.Match, except "**",
+// which matches zero or more elements, as in npm workspaces.
+func matchGlob(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
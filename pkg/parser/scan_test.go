@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"go.mod": {Data: []byte("module example.com\n\nrequire github.com/google/uuid v1.6.0\n")},
+		"go.sum": {Data: []byte("github.com/google/uuid v1.6.0 h1:NIvaJDMOsjHA8n1jAhLSgzrAzy1Hgr+hNrb57e+94F0=\n")},
+		"web/package.json": {Data: []byte(
+			`{"workspaces": ["packages/*"], "dependencies": {"express": "^4.18.0"}}`,
+		)},
+		"web/package-lock.json": {Data: []byte(
+			`{"packages": {"": {"dependencies": {"express": "^4.18.0"}}, "node_modules/express": {"version": "4.18.2"}}}`,
+		)},
+		"web/packages/ui/package.json":      {Data: []byte(`{"dependencies": {"react": "^18.2.0"}}`)},
+		"web/node_modules/x/package.json":   {Data: []byte(`{"dependencies": {"y": "1.0.0"}}`)},
+		"svc/requirements.txt":              {Data: []byte("requests==2.31.0\n")},
+		"svc/testdata/requirements.txt":     {Data: []byte("flask==3.0.0\n")},
+		"android/gradle/libs.versions.toml": {Data: []byte("[libraries]\nokhttp = \"com.squareup.okhttp3:okhttp:4.12.0\"\n")},
+		"android/build.gradle.kts": {Data: []byte(
+			"dependencies {\n    implementation(\"com.google.guava:guava:32.1.2-jre\")\n}\n",
+		)},
+		"broken/Cargo.toml": {Data: []byte("[dependencies\n")},
+		"README.md":         {Data: []byte("# hello\n")},
+	}
+
+	projects, err := Scan(fsys, ScanOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "broken/Cargo.toml")
+
+	type summary struct {
+		root, ecosystem string
+		files           []string
+		deps            []string
+	}
+	var got []summary
+	for _, p := range projects {
+		s := summary{root: p.Root, ecosystem: p.Ecosystem, files: p.Files}
+		for _, d := range p.Dependencies {
+			s.deps = append(s.deps, d.ID())
+		}
+		got = append(got, s)
+	}
+
+	require.Equal(t, []summary{
+		{root: ".", ecosystem: "go", files: []string{"go.mod"}, deps: []string{"github.com/google/uuid@v1.6.0"}},
+		{
+			root: "android", ecosystem: "maven",
+			files: []string{"android/build.gradle.kts", "android/gradle/libs.versions.toml"},
+			deps:  []string{"com.google.guava:guava@32.1.2-jre", "com.squareup.okhttp3:okhttp@4.12.0"},
+		},
+		{root: "svc", ecosystem: "pypi", files: []string{"svc/requirements.txt"}, deps: []string{"requests@2.31.0"}},
+		{root: "web", ecosystem: "npm", files: []string{"web/package-lock.json"}, deps: []string{"express@4.18.2"}},
+	}, got)
+
+	projects, err = Scan(fsys, ScanOptions{Ignore: []string{"broken", "android", "web", "go.*"}})
+	require.NoError(t, err)
+	require.Len(t, projects, 2)
+	require.Equal(t, "svc", projects[0].Root)
+	require.Equal(t, "svc/testdata", projects[1].Root)
+}
+
+func TestScanNpmWorkspacesDoubleStar(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"package.json": {Data: []byte(`{"workspaces": ["packages/**"]}`)},
+		"package-lock.json": {Data: []byte(
+			`{"packages": {"": {}, "node_modules/react": {"version": "18.2.0"}}}`,
+		)},
+		"packages/ui/package.json":         {Data: []byte(`{"dependencies": {"react": "^18.2.0"}}`)},
+		"packages/apps/web/package.json":   {Data: []byte(`{"dependencies": {"react": "^18.2.0"}}`)},
+		"tools/package.json":               {Data: []byte(`{"dependencies": {"lodash": "^4.17.21"}}`)},
+		"fixtures/deep/nested/go.mod":      {Data: []byte("module example.com/fixture\n")},
+		"fixtures/deep/nested/ignored.txt": {Data: []byte("")},
+	}
+
+	projects, err := Scan(fsys, ScanOptions{Ignore: []string{"fixtures/**/go.mod"}})
+	require.NoError(t, err)
+	var roots []string
+	for _, p := range projects {
+		roots = append(roots, p.Root)
+	}
+	require.Equal(t, []string{".", "tools"}, roots)
+}
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"packages/*", "packages/ui", true},
+		{"packages/*", "packages/apps/web", false},
+		{"packages/**", "packages/ui", true},
+		{"packages/**", "packages/apps/web", true},
+		{"packages/**", "tools/ui", false},
+		{"**/testdata", "a/b/testdata", true},
+		{"**/testdata", "testdata", true},
+		{"a/**/b/*.json", "a/x/y/b/c.json", true},
+		{"a/**/b/*.json", "a/b/c.json", true},
+		{"a/**/b/*.json", "a/b/c/d.json", false},
+	} {
+		require.Equal(t, tc.want, matchGlob(tc.pattern, tc.name), "%s ~ %s", tc.pattern, tc.name)
+	}
+}
@@ -0,0 +1,135 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"errors"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/spdx"
+	"github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+)
+
+// spdxEdge is a dependency between two SPDX elements, from the dependent
+// element to its dependency, with the scope the relationship implies.
+type spdxEdge struct {
+	parent, child string
+	scope         Scope
+	declared      string
+}
+
+// ParseSPDX parses an SPDX 2.x document in its JSON or tag-value encoding
+// and returns the packages with a package URL of an ecosystem supported by
+// Trusty. Like ParseCycloneDX, the dependencies returned have their
+// ecosystem set.
+func ParseSPDX(content string) ([]types.Dependency, error) {
+	deps, err := parseSPDX(content)
+	if err != nil {
+		return nil, err
+	}
+	return toV1Dependencies(deps), nil
+}
+
+// parseSPDX reads the packages of an SPDX document and links them using
+// its dependency and CONTAINS relationships. The packages the document
+// describes are the project: they are only returned when they have no
+// dependencies, and the packages they depend on are direct. When the
+// document describes no package with dependencies, packages no other
+// depends on are direct.
+func parseSPDX(content string) ([]Dependency, error) {
+	doc, err := spdx.Decode([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+
+	roots := map[string]bool{}
+	for _, id := range doc.Described() {
+		roots[id] = true
+	}
+	edges := spdxEdges(doc.Relationships)
+	hasDependencies := map[string]bool{}
+	scopes := map[string]Dependency{}
+	for _, e := range edges {
+		hasDependencies[e.parent] = true
+		// Runtime relationships win over the narrower ones
+		if s, ok := scopes[e.child]; !ok || (s.Scope != ScopeRuntime && e.scope == ScopeRuntime) {
+			scopes[e.child] = Dependency{Scope: e.scope, DeclaredScope: e.declared}
+		}
+	}
+
+	b := newLockBuilder()
+	for i := range doc.Packages {
+		p := &doc.Packages[i]
+		if roots[p.SPDXID] && hasDependencies[p.SPDXID] {
+			continue
+		}
+		purl := p.Purl()
+		if purl == "" {
+			continue
+		}
+		dep, err := DependencyFromPurl(purl)
+		if errors.Is(err, ErrUnsupportedPurl) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if dep.Version == "" {
+			dep.Version = p.VersionInfo
+		}
+		if s, ok := scopes[p.SPDXID]; ok {
+			dep.Scope, dep.DeclaredScope = s.Scope, s.DeclaredScope
+		}
+		b.add(p.SPDXID, dep)
+	}
+
+	hasRoot := false
+	for _, e := range edges {
+		if roots[e.parent] {
+			hasRoot = true
+			b.markDirect(e.child)
+			continue
+		}
+		b.link(e.parent, e.child)
+	}
+
+	if !hasRoot {
+		b.markOrphansDirect()
+	}
+	return b.dependencies(), nil
+}
+
+// spdxEdges returns the dependencies expressed by the relationships of a
+// document, oriented from the dependent element to its dependency.
+func spdxEdges(relationships []spdx.Relationship) []spdxEdge {
+	var edges []spdxEdge
+	for _, r := range relationships {
+		a, b := r.SPDXElementID, r.RelatedSPDXElement
+		switch r.RelationshipType {
+		case spdx.RelationshipDependsOn, spdx.RelationshipContains:
+			edges = append(edges, spdxEdge{parent: a, child: b, scope: ScopeRuntime})
+		case spdx.RelationshipDependencyOf, spdx.RelationshipRuntimeDependencyOf:
+			edges = append(edges, spdxEdge{parent: b, child: a, scope: ScopeRuntime})
+		case spdx.RelationshipOptionalDependencyOf:
+			edges = append(edges, spdxEdge{parent: b, child: a, scope: ScopeRuntime, declared: "optional"})
+		case spdx.RelationshipDevDependencyOf:
+			edges = append(edges, spdxEdge{parent: b, child: a, scope: ScopeDev, declared: "dev"})
+		case spdx.RelationshipTestDependencyOf:
+			edges = append(edges, spdxEdge{parent: b, child: a, scope: ScopeTest, declared: "test"})
+		case spdx.RelationshipBuildDependencyOf:
+			edges = append(edges, spdxEdge{parent: b, child: a, scope: ScopeBuild, declared: "build"})
+		}
+	}
+	return edges
+}
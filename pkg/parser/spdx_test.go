@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSPDX(t *testing.T) {
+	t.Parallel()
+
+	expected := []Dependency{
+		{
+			Name: "@types/node", Ecosystem: "npm", Version: "20.11.0", Scope: ScopeDev, DeclaredScope: "dev",
+			Direct: true,
+		},
+		{Name: "express", Ecosystem: "npm", Version: "4.18.2", Scope: ScopeRuntime, Direct: true},
+		{
+			Name: "ms", Ecosystem: "npm", Version: "2.0.0", Scope: ScopeRuntime,
+			Parents: []string{"express@4.18.2"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "json",
+			content: `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "app",
+  "documentDescribes": ["SPDXRef-app"],
+  "packages": [
+    {"SPDXID": "SPDXRef-app", "name": "app", "versionInfo": "1.0.0",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/app@1.0.0"}]},
+    {"SPDXID": "SPDXRef-express", "name": "express", "versionInfo": "4.18.2",
+     "externalRefs": [
+       {"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:express:express:4.18.2"},
+       {"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/express@4.18.2"}
+     ]},
+    {"SPDXID": "SPDXRef-ms", "name": "ms", "versionInfo": "2.0.0",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/ms"}]},
+    {"SPDXID": "SPDXRef-types", "name": "@types/node", "versionInfo": "20.11.0",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:npm/%40types/node@20.11.0"}]},
+    {"SPDXID": "SPDXRef-libc", "name": "libc",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:deb/debian/libc6@2.36"}]}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-DOCUMENT", "relationshipType": "DESCRIBES", "relatedSpdxElement": "SPDXRef-app"},
+    {"spdxElementId": "SPDXRef-app", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-express"},
+    {"spdxElementId": "SPDXRef-types", "relationshipType": "DEV_DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-app"},
+    {"spdxElementId": "SPDXRef-ms", "relationshipType": "DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-express"},
+    {"spdxElementId": "SPDXRef-express", "relationshipType": "DEPENDS_ON", "relatedSpdxElement": "SPDXRef-libc"}
+  ]
+}`,
+		},
+		{
+			name: "tag-value",
+			content: `SPDXVersion: SPDX-2.3
+DataLicense: CC0-1.0
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: app
+DocumentComment: <text>Generated
+for the tests</text>
+
+## Packages
+PackageName: app
+SPDXID: SPDXRef-app
+PackageVersion: 1.0.0
+ExternalRef: PACKAGE-MANAGER purl pkg:npm/app@1.0.0
+
+PackageName: express
+SPDXID: SPDXRef-express
+PackageVersion: 4.18.2
+ExternalRef: PACKAGE-MANAGER purl pkg:npm/express@4.18.2
+
+PackageName: ms
+SPDXID: SPDXRef-ms
+PackageVersion: 2.0.0
+ExternalRef: PACKAGE-MANAGER purl pkg:npm/ms
+
+PackageName: @types/node
+SPDXID: SPDXRef-types
+PackageVersion: 20.11.0
+ExternalRef: PACKAGE-MANAGER purl pkg:npm/%40types/node@20.11.0
+
+FileName: ./index.js
+SPDXID: SPDXRef-index
+
+Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-app
+Relationship: SPDXRef-app DEPENDS_ON SPDXRef-express
+Relationship: SPDXRef-types DEV_DEPENDENCY_OF SPDXRef-app
+Relationship: SPDXRef-ms DEPENDENCY_OF SPDXRef-express
+Relationship: SPDXRef-app CONTAINS SPDXRef-index
+`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			deps, err := parseSPDX(tc.content)
+			require.NoError(t, err)
+			require.Equal(t, expected, deps)
+		})
+	}
+}
+
+func TestParseSPDXWithoutRelationships(t *testing.T) {
+	t.Parallel()
+
+	deps, err := parseSPDX(`SPDXVersion: SPDX-2.2
+SPDXID: SPDXRef-DOCUMENT
+PackageName: requests
+SPDXID: SPDXRef-requests
+ExternalRef: PACKAGE-MANAGER purl pkg:pypi/Requests@2.31.0
+`)
+	require.NoError(t, err)
+	require.Equal(t, []Dependency{
+		{Name: "requests", Ecosystem: "pypi", Version: "2.31.0", Scope: ScopeRuntime, Direct: true},
+	}, deps)
+
+	_, err = parseSPDX(`{"spdxVersion": "SPDX-3.0"}`)
+	require.Error(t, err)
+}
@@ -0,0 +1,313 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+// Rule is a custom check written as a CEL expression. The expression must
+// evaluate to a boolean, true meaning that the package violates the rule.
+// It can use the following variables, whose fields are documented in the
+// types they are built from:
+//
+//	dep         DependencyInput
+//	pkg         PackageInput
+//	summary     SummaryInput
+//	provenance  ProvenanceInput
+//
+// For example, to only accept archived packages with verified provenance
+// and more than five contributors:
+//
+//	pkg.archived && !(summary.provenance_type == "verified_provenance_match" && pkg.contributor_count > 5)
+type Rule struct {
+	// ID identifies the rule in the violations it reports.
+	ID string `yaml:"id" json:"id"`
+
+	// Expression is the CEL expression of the rule.
+	Expression string `yaml:"expression" json:"expression"`
+
+	// Severity is the severity of the violations. Defaults to "error".
+	Severity result.Severity `yaml:"severity,omitempty" json:"severity,omitempty"`
+
+	// Message describes the violations. Defaults to the expression.
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// RuleIDPrefix prefixes the rule ID of the violations of custom rules.
+const RuleIDPrefix = "policy/rule/"
+
+// DependencyInput describes the dependency in CEL rules.
+type DependencyInput struct {
+	Name      string `cel:"name"`
+	Ecosystem string `cel:"ecosystem"`
+	Version   string `cel:"version"`
+	Scope     string `cel:"scope"`
+	Direct    bool   `cel:"direct"`
+}
+
+// PackageInput describes the package metadata in CEL rules. Available is
+// false when Trusty returned no metadata, in which case all the other
+// fields have their zero value.
+type PackageInput struct {
+	Available        bool   `cel:"available"`
+	Status           string `cel:"status"`
+	Author           string `cel:"author"`
+	Origin           string `cel:"origin"`
+	RepositoryName   string `cel:"repository_name"`
+	Visibility       string `cel:"visibility"`
+	Archived         bool   `cel:"archived"`
+	Deprecated       bool   `cel:"deprecated"`
+	Disabled         bool   `cel:"disabled"`
+	Malicious        bool   `cel:"malicious"`
+	StarGazersCount  int64  `cel:"stargazers_count"`
+	WatchersCount    int64  `cel:"watchers_count"`
+	ForksCount       int64  `cel:"forks_count"`
+	OpenIssuesCount  int64  `cel:"open_issues_count"`
+	ContributorCount int64  `cel:"contributor_count"`
+	PublicRepos      int64  `cel:"public_repos"`
+	Followers        int64  `cel:"followers"`
+}
+
+// SummaryInput describes the package summary in CEL rules. Available is
+// false when Trusty returned no summary, and HasScore is false when the
+// package has no score yet.
+type SummaryInput struct {
+	Available      bool    `cel:"available"`
+	HasScore       bool    `cel:"has_score"`
+	Score          float64 `cel:"score"`
+	Malicious      bool    `cel:"malicious"`
+	Typosquatting  float64 `cel:"typosquatting"`
+	Provenance     float64 `cel:"provenance"`
+	ProvenanceType string  `cel:"provenance_type"`
+	Activity       float64 `cel:"activity"`
+	ActivityUser   float64 `cel:"activity_user"`
+	ActivityRepo   float64 `cel:"activity_repo"`
+	TrustSummary   float64 `cel:"trust_summary"`
+	TrustActivity  float64 `cel:"trust_activity"`
+}
+
+// ProvenanceInput describes the provenance data in CEL rules. Available
+// is false when the provenance data was not fetched.
+type ProvenanceInput struct {
+	Available  bool                      `cel:"available"`
+	Score      float64                   `cel:"score"`
+	Historical HistoricalProvenanceInput `cel:"historical"`
+	Sigstore   SigstoreProvenanceInput   `cel:"sigstore"`
+}
+
+// HistoricalProvenanceInput describes the historical provenance of a
+// package in CEL rules.
+type HistoricalProvenanceInput struct {
+	Overlap  float64 `cel:"overlap"`
+	Common   float64 `cel:"common"`
+	Tags     float64 `cel:"tags"`
+	Versions float64 `cel:"versions"`
+}
+
+// SigstoreProvenanceInput describes the Sigstore provenance of a package
+// in CEL rules.
+type SigstoreProvenanceInput struct {
+	SourceRepo   string `cel:"source_repo"`
+	Workflow     string `cel:"workflow"`
+	Issuer       string `cel:"issuer"`
+	TokenIssuer  string `cel:"token_issuer"`
+	Transparency string `cel:"transparency"`
+}
+
+// celVariables maps the variables of CEL rules to their types.
+var celVariables = map[string]reflect.Type{
+	"dep":        reflect.TypeOf(DependencyInput{}),
+	"pkg":        reflect.TypeOf(PackageInput{}),
+	"summary":    reflect.TypeOf(SummaryInput{}),
+	"provenance": reflect.TypeOf(ProvenanceInput{}),
+}
+
+// newCELEnv returns the environment CEL rules are compiled in.
+func newCELEnv() (*cel.Env, error) {
+	opts := []cel.EnvOption{
+		cel.CrossTypeNumericComparisons(true),
+		ext.Strings(),
+		ext.NativeTypes(
+			ext.ParseStructTags(true),
+			reflect.TypeOf(DependencyInput{}),
+			reflect.TypeOf(PackageInput{}),
+			reflect.TypeOf(SummaryInput{}),
+			reflect.TypeOf(ProvenanceInput{}),
+			reflect.TypeOf(HistoricalProvenanceInput{}),
+			reflect.TypeOf(SigstoreProvenanceInput{}),
+		),
+	}
+	for name, t := range celVariables {
+		opts = append(opts, cel.Variable(name, cel.ObjectType("policy."+t.Name())))
+	}
+	return cel.NewEnv(opts...)
+}
+
+// compiledRule is a rule ready to be evaluated.
+type compiledRule struct {
+	rule    Rule
+	program cel.Program
+	err     error
+}
+
+// compileRules compiles the custom rules of the policy. Rules that do not
+// compile are returned with their error.
+func compileRules(rules []Rule) ([]compiledRule, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	env, err := newCELEnv()
+	if err != nil {
+		return nil, fmt.Errorf("creating CEL environment: %w", err)
+	}
+
+	res := make([]compiledRule, len(rules))
+	for i, r := range rules {
+		res[i].rule = r
+		res[i].program, res[i].err = compileRule(env, r)
+	}
+	return res, nil
+}
+
+func compileRule(env *cel.Env, r Rule) (cel.Program, error) {
+	if r.ID == "" {
+		return nil, fmt.Errorf("rule has no id")
+	}
+	switch r.Severity {
+	case "", result.SeverityError, result.SeverityWarning, result.SeverityNote:
+	default:
+		return nil, fmt.Errorf("rule %q has an invalid severity %q", r.ID, r.Severity)
+	}
+
+	ast, issues := env.Compile(r.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("rule %q: %w", r.ID, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("rule %q must evaluate to a bool, not %s", r.ID, ast.OutputType())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", r.ID, err)
+	}
+	return prg, nil
+}
+
+// evaluateRule evaluates a compiled rule against a package. Rules failing
+// to compile or evaluate are reported as violations, so that a broken rule
+// never lets a package through silently.
+func evaluateRule(r *compiledRule, pkg *result.Package) (bool, string) {
+	if r.err != nil {
+		return true, "could not be checked: " + r.err.Error()
+	}
+	out, _, err := r.program.Eval(ruleInput(pkg))
+	if err != nil {
+		return true, fmt.Sprintf("could not be checked by rule %q: %v", r.rule.ID, err)
+	}
+	if violated, ok := out.Value().(bool); !ok || !violated {
+		return false, ""
+	}
+	if r.rule.Message != "" {
+		return true, r.rule.Message
+	}
+	return true, "violates rule " + r.rule.ID + ": " + strings.TrimSpace(r.rule.Expression)
+}
+
+// ruleInput builds the variables of CEL rules from a package.
+func ruleInput(p *result.Package) map[string]any {
+	dep := DependencyInput{
+		Name:      p.Dependency.Name,
+		Ecosystem: p.Dependency.Ecosystem,
+		Version:   p.Dependency.Version,
+		Scope:     string(p.Dependency.Scope),
+		Direct:    p.Dependency.Direct,
+	}
+
+	pkg := PackageInput{
+		Available:  p.Metadata != nil || p.Report != nil,
+		Archived:   p.Archived(),
+		Deprecated: p.Deprecated(),
+		Malicious:  p.IsMalicious(),
+	}
+	if m := p.Metadata; m != nil {
+		pkg.Status = string(deref(m.Status))
+		pkg.Author = deref(m.Author)
+		pkg.Origin = deref(m.Origin)
+		pkg.RepositoryName = deref(m.RepositoryName)
+		pkg.Visibility = deref(m.Visibility)
+		pkg.Disabled = deref(m.Disabled)
+		pkg.StarGazersCount = int64(deref(m.StarGazersCount))
+		pkg.WatchersCount = int64(deref(m.WatchersCount))
+		pkg.ForksCount = int64(deref(m.ForksCount))
+		pkg.OpenIssuesCount = int64(deref(m.OpenIssuesCount))
+		pkg.ContributorCount = int64(deref(m.ContributorCount))
+		pkg.PublicRepos = int64(deref(m.PublicRepos))
+		pkg.Followers = int64(deref(m.Followers))
+	}
+
+	summary := SummaryInput{
+		Available:      p.Summary != nil || p.Report != nil,
+		Malicious:      p.IsMalicious(),
+		ProvenanceType: string(p.ProvenanceType()),
+	}
+	if score := p.Score(); score != nil {
+		summary.HasScore, summary.Score = true, *score
+	}
+	if risk := p.Typosquatting(); risk != nil {
+		summary.Typosquatting = *risk
+	}
+	if s := p.Summary; s != nil {
+		summary.Provenance = s.Description.Provenance
+		summary.Activity = s.Description.Activity
+		summary.ActivityUser = s.Description.ActivityUser
+		summary.ActivityRepo = s.Description.ActivityRepo
+		summary.TrustSummary = s.Description.TrustSummary
+		summary.TrustActivity = s.Description.TrustActivity
+	}
+
+	var provenance ProvenanceInput
+	if pr := p.Provenance; pr != nil {
+		provenance = ProvenanceInput{
+			Available:  true,
+			Score:      deref(pr.Sore),
+			Historical: HistoricalProvenanceInput(pr.Historical),
+			Sigstore:   SigstoreProvenanceInput(pr.Sigstore),
+		}
+	}
+
+	return map[string]any{
+		"dep":        dep,
+		"pkg":        pkg,
+		"summary":    summary,
+		"provenance": provenance,
+	}
+}
+
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+	return *v
+}
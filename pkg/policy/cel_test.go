@@ -0,0 +1,122 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+const celPolicy = `
+rules:
+  - id: archived-unverified
+    expression: >-
+      pkg.archived &&
+      !(summary.provenance_type == "verified_provenance_match" && pkg.contributor_count > 5)
+    message: is archived without verified provenance and a healthy contributor base
+  - id: unsigned
+    expression: provenance.available && provenance.sigstore.issuer == ""
+    severity: note
+  - id: low-activity
+    expression: dep.direct && summary.has_score && summary.activity < 3
+    severity: warning
+    message: has little activity
+`
+
+func TestLoadRules(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(strings.NewReader(celPolicy))
+	require.NoError(t, err)
+	require.Len(t, p.Rules, 3)
+	require.Equal(t, result.SeverityNote, p.Rules[1].Severity)
+
+	testCases := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{name: "unknown field", policy: "rules: [{id: x, expression: pkg.archivd}]", err: "undefined field 'archivd'"},
+		{name: "unknown variable", policy: "rules: [{id: x, expression: repo.archived}]", err: "undeclared reference"},
+		{name: "not a bool", policy: "rules: [{id: x, expression: summary.score}]", err: "must evaluate to a bool"},
+		{name: "type error", policy: `rules: [{id: x, expression: pkg.author > 3}]`, err: "no matching overload"},
+		{name: "missing id", policy: "rules: [{expression: pkg.archived}]", err: "rule has no id"},
+		{name: "bad severity", policy: "rules: [{id: x, expression: pkg.archived, severity: fatal}]", err: "invalid severity"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Load(strings.NewReader(tc.policy))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(strings.NewReader(celPolicy))
+	require.NoError(t, err)
+
+	yes := true
+	few, many := 2, 10
+	score := 8.0
+	verified := v2types.ProvenanceTypeVerified
+	pkg := func(name string, contributors *int, pt *v2types.ProvenanceType) result.Package {
+		return result.Package{
+			Dependency: parser.Dependency{Name: name, Ecosystem: "npm", Version: "1.0.0"},
+			Summary: &v2types.PackageSummaryAnnotation{
+				Score:       &score,
+				Description: v2types.SummaryDescription{Activity: 5, ProvenanceType: pt},
+			},
+			Metadata: &v2types.TrustyPackageData{Archived: &yes, ContributorCount: contributors},
+		}
+	}
+
+	quiet := pkg("quiet", nil, &verified)
+	quiet.Dependency.Direct = true
+	quiet.Summary.Description.Activity = 1
+	quiet.Metadata.Archived = nil
+	unsigned := pkg("unsigned", &many, &verified)
+	unsigned.Provenance = &v2types.Provenance{}
+
+	packages := []result.Package{
+		pkg("healthy", &many, &verified),
+		pkg("lonely", &few, &verified),
+		pkg("unknown", &many, nil),
+		quiet,
+		unsigned,
+	}
+
+	var got []string
+	for _, v := range p.Evaluate(packages) {
+		got = append(got, string(v.Severity)+" "+string(v.Rule)+": "+v.Message)
+	}
+	require.Equal(t, []string{
+		"error policy/rule/archived-unverified: lonely@1.0.0 is archived without verified provenance" +
+			" and a healthy contributor base",
+		"error policy/rule/archived-unverified: unknown@1.0.0 is archived without verified provenance" +
+			" and a healthy contributor base",
+		"warning policy/rule/low-activity: quiet@1.0.0 has little activity",
+		`note policy/rule/unsigned: unsigned@1.0.0 violates rule unsigned: provenance.available && provenance.sigstore.issuer == ""`,
+	}, got)
+	require.True(t, Failed(p.Evaluate(packages[:2])))
+	require.False(t, Failed(p.Evaluate(packages[3:])))
+}
+
+func TestInvalidRulesFailClosed(t *testing.T) {
+	t.Parallel()
+
+	p := &Policy{Rules: []Rule{{ID: "broken", Expression: "pkg.nope"}}}
+	violations := p.Evaluate([]result.Package{{Dependency: parser.Dependency{Name: "foo", Version: "1.0"}}})
+	require.Len(t, violations, 1)
+	require.Equal(t, result.RuleID("policy/rule/broken"), violations[0].Rule)
+	require.Equal(t, result.SeverityError, violations[0].Severity)
+	require.Contains(t, violations[0].Message, "could not be checked")
+}
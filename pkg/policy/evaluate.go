@@ -0,0 +1,296 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v1types "github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+const (
+	// RuleDenied flags packages in the denylist of the policy.
+	RuleDenied result.RuleID = "policy/denied"
+	// RuleUnverifiedProvenance flags packages required to have verified
+	// provenance that do not.
+	RuleUnverifiedProvenance result.RuleID = "policy/unverified-provenance"
+	// RuleInvalid flags packages that could not be checked because the
+	// rules of the policy are invalid.
+	RuleInvalid result.RuleID = "policy/invalid"
+)
+
+// Rules describes the built-in requirements of policies, like
+// result.Rules describes the checks of Trusty.
+var Rules = []result.Rule{
+	{
+		ID:          RuleDenied,
+		Name:        "DeniedPackage",
+		Description: "The package is in the denylist of the policy.",
+		Help:        "Remove the package, or update the deny entry of the policy matching it.",
+		Severity:    result.SeverityError,
+	},
+	{
+		ID:          RuleUnverifiedProvenance,
+		Name:        "UnverifiedProvenance",
+		Description: "The policy requires the package to have verified provenance, and it does not.",
+		Help:        "Use a release of the package published with verifiable provenance, or allow it in the policy.",
+		Severity:    result.SeverityError,
+	},
+	{
+		ID:          RuleInvalid,
+		Name:        "InvalidPolicy",
+		Description: "The package could not be checked because the rules of the policy are invalid.",
+		Help:        "Fix the rules of the policy; run \"trusty policy check\" to see the errors.",
+		Severity:    result.SeverityError,
+	},
+}
+
+// RuleDescriptions describes the rules the violations of the policy can
+// report: the built-in requirements of Rules, followed by the custom
+// rules of the policy.
+func (p *Policy) RuleDescriptions() []result.Rule {
+	res := append([]result.Rule(nil), Rules...)
+	for _, r := range p.Rules {
+		desc := r.Message
+		if desc == "" {
+			desc = r.Expression
+		}
+		severity := r.Severity
+		if severity == "" {
+			severity = result.SeverityError
+		}
+		res = append(res, result.Rule{
+			ID:          RuleIDPrefix + result.RuleID(r.ID),
+			Name:        r.ID,
+			Description: desc,
+			Help:        "The package matches the custom rule " + r.ID + " of the policy: " + r.Expression,
+			Severity:    severity,
+		})
+	}
+	return res
+}
+
+// Violation is a requirement of the policy a package does not meet.
+type Violation struct {
+	// Rule is the check the package failed.
+	Rule result.RuleID
+
+	// Severity is the severity of the rule. The built-in requirements of
+	// the policy are errors, custom rules have the severity they set.
+	Severity result.Severity
+
+	// Package is the package violating the policy.
+	Package *result.Package
+
+	// Message explains the violation, including the values compared and
+	// the part of the policy that set the requirement.
+	Message string
+}
+
+// Evaluate checks the packages against the policy at the current time,
+// and returns the violations found. Packages that could not be fetched
+// are skipped.
+func (p *Policy) Evaluate(packages []result.Package) []Violation {
+	return p.EvaluateAt(packages, time.Now())
+}
+
+// EvaluateAt works like Evaluate, using now to decide which allowlist and
+// denylist entries have expired.
+func (p *Policy) EvaluateAt(packages []result.Package, now time.Time) []Violation {
+	var res []Violation
+	for i := range packages {
+		res = append(res, p.evaluate(&packages[i], now)...)
+	}
+	return res
+}
+
+// EvaluateReply checks a report of the v1 API against the policy.
+func (p *Policy) EvaluateReply(reply *v1types.Reply) []Violation {
+	pkg := result.FromReply(reply)
+	return p.evaluate(&pkg, time.Now())
+}
+
+// EvaluateV2 checks the data returned by the v2 API for dep against the
+// policy. Either of summary and metadata can be nil, in which case the
+// checks relying on them pass.
+func (p *Policy) EvaluateV2(
+	dep *v2types.Dependency, summary *v2types.PackageSummaryAnnotation, metadata *v2types.TrustyPackageData,
+) []Violation {
+	pkg := result.FromV2(dep, summary, metadata)
+	return p.evaluate(&pkg, time.Now())
+}
+
+func (p *Policy) evaluate(pkg *result.Package, now time.Time) []Violation {
+	if pkg.Err != nil {
+		return nil
+	}
+	id := pkg.Dependency.ID()
+	var res []Violation
+	var note string
+	addWith := func(rule result.RuleID, severity result.Severity, format string, args ...any) {
+		res = append(res, Violation{
+			Rule: rule, Severity: severity, Package: pkg, Message: id + " " + fmt.Sprintf(format, args...) + note,
+		})
+	}
+	add := func(rule result.RuleID, format string, args ...any) {
+		addWith(rule, result.SeverityError, format, args...)
+	}
+
+	if e := matchingEntry(p.Deny, pkg, now, false); e != nil {
+		add(RuleDenied, "is denied by the policy entry %q%s", e.Package, reasonSuffix(e))
+	}
+	if e := matchingEntry(p.Allow, pkg, now, false); e != nil {
+		// Allowed packages only fail when explicitly denied
+		return res
+	}
+	if e := matchingEntry(p.Allow, pkg, now, true); e != nil {
+		note = fmt.Sprintf(" (the allow entry %q expired on %s)", e.Package, e.Expires.Format(dateLayout))
+	}
+
+	if (p.BlockMalicious == nil || *p.BlockMalicious) && pkg.IsMalicious() {
+		msg := "is reported as malicious"
+		if mal := pkg.Malicious(); mal.Summary != "" {
+			msg += ": " + mal.Summary
+		}
+		add(result.RuleMalicious, "%s", msg)
+	}
+
+	minScore, minFrom := p.threshold(pkg.Dependency.Ecosystem,
+		func(t Thresholds) *float64 { return t.MinScore }, p.MinScore)
+	if score := pkg.Score(); minScore != nil && score != nil && *score < *minScore {
+		add(result.RuleLowScore, "has a Trusty score of %.1f, below the minimum of %.1f set by %s",
+			*score, *minScore, minFrom)
+	}
+
+	maxTypo, maxFrom := p.threshold(pkg.Dependency.Ecosystem,
+		func(t Thresholds) *float64 { return t.MaxTyposquatting }, p.MaxTyposquatting)
+	if risk := pkg.Typosquatting(); maxTypo != nil && risk != nil && *risk > *maxTypo {
+		add(result.RuleTyposquatting, "has a typosquatting risk of %.1f, above the maximum of %.1f set by %s",
+			*risk, *maxTypo, maxFrom)
+	}
+
+	if p.BlockDeprecated && pkg.Deprecated() {
+		add(result.RuleDeprecated, "is deprecated, and block_deprecated is set")
+	}
+	if p.BlockArchived && pkg.Archived() {
+		add(result.RuleArchived, "has an archived repository, and block_archived is set")
+	}
+
+	for _, pattern := range p.RequireVerifiedProvenance {
+		if !matchesPackage(globPattern(pattern), "", pkg) {
+			continue
+		}
+		if pt := pkg.ProvenanceType(); pt != v2types.ProvenanceTypeVerified {
+			if pt == "" {
+				pt = v2types.ProvenanceTypeUnknown
+			}
+			add(RuleUnverifiedProvenance, "has %s provenance, but require_verified_provenance matches it with %q", pt, pattern)
+		}
+		break
+	}
+
+	rules, err := p.compiledRules()
+	if err != nil {
+		add(RuleInvalid, "could not be checked against the rules of the policy: %v", err)
+	}
+	for i := range rules {
+		r := &rules[i]
+		if violated, msg := evaluateRule(r, pkg); violated {
+			severity := r.rule.Severity
+			if severity == "" || r.err != nil {
+				severity = result.SeverityError
+			}
+			addWith(RuleIDPrefix+result.RuleID(r.rule.ID), severity, "%s", msg)
+		}
+	}
+	return res
+}
+
+// Failed returns true if any of the violations is an error.
+func Failed(violations []Violation) bool {
+	for _, v := range violations {
+		if v.Severity == result.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// threshold returns the value of a threshold for an ecosystem, and the
+// part of the policy that set it.
+func (p *Policy) threshold(ecosystem string, get func(Thresholds) *float64, global *float64) (*float64, string) {
+	if t, ok := p.Ecosystems[ecosystem]; ok {
+		if v := get(t); v != nil {
+			return v, "the " + ecosystem + " ecosystem policy"
+		}
+	}
+	return global, "the policy"
+}
+
+// matchingEntry returns the first entry matching the package that has
+// expired at now, or has not when expired is false.
+func matchingEntry(entries []Entry, pkg *result.Package, now time.Time, expired bool) *Entry {
+	for i := range entries {
+		e := &entries[i]
+		if e.Expired(now) != expired {
+			continue
+		}
+		if e.Package == "" || !matchesPackage(globPattern(e.Package), e.Ecosystem, pkg) {
+			continue
+		}
+		if e.Versions == "" || inVersionRange(e.Package, e.Ecosystem, e.Versions, pkg) {
+			return e
+		}
+	}
+	return nil
+}
+
+// matchesPackage returns true if pattern matches the name of the package,
+// its package URL, or its package URL without the version. Package URLs
+// are matched both escaped and not, so that "pkg:npm/@scope/*" works.
+func matchesPackage(pattern *regexp.Regexp, ecosystem string, pkg *result.Package) bool {
+	dep := &pkg.Dependency
+	if pattern.MatchString(dep.Name) && (ecosystem == "" || strings.EqualFold(ecosystem, dep.Ecosystem)) {
+		return true
+	}
+	unversioned := *dep
+	unversioned.Version = ""
+	for _, purl := range []string{dep.Purl(), unversioned.Purl()} {
+		if purl == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(purl); err == nil && pattern.MatchString(unescaped) {
+			return true
+		}
+		if pattern.MatchString(purl) {
+			return true
+		}
+	}
+	return false
+}
+
+func reasonSuffix(e *Entry) string {
+	if e.Reason == "" {
+		return ""
+	}
+	return ": " + e.Reason
+}
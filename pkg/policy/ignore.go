@@ -0,0 +1,281 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	"github.com/stacklok/trusty-sdk-go/pkg/version"
+)
+
+// IgnoreFileName is the conventional name of ignore files.
+const IgnoreFileName = ".trustyignore"
+
+// IgnoreFile lists accepted risks, so that their findings and violations
+// are not reported. It is written in YAML:
+//
+//	suppressions:
+//	  - purl: pkg:npm/lodash
+//	    versions: ">=4.17.0 <4.17.21"
+//	    rule: trusty/low-score
+//	    path: frontend/*
+//	    justification: Only used at build time, upgrade tracked in JIRA-123
+//	    expires: 2025-06-30
+type IgnoreFile struct {
+	Suppressions []Suppression `yaml:"suppressions" json:"suppressions"`
+}
+
+// Suppression accepts the findings and violations matching all of its
+// criteria. At least one of Purl, Rule and Path must be set.
+type Suppression struct {
+	// Purl is the package URL of the package, or a glob matching it.
+	// Package URLs without a version match all the versions of the
+	// package.
+	Purl string `yaml:"purl,omitempty" json:"purl,omitempty"`
+
+	// Versions restricts the suppression to a range of versions of the
+	// package, written in the syntax of the ecosystem of the package URL,
+	// such as "^4.17.0" for npm or "[1.0,2.0)" for Maven. When Purl has no
+	// type, it is written as space or comma separated comparisons such as
+	// ">=1.2.0 <2", and a version without operator matches exactly.
+	Versions string `yaml:"versions,omitempty" json:"versions,omitempty"`
+
+	// Rule is the ID of the rule suppressed, or a glob matching it.
+	Rule string `yaml:"rule,omitempty" json:"rule,omitempty"`
+
+	// Path is the path of the manifest the dependency is declared in, or a
+	// glob matching it.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// Justification explains why the risk is accepted. It is required.
+	Justification string `yaml:"justification" json:"justification"`
+
+	// Expires is the date from which the suppression no longer applies.
+	// It is required, so that accepted risks are reviewed.
+	Expires *Date `yaml:"expires" json:"expires"`
+}
+
+// Expired returns true if the suppression no longer applies at now.
+func (s *Suppression) Expired(now time.Time) bool {
+	return s.Expires != nil && !now.UTC().Before(s.Expires.Time)
+}
+
+// String describes the suppression by its criteria.
+func (s *Suppression) String() string {
+	var parts []string
+	for _, c := range []struct{ name, value string }{
+		{"purl", s.Purl}, {"versions", s.Versions}, {"rule", s.Rule}, {"path", s.Path},
+	} {
+		if c.value != "" {
+			parts = append(parts, c.name+"="+strconv.Quote(c.value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// LoadIgnore reads an ignore file. Unknown fields are rejected.
+func LoadIgnore(r io.Reader) (*IgnoreFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var f IgnoreFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decoding ignore file: %w", err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// LoadIgnoreFile reads an ignore file from path.
+func LoadIgnoreFile(path string) (*IgnoreFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadIgnore(f)
+}
+
+// Validate checks that every suppression has criteria, a justification
+// and an expiry date, and that the version ranges are valid.
+func (f *IgnoreFile) Validate() error {
+	var errs []error
+	for i := range f.Suppressions {
+		s := &f.Suppressions[i]
+		if s.Purl == "" && s.Rule == "" && s.Path == "" {
+			errs = append(errs, fmt.Errorf("suppressions[%d] needs at least one of purl, rule and path", i))
+		}
+		if s.Versions != "" && s.Purl == "" {
+			errs = append(errs, fmt.Errorf("suppressions[%d] sets versions without a purl", i))
+		}
+		if s.Versions != "" {
+			if _, err := versionRange(s.Purl, "", s.Versions); err != nil {
+				errs = append(errs, fmt.Errorf("suppressions[%d]: %w", i, err))
+			}
+		}
+		if strings.TrimSpace(s.Justification) == "" {
+			errs = append(errs, fmt.Errorf("suppressions[%d] has no justification", i))
+		}
+		if s.Expires == nil {
+			errs = append(errs, fmt.Errorf("suppressions[%d] has no expiry date", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProblemKind is the reason a suppression needs to be cleaned up.
+type ProblemKind string
+
+const (
+	// ProblemExpired is a suppression past its expiry date.
+	ProblemExpired ProblemKind = "expired"
+	// ProblemUnused is a suppression that matched nothing.
+	ProblemUnused ProblemKind = "unused"
+)
+
+// SuppressionProblem is a suppression that needs to be cleaned up.
+type SuppressionProblem struct {
+	Kind        ProblemKind
+	Index       int
+	Suppression *Suppression
+	Message     string
+}
+
+// Suppressor filters findings and violations through the suppressions of
+// an ignore file, keeping track of the suppressions used.
+type Suppressor struct {
+	file *IgnoreFile
+	now  time.Time
+	used []bool
+}
+
+// Suppressor returns a suppressor applying the suppressions active at now.
+func (f *IgnoreFile) Suppressor(now time.Time) *Suppressor {
+	return &Suppressor{file: f, now: now, used: make([]bool, len(f.Suppressions))}
+}
+
+// Findings returns the findings not suppressed.
+func (s *Suppressor) Findings(findings []result.Finding) []result.Finding {
+	var res []result.Finding
+	for _, f := range findings {
+		if !s.suppressed(f.Rule, f.Package) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// Violations returns the violations not suppressed.
+func (s *Suppressor) Violations(violations []Violation) []Violation {
+	var res []Violation
+	for _, v := range violations {
+		if !s.suppressed(v.Rule, v.Package) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// Problems returns the suppressions that have expired, and the ones that
+// did not suppress anything so far.
+func (s *Suppressor) Problems() []SuppressionProblem {
+	var res []SuppressionProblem
+	for i := range s.file.Suppressions {
+		sup := &s.file.Suppressions[i]
+		switch {
+		case sup.Expired(s.now):
+			res = append(res, SuppressionProblem{
+				Kind: ProblemExpired, Index: i, Suppression: sup,
+				Message: fmt.Sprintf("suppression %s expired on %s", sup, sup.Expires.Format(dateLayout)),
+			})
+		case !s.used[i]:
+			res = append(res, SuppressionProblem{
+				Kind: ProblemUnused, Index: i, Suppression: sup,
+				Message: fmt.Sprintf("suppression %s did not match any finding", sup),
+			})
+		}
+	}
+	return res
+}
+
+// suppressed returns true if an active suppression matches the rule and
+// the package, marking all the matching suppressions as used.
+func (s *Suppressor) suppressed(rule result.RuleID, pkg *result.Package) bool {
+	res := false
+	for i := range s.file.Suppressions {
+		sup := &s.file.Suppressions[i]
+		if !sup.Expired(s.now) && sup.matches(rule, pkg) {
+			s.used[i] = true
+			res = true
+		}
+	}
+	return res
+}
+
+func (s *Suppression) matches(rule result.RuleID, pkg *result.Package) bool {
+	if s.Rule != "" && !globPattern(s.Rule).MatchString(string(rule)) {
+		return false
+	}
+	if pkg == nil {
+		return s.Purl == "" && s.Path == ""
+	}
+	if s.Path != "" && !globPattern(s.Path).MatchString(pkg.Dependency.Location.Path) {
+		return false
+	}
+	if s.Purl != "" && !matchesPackage(globPattern(s.Purl), "", pkg) {
+		return false
+	}
+	if s.Versions != "" && !inVersionRange(s.Purl, "", s.Versions, pkg) {
+		return false
+	}
+	return true
+}
+
+// versionRange parses the versions of the packages matched by a package
+// name, URL or glob, in the range syntax of the ecosystem of the package
+// URL type. Patterns without a type use comparisons, like "ecosystem" set
+// to an unknown ecosystem.
+func versionRange(pattern, ecosystem, versions string) (version.Range, error) {
+	if rest, ok := strings.CutPrefix(pattern, "pkg:"); ok {
+		if t, _, _ := strings.Cut(rest, "/"); !strings.Contains(t, "*") {
+			ecosystem = t
+		}
+	}
+	return version.ParseRangeFor(ecosystem, versions)
+}
+
+// inVersionRange returns true if the pinned version of the package is in
+// the range of versions. Packages without a pinned version are in no range.
+func inVersionRange(pattern, ecosystem, versions string, pkg *result.Package) bool {
+	r, err := versionRange(pattern, ecosystem, versions)
+	return err == nil && r.ContainsString(pkg.Dependency.PinnedVersion())
+}
@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+const testIgnore = `
+suppressions:
+  - purl: pkg:npm/lodash
+    versions: ">= 4.17.0, <4.17.21"
+    rule: trusty/low-score
+    justification: Upgrade tracked in JIRA-123
+    expires: 2025-06-30
+  - rule: trusty/deprecated
+    path: "tools/*"
+    justification: Build tooling only
+    expires: 2025-01-01
+  - purl: pkg:pypi/requests@2.31.0
+    rule: "trusty/*"
+    justification: Reviewed by security
+    expires: 2025-12-31
+  - purl: pkg:npm/unused
+    justification: No longer a dependency
+    expires: 2025-12-31
+`
+
+func TestLoadIgnore(t *testing.T) {
+	t.Parallel()
+
+	f, err := LoadIgnore(strings.NewReader(testIgnore))
+	require.NoError(t, err)
+	require.Len(t, f.Suppressions, 4)
+	require.Equal(t, `purl="pkg:npm/lodash" versions=">= 4.17.0, <4.17.21" rule="trusty/low-score"`,
+		f.Suppressions[0].String())
+
+	testCases := []struct {
+		name   string
+		ignore string
+		err    string
+	}{
+		{name: "no criteria", ignore: "suppressions: [{justification: x, expires: 2025-01-01}]", err: "at least one of"},
+		{name: "no justification", ignore: "suppressions: [{rule: x, expires: 2025-01-01}]", err: "no justification"},
+		{name: "no expiry", ignore: "suppressions: [{rule: x, justification: y}]", err: "no expiry date"},
+		{name: "versions without purl", ignore: "suppressions: [{rule: x, versions: '1.0', justification: y, expires: 2025-01-01}]",
+			err: "without a purl"},
+		{name: "bad range", ignore: "suppressions: [{purl: x, versions: '~>1', justification: y, expires: 2025-01-01}]",
+			err: "invalid version range"},
+		{name: "bad ecosystem range", ignore: "suppressions: [{purl: 'pkg:maven/x/y', versions: '[1.0', justification: y, expires: 2025-01-01}]",
+			err: "invalid version range"},
+		{name: "unknown field", ignore: "suppressions: [{package: x, justification: y, expires: 2025-01-01}]",
+			err: "field package not found"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := LoadIgnore(strings.NewReader(tc.ignore))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestSuppressor(t *testing.T) {
+	t.Parallel()
+
+	f, err := LoadIgnore(strings.NewReader(testIgnore))
+	require.NoError(t, err)
+
+	pkg := func(ecosystem, name, version, path string) *result.Package {
+		return &result.Package{Dependency: parser.Dependency{
+			Name: name, Ecosystem: ecosystem, Version: version, Location: parser.Location{Path: path},
+		}}
+	}
+	finding := func(rule result.RuleID, p *result.Package) result.Finding {
+		return result.Finding{Rule: rule, Package: p, Message: string(rule) + " " + p.Dependency.ID()}
+	}
+
+	findings := []result.Finding{
+		finding(result.RuleLowScore, pkg("npm", "lodash", "4.17.4", "package-lock.json")),
+		finding(result.RuleLowScore, pkg("npm", "lodash", "4.17.21", "package-lock.json")),
+		finding(result.RuleDeprecated, pkg("npm", "lodash", "4.17.4", "package-lock.json")),
+		finding(result.RuleDeprecated, pkg("npm", "request", "2.88.2", "tools/package.json")),
+		finding(result.RuleMalicious, pkg("pypi", "requests", "2.31.0", "requirements.txt")),
+		finding(result.RuleMalicious, pkg("pypi", "requests", "2.32.0", "requirements.txt")),
+	}
+
+	s := f.Suppressor(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	var got []string
+	for _, f := range s.Findings(findings) {
+		got = append(got, f.Message)
+	}
+	require.Equal(t, []string{
+		"trusty/low-score lodash@4.17.21",
+		"trusty/deprecated lodash@4.17.4",
+		"trusty/deprecated request@2.88.2",
+		"trusty/malicious requests@2.32.0",
+	}, got)
+
+	violations := s.Violations([]Violation{{Rule: RuleDenied, Package: pkg("pypi", "requests", "2.31.0", "")}})
+	require.Len(t, violations, 1)
+
+	var problems []string
+	for _, p := range s.Problems() {
+		problems = append(problems, string(p.Kind)+": "+p.Message)
+	}
+	require.Equal(t, []string{
+		`expired: suppression rule="trusty/deprecated" path="tools/*" expired on 2025-01-01`,
+		`unused: suppression purl="pkg:npm/unused" did not match any finding`,
+	}, problems)
+}
+
+func TestVersionRange(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		pattern  string
+		versions string
+		dep      parser.Dependency
+		want     bool
+	}{
+		{pattern: "x", versions: "1.2.3", dep: parser.Dependency{Version: "1.2.3"}, want: true},
+		{pattern: "x", versions: "1.2", dep: parser.Dependency{Version: "1.2.0"}, want: true},
+		{pattern: "x", versions: "=1.2.3", dep: parser.Dependency{Version: "v1.2.3"}, want: true},
+		{pattern: "x", versions: ">=1.0 <2", dep: parser.Dependency{Version: "1.10.0"}, want: true},
+		{pattern: "x", versions: ">=1.0 <2", dep: parser.Dependency{Version: "2.0.0"}, want: false},
+		{pattern: "x", versions: "<1.0.0", dep: parser.Dependency{Version: "1.0.0-rc.1"}, want: true},
+		{pattern: "x", versions: "!= 1.0", dep: parser.Dependency{Version: "1.0.1"}, want: true},
+		{pattern: "x", versions: ">1.0", dep: parser.Dependency{}, want: false},
+		{pattern: "pkg:npm/lodash", versions: "^4.17.0", dep: parser.Dependency{Ecosystem: "npm", Version: "4.17.20"}, want: true},
+		{pattern: "pkg:npm/lodash", versions: "^4.17.0", dep: parser.Dependency{Ecosystem: "npm", Version: "5.0.0"}, want: false},
+		{pattern: "pkg:npm/lodash", versions: "<4.17.21", dep: parser.Dependency{Ecosystem: "npm", Constraint: "4.17.20"}, want: true},
+		{pattern: "pkg:npm/lodash", versions: "<4.17.21", dep: parser.Dependency{Ecosystem: "npm", Constraint: "^4.17.20"}, want: false},
+		{pattern: "pkg:pypi/requests", versions: "~=2.0", dep: parser.Dependency{Ecosystem: "pypi", Constraint: "==2.31.0"}, want: true},
+		{pattern: "pkg:maven/org.example/*", versions: "[1.0,2.0)", dep: parser.Dependency{Ecosystem: "maven", Version: "1.5"}, want: true},
+		{pattern: "pkg:cargo/serde", versions: "1.0", dep: parser.Dependency{Ecosystem: "crates", Version: "1.0.200"}, want: true},
+		{pattern: "lodash", versions: "^4", dep: parser.Dependency{Ecosystem: "npm", Version: "4.0.0"}, want: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.pattern+" "+tc.versions, func(t *testing.T) {
+			t.Parallel()
+
+			pkg := &result.Package{Dependency: tc.dep}
+			require.Equal(t, tc.want, inVersionRange(tc.pattern, "", tc.versions, pkg))
+		})
+	}
+}
@@ -0,0 +1,281 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy decides whether dependencies are acceptable using the
+// data Trusty has about them and a declarative policy, written in YAML
+// or JSON:
+//
+//	min_score: 5
+//	block_malicious: true
+//	block_deprecated: true
+//	max_typosquatting: 7
+//	ecosystems:
+//	  npm:
+//	    min_score: 6
+//	require_verified_provenance:
+//	  - "@acme/*"
+//	allow:
+//	  - package: left-pad
+//	    reason: Vendored copy under review
+//	    expires: 2025-01-31
+//	deny:
+//	  - package: pkg:npm/event-stream@3.3.6
+//	    reason: Compromised release
+//	  - package: pkg:npm/tar
+//	    versions: "<6.1.9"
+//	    reason: Path traversal
+//	rules:
+//	  - id: few-contributors
+//	    expression: dep.direct && pkg.available && pkg.contributor_count < 2
+//	    severity: warning
+//	    message: has a single contributor
+//
+// Rules are CEL expressions, described in Rule. Known risks are accepted
+// without relaxing the policy with an ignore file, described in IgnoreFile.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dateLayout is the layout of the dates in policies.
+const dateLayout = "2006-01-02"
+
+// Policy is the set of requirements dependencies must meet.
+type Policy struct {
+	// MinScore is the minimum Trusty score of the packages. Packages
+	// without a score are not checked.
+	MinScore *float64 `yaml:"min_score,omitempty" json:"min_score,omitempty"`
+
+	// MaxTyposquatting is the maximum typosquatting risk of the packages.
+	MaxTyposquatting *float64 `yaml:"max_typosquatting,omitempty" json:"max_typosquatting,omitempty"`
+
+	// Ecosystems overrides the thresholds for the packages of an
+	// ecosystem, keyed by the ecosystem name (eg "npm" or "pypi").
+	Ecosystems map[string]Thresholds `yaml:"ecosystems,omitempty" json:"ecosystems,omitempty"`
+
+	// BlockMalicious rejects the packages reported as malicious. It is
+	// enabled unless explicitly set to false.
+	BlockMalicious *bool `yaml:"block_malicious,omitempty" json:"block_malicious,omitempty"`
+
+	// BlockDeprecated rejects deprecated packages.
+	BlockDeprecated bool `yaml:"block_deprecated,omitempty" json:"block_deprecated,omitempty"`
+
+	// BlockArchived rejects packages whose repository is archived.
+	BlockArchived bool `yaml:"block_archived,omitempty" json:"block_archived,omitempty"`
+
+	// RequireVerifiedProvenance holds the patterns of the packages that
+	// must have verified provenance.
+	RequireVerifiedProvenance []string `yaml:"require_verified_provenance,omitempty" json:"require_verified_provenance,omitempty"`
+
+	// Allow lists the packages accepted regardless of the other rules.
+	Allow []Entry `yaml:"allow,omitempty" json:"allow,omitempty"`
+
+	// Deny lists the packages rejected regardless of the other rules.
+	Deny []Entry `yaml:"deny,omitempty" json:"deny,omitempty"`
+
+	// Rules are custom checks written as CEL expressions.
+	Rules []Rule `yaml:"rules,omitempty" json:"rules,omitempty"`
+
+	compileOnce sync.Once
+	compiled    []compiledRule
+	compileErr  error
+}
+
+// Thresholds are the score limits applied to the packages of an
+// ecosystem. Unset thresholds fall back to the ones of the policy.
+type Thresholds struct {
+	MinScore         *float64 `yaml:"min_score,omitempty" json:"min_score,omitempty"`
+	MaxTyposquatting *float64 `yaml:"max_typosquatting,omitempty" json:"max_typosquatting,omitempty"`
+}
+
+// Entry is an item of an allowlist or denylist.
+type Entry struct {
+	// Package is a package name, a package URL or a glob matching either,
+	// where * matches any sequence of characters. Package URLs without a
+	// version match all the versions of the package.
+	Package string `yaml:"package" json:"package"`
+
+	// Ecosystem restricts the entry to an ecosystem when Package is not a
+	// package URL.
+	Ecosystem string `yaml:"ecosystem,omitempty" json:"ecosystem,omitempty"`
+
+	// Versions restricts the entry to a range of versions, written in the
+	// syntax of the ecosystem of the package, such as "<4.17.21" for npm
+	// or "[1.0,2.0)" for Maven.
+	Versions string `yaml:"versions,omitempty" json:"versions,omitempty"`
+
+	// Reason explains why the package is listed.
+	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+
+	// Expires is the date from which the entry no longer applies.
+	Expires *Date `yaml:"expires,omitempty" json:"expires,omitempty"`
+}
+
+// Date is a calendar date, written as YYYY-MM-DD.
+type Date struct {
+	time.Time
+}
+
+// UnmarshalYAML reads a date in the YYYY-MM-DD format.
+func (d *Date) UnmarshalYAML(node *yaml.Node) error {
+	t, err := time.Parse(dateLayout, node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid date %q, expected YYYY-MM-DD", node.Line, node.Value)
+	}
+	d.Time = t
+	return nil
+}
+
+// MarshalYAML writes a date in the YYYY-MM-DD format.
+func (d Date) MarshalYAML() (any, error) {
+	return d.Format(dateLayout), nil
+}
+
+// UnmarshalJSON reads a date in the YYYY-MM-DD format.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", s)
+	}
+	d.Time = t
+	return nil
+}
+
+// MarshalJSON writes a date in the YYYY-MM-DD format.
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.Format(dateLayout) + `"`), nil
+}
+
+func (e *Entry) validate(name string) []error {
+	if e.Package == "" {
+		return []error{fmt.Errorf("%s has no package", name)}
+	}
+	if e.Versions != "" {
+		if _, err := versionRange(e.Package, e.Ecosystem, e.Versions); err != nil {
+			return []error{fmt.Errorf("%s: %w", name, err)}
+		}
+	}
+	return nil
+}
+
+// Expired returns true if the entry no longer applies at now. Entries
+// expire at the start of their expiry date, in UTC.
+func (e *Entry) Expired(now time.Time) bool {
+	return e.Expires != nil && !now.UTC().Before(e.Expires.Time)
+}
+
+// Load reads a policy in YAML or JSON. Unknown fields are rejected to
+// catch typos that would silently relax the policy.
+func Load(r io.Reader) (*Policy, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	// JSON documents are valid YAML
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decoding policy: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// LoadFile reads a policy from a YAML or JSON file.
+func LoadFile(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Validate checks that the thresholds are within the range of Trusty
+// scores, that the allowlist and denylist entries are valid, and that
+// the rules compile to boolean expressions.
+func (p *Policy) Validate() error {
+	var errs []error
+	checkScore := func(name string, v *float64) {
+		if v != nil && (*v < 0 || *v > 10) {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 10, got %v", name, *v))
+		}
+	}
+	checkScore("min_score", p.MinScore)
+	checkScore("max_typosquatting", p.MaxTyposquatting)
+	for ecosystem, t := range p.Ecosystems {
+		checkScore("ecosystems."+ecosystem+".min_score", t.MinScore)
+		checkScore("ecosystems."+ecosystem+".max_typosquatting", t.MaxTyposquatting)
+	}
+	for _, pattern := range p.RequireVerifiedProvenance {
+		if pattern == "" {
+			errs = append(errs, errors.New("require_verified_provenance has an empty pattern"))
+		}
+	}
+	for i := range p.Allow {
+		errs = append(errs, p.Allow[i].validate(fmt.Sprintf("allow[%d]", i))...)
+	}
+	for i := range p.Deny {
+		errs = append(errs, p.Deny[i].validate(fmt.Sprintf("deny[%d]", i))...)
+	}
+	rules, err := p.compiledRules()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for i := range rules {
+		if rules[i].err != nil {
+			errs = append(errs, fmt.Errorf("rules[%d]: %w", i, rules[i].err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compiledRules compiles the rules of the policy the first time it is
+// called. The rules must not be changed afterwards.
+func (p *Policy) compiledRules() ([]compiledRule, error) {
+	p.compileOnce.Do(func() {
+		p.compiled, p.compileErr = compileRules(p.Rules)
+	})
+	return p.compiled, p.compileErr
+}
+
+// globPattern compiles a glob where * matches any sequence of characters,
+// including slashes, into an anchored regular expression.
+func globPattern(glob string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(glob)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$")
+}
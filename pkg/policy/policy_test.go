@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v1types "github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+var errTest = errors.New("test error")
+
+const testPolicy = `
+min_score: 5
+max_typosquatting: 7
+block_deprecated: true
+ecosystems:
+  npm:
+    min_score: 6
+require_verified_provenance:
+  - "pkg:npm/@acme/*"
+allow:
+  - package: left-pad
+    reason: Vendored copy under review
+    expires: 2024-01-31
+  - package: "lodash*"
+    ecosystem: npm
+deny:
+  - package: pkg:npm/event-stream@3.3.6
+    reason: Compromised release
+  - package: express
+    ecosystem: npm
+    versions: "<4.17.3"
+  - package: pkg:npm/tar
+    versions: ">=6.0.0 <6.1.9"
+    reason: Path traversal
+`
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(strings.NewReader(testPolicy))
+	require.NoError(t, err)
+	require.Equal(t, 5.0, *p.MinScore)
+	require.Equal(t, 6.0, *p.Ecosystems["npm"].MinScore)
+	require.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), p.Allow[0].Expires.Time)
+
+	p, err = Load(strings.NewReader(`{"min_score": 4, "deny": [{"package": "foo", "expires": "2030-01-01"}]}`))
+	require.NoError(t, err)
+	require.Equal(t, 4.0, *p.MinScore)
+	require.Equal(t, 2030, p.Deny[0].Expires.Year())
+
+	for _, invalid := range []string{
+		"min_scor: 5",
+		"min_score: 11",
+		"ecosystems: {npm: {max_typosquatting: -1}}",
+		"allow: [{reason: missing package}]",
+		"deny: [{package: foo, expires: tomorrow}]",
+		"deny: [{package: foo, ecosystem: maven, versions: '[1.0'}]",
+	} {
+		_, err := Load(strings.NewReader(invalid))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(strings.NewReader(testPolicy))
+	require.NoError(t, err)
+
+	score := func(v float64) *v2types.PackageSummaryAnnotation {
+		return &v2types.PackageSummaryAnnotation{Score: &v}
+	}
+	yes := true
+	historical := v2types.ProvenanceTypeHistorical
+	pkg := func(name, version string, summary *v2types.PackageSummaryAnnotation) result.Package {
+		return result.Package{
+			Dependency: parser.Dependency{Name: name, Ecosystem: "npm", Version: version},
+			Summary:    summary,
+		}
+	}
+
+	acme := pkg("@acme/utils", "1.0.0", score(9))
+	acme.Summary.Description.ProvenanceType = &historical
+	deprecated := pkg("request", "2.88.2", score(8))
+	deprecated.Metadata = &v2types.TrustyPackageData{IsDeprecated: &yes}
+	typo := pkg("expresss", "1.0.0", score(9))
+	typo.Summary.Description.TypoSquatting = 8
+
+	packages := []result.Package{
+		pkg("express", "4.18.2", score(9)),
+		pkg("tiny", "1.0.0", score(5.5)),
+		pkg("lodash.merge", "4.6.0", score(1)),
+		pkg("left-pad", "1.3.0", score(2)),
+		pkg("event-stream", "3.3.6", score(9)),
+		pkg("tar", "6.1.0", score(9)),
+		acme,
+		deprecated,
+		typo,
+		{Dependency: parser.Dependency{Name: "failed", Ecosystem: "npm"}, Err: errTest},
+	}
+
+	var got []string
+	for _, v := range p.EvaluateAt(packages, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		got = append(got, string(v.Rule)+": "+v.Message)
+	}
+	require.Equal(t, []string{
+		"trusty/low-score: tiny@1.0.0 has a Trusty score of 5.5, below the minimum of 6.0 set by the npm ecosystem policy",
+		"trusty/low-score: left-pad@1.3.0 has a Trusty score of 2.0, below the minimum of 6.0 set by the npm ecosystem policy" +
+			" (the allow entry \"left-pad\" expired on 2024-01-31)",
+		`policy/denied: event-stream@3.3.6 is denied by the policy entry "pkg:npm/event-stream@3.3.6": Compromised release`,
+		`policy/denied: tar@6.1.0 is denied by the policy entry "pkg:npm/tar": Path traversal`,
+		"policy/unverified-provenance: @acme/utils@1.0.0 has historical_provenance_match provenance, " +
+			`but require_verified_provenance matches it with "pkg:npm/@acme/*"`,
+		"trusty/deprecated: request@2.88.2 is deprecated, and block_deprecated is set",
+		"trusty/typosquatting: expresss@1.0.0 has a typosquatting risk of 8.0, above the maximum of 7.0 set by the policy",
+	}, got)
+}
+
+func TestEvaluateAPITypes(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load(strings.NewReader("min_score: 5"))
+	require.NoError(t, err)
+
+	low := 3.0
+	violations := p.EvaluateReply(&v1types.Reply{
+		PackageName: "evil", PackageType: "pypi", PackageVersion: "1.0",
+		Summary:     v1types.ScoreSummary{Score: &low},
+		PackageData: v1types.PackageData{Malicious: &v1types.MaliciousData{Summary: "Steals keys"}},
+	})
+	require.Len(t, violations, 2)
+	require.Equal(t, "evil@1.0 is reported as malicious: Steals keys", violations[0].Message)
+	require.Equal(t, result.RuleLowScore, violations[1].Rule)
+
+	version := "2.0.0"
+	violations = p.EvaluateV2(
+		&v2types.Dependency{PackageName: "ok", PackageType: "npm", PackageVersion: &version},
+		&v2types.PackageSummaryAnnotation{Score: &low},
+		nil,
+	)
+	require.Len(t, violations, 1)
+	require.Equal(t, "ok@2.0.0", violations[0].Package.Dependency.ID())
+}
@@ -0,0 +1,145 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlTemplate is the HTML report. It has no external resources, so that
+// it can be archived as a build artifact and opened offline.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.6em; }
+table { border-collapse: collapse; margin: 1em 0 2em; width: 100%; }
+th, td { border: 1px solid #d0d7de; padding: 6px 12px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: 0.9em; }
+.severity { font-weight: 600; text-transform: uppercase; font-size: 0.8em; }
+.error { color: #cf222e; }
+.warning { color: #9a6700; }
+.note { color: #0969da; }
+.meta { color: #656d76; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Summary}}.</p>
+{{- if or .Version .GeneratedAt}}
+<p class="meta">Generated{{with .Version}} by trusty {{.}}{{end}}{{with .GeneratedAt}} on {{.}}{{end}}</p>
+{{- end}}
+{{- if .Findings}}
+<h2>Findings</h2>
+<table>
+<thead><tr><th>Severity</th><th>Rule</th><th>Package</th><th>Location</th><th>Message</th></tr></thead>
+<tbody>
+{{- range .Findings}}
+<tr><td class="severity {{.Severity}}">{{.Severity}}</td><td><code>{{.Rule}}</code></td><td><code>{{.Package}}</code></td><td>{{with .Location}}<code>{{.}}</code>{{end}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+{{- if .Packages}}
+<h2>Packages</h2>
+<table>
+<thead><tr><th>Package</th><th>Ecosystem</th><th>Score</th><th>Malicious</th><th>Deprecated</th><th>Archived</th><th>Location</th></tr></thead>
+<tbody>
+{{- range .Packages}}
+<tr><td><code>{{.Package}}</code></td><td>{{.Ecosystem}}</td>
+{{- if .Error}}<td colspan="4" class="error">{{.Error}}</td>
+{{- else}}<td>{{.Score}}</td><td{{if .Malicious}} class="error"{{end}}>{{if .Malicious}}yes{{else}}no{{end}}</td><td>{{if .Deprecated}}yes{{else}}no{{end}}</td><td>{{if .Archived}}yes{{else}}no{{end}}</td>
+{{- end}}<td>{{with .Location}}<code>{{.}}</code>{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlReport struct {
+	Title       string
+	Summary     string
+	Version     string
+	GeneratedAt string
+	Findings    []htmlFinding
+	Packages    []htmlPackage
+}
+
+type htmlFinding struct {
+	Severity string
+	Rule     string
+	Package  string
+	Location string
+	Message  string
+}
+
+type htmlPackage struct {
+	Package    string
+	Ecosystem  string
+	Score      string
+	Malicious  bool
+	Deprecated bool
+	Archived   bool
+	Location   string
+	Error      string
+}
+
+// WriteHTML writes the report as a self-contained HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	data := htmlReport{
+		Title:   r.title(),
+		Summary: r.Counts().String(),
+		Version: r.ToolVersion,
+	}
+	if !r.GeneratedAt.IsZero() {
+		data.GeneratedAt = r.GeneratedAt.UTC().Format("2006-01-02 15:04 MST")
+	}
+	for _, f := range r.sortedFindings() {
+		data.Findings = append(data.Findings, htmlFinding{
+			Severity: string(f.Severity),
+			Rule:     string(f.Rule),
+			Package:  f.Package.Dependency.ID(),
+			Location: Location(&f.Package.Dependency),
+			Message:  f.Message,
+		})
+	}
+	for i := range r.Packages {
+		p := &r.Packages[i]
+		hp := htmlPackage{
+			Package:   p.Dependency.ID(),
+			Ecosystem: p.Dependency.Ecosystem,
+			Location:  Location(&p.Dependency),
+		}
+		if p.Err != nil {
+			hp.Error = p.Err.Error()
+		} else {
+			hp.Score = FormatScore(p.Score())
+			hp.Malicious = p.IsMalicious()
+			hp.Deprecated = p.Deprecated()
+			hp.Archived = p.Archived()
+		}
+		data.Packages = append(data.Packages, hp)
+	}
+	return htmlTemplate.Execute(w, data)
+}
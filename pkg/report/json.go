@@ -0,0 +1,157 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// SchemaVersion is the version of the JSON document. It changes on any
+// incompatible change to Document: removing or renaming a field, or
+// changing its meaning. Fields may be added without changing it.
+const SchemaVersion = "1"
+
+// Document is the JSON representation of a report.
+type Document struct {
+	SchemaVersion string     `json:"schema_version"`
+	Tool          Tool       `json:"tool"`
+	GeneratedAt   *time.Time `json:"generated_at,omitempty"`
+	Summary       Summary    `json:"summary"`
+	Packages      []Package  `json:"packages"`
+	Findings      []Finding  `json:"findings"`
+}
+
+// Tool is the tool that generated a report.
+type Tool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Summary are the totals of a report.
+type Summary struct {
+	Packages int `json:"packages"`
+	// Errors is the number of packages that could not be fetched.
+	Errors   int            `json:"errors"`
+	Findings int            `json:"findings"`
+	Severity map[string]int `json:"severity"`
+}
+
+// Package is a package checked.
+type Package struct {
+	Purl       string `json:"purl"`
+	Name       string `json:"name"`
+	Ecosystem  string `json:"ecosystem"`
+	Version    string `json:"version,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	Scope      string `json:"scope,omitempty"`
+	Direct     bool   `json:"direct"`
+	Path       string `json:"path,omitempty"`
+	Line       int    `json:"line,omitempty"`
+
+	Score         *float64 `json:"score,omitempty"`
+	Typosquatting *float64 `json:"typosquatting,omitempty"`
+	Provenance    string   `json:"provenance,omitempty"`
+	Malicious     bool     `json:"malicious"`
+	Deprecated    bool     `json:"deprecated"`
+	Archived      bool     `json:"archived"`
+
+	// Error is set when the data of the package could not be fetched.
+	Error string `json:"error,omitempty"`
+}
+
+// Finding is a finding raised on a package.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Purl     string `json:"purl"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+const toolName = "trusty"
+
+// Document returns the JSON representation of the report.
+func (r *Report) Document() *Document {
+	c := r.Counts()
+	doc := &Document{
+		SchemaVersion: SchemaVersion,
+		Tool:          Tool{Name: toolName, Version: r.ToolVersion},
+		Summary: Summary{
+			Packages: c.Packages,
+			Errors:   c.Errors,
+			Findings: c.Findings,
+			Severity: map[string]int{},
+		},
+		Packages: make([]Package, len(r.Packages)),
+		Findings: make([]Finding, len(r.Findings)),
+	}
+	if !r.GeneratedAt.IsZero() {
+		t := r.GeneratedAt.UTC()
+		doc.GeneratedAt = &t
+	}
+	for s, n := range c.BySeverity {
+		doc.Summary.Severity[string(s)] = n
+	}
+
+	for i := range r.Packages {
+		p := &r.Packages[i]
+		dep := &p.Dependency
+		doc.Packages[i] = Package{
+			Purl:       dep.Purl(),
+			Name:       dep.Name,
+			Ecosystem:  dep.Ecosystem,
+			Version:    dep.Version,
+			Constraint: dep.Constraint,
+			Scope:      string(dep.Scope),
+			Direct:     dep.Direct,
+			Path:       dep.Location.Path,
+			Line:       dep.Location.Line,
+		}
+		if p.Err != nil {
+			doc.Packages[i].Error = p.Err.Error()
+			continue
+		}
+		doc.Packages[i].Score = p.Score()
+		doc.Packages[i].Typosquatting = p.Typosquatting()
+		doc.Packages[i].Provenance = string(p.ProvenanceType())
+		doc.Packages[i].Malicious = p.IsMalicious()
+		doc.Packages[i].Deprecated = p.Deprecated()
+		doc.Packages[i].Archived = p.Archived()
+	}
+
+	for i, f := range r.Findings {
+		dep := &f.Package.Dependency
+		doc.Findings[i] = Finding{
+			Rule:     string(f.Rule),
+			Severity: string(f.Severity),
+			Purl:     dep.Purl(),
+			Path:     dep.Location.Path,
+			Line:     dep.Location.Line,
+			Message:  f.Message,
+		}
+	}
+	return doc
+}
+
+// WriteJSON writes the document of the report to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Document())
+}
@@ -0,0 +1,101 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+var severityEmoji = map[result.Severity]string{
+	result.SeverityError:   ":x:",
+	result.SeverityWarning: ":warning:",
+	result.SeverityNote:    ":information_source:",
+}
+
+// WriteMarkdown writes the report as GitHub-flavoured Markdown: a heading,
+// a summary, the table of findings and, folded, the table of all the
+// packages checked.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "## %s\n\n", markdownEscape(r.title()))
+
+	c := r.Counts()
+	if c.Findings == 0 {
+		bw.WriteString(":white_check_mark: ")
+	}
+	bw.WriteString(c.String() + ".\n")
+
+	if len(r.Findings) > 0 {
+		bw.WriteString("\n| | Rule | Package | Location | Message |\n|---|---|---|---|---|\n")
+		for _, f := range r.sortedFindings() {
+			dep := &f.Package.Dependency
+			fmt.Fprintf(bw, "| %s | `%s` | %s | %s | %s |\n",
+				severityEmoji[f.Severity], f.Rule, markdownCode(dep.ID()),
+				markdownCode(Location(dep)), markdownEscape(f.Message))
+		}
+	}
+
+	if len(r.Packages) > 0 {
+		fmt.Fprintf(bw, "\n<details>\n<summary>%s checked</summary>\n\n", plural(len(r.Packages), "package"))
+		bw.WriteString("| Package | Ecosystem | Score | Malicious | Location |\n|---|---|---|---|---|\n")
+		for i := range r.Packages {
+			p := &r.Packages[i]
+			score := FormatScore(p.Score())
+			malicious := "no"
+			switch {
+			case p.Err != nil:
+				score, malicious = "error", "-"
+			case p.IsMalicious():
+				malicious = "**yes**"
+			}
+			fmt.Fprintf(bw, "| %s | %s | %s | %s | %s |\n",
+				markdownCode(p.Dependency.ID()), markdownEscape(p.Dependency.Ecosystem),
+				score, malicious, markdownCode(Location(&p.Dependency)))
+		}
+		bw.WriteString("\n</details>\n")
+	}
+	return bw.Flush()
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", ">", "&gt;",
+	"[", `\[`, "]", `\]`, "\n", " ",
+)
+
+// markdownEscape escapes s so that it renders as plain text in a table
+// cell.
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+// markdownCode formats s as inline code, or returns an empty cell when s
+// is empty.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	// Pipes end table cells even within code spans
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
@@ -0,0 +1,218 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report renders the packages checked and the findings raised on
+// them for people and machines: as a versioned JSON document, an aligned
+// terminal table, GitHub-flavoured Markdown and a self-contained HTML
+// page. All the formats are generated from the same Report.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+// Format is an output format of reports.
+type Format string
+
+const (
+	// FormatJSON is the JSON document described by Document.
+	FormatJSON Format = "json"
+	// FormatTable is an aligned table for terminals.
+	FormatTable Format = "table"
+	// FormatMarkdown is GitHub-flavoured Markdown, eg for PR comments.
+	FormatMarkdown Format = "markdown"
+	// FormatHTML is a self-contained HTML page.
+	FormatHTML Format = "html"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatTable, FormatJSON, FormatMarkdown, FormatHTML}
+
+// ParseFormat returns the format with the given name. "md" is accepted for
+// Markdown and "text" for tables.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "table", "text":
+		return FormatTable, nil
+	case "json":
+		return FormatJSON, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "html":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q", name)
+	}
+}
+
+// Report is the result of checking a set of packages.
+type Report struct {
+	// Title is the heading of the Markdown and HTML reports. When empty,
+	// DefaultTitle is used.
+	Title string
+
+	// ToolVersion is the version of the tool that generated the report.
+	ToolVersion string
+
+	// GeneratedAt is when the report was generated. It is omitted when
+	// zero, so that the output is reproducible.
+	GeneratedAt time.Time
+
+	// Packages are the packages checked, including the ones that could
+	// not be fetched.
+	Packages []result.Package
+
+	// Findings are the findings raised on the packages, either by
+	// result.Findings or by a policy.
+	Findings []result.Finding
+}
+
+// DefaultTitle is the title of reports without one.
+const DefaultTitle = "Trusty report"
+
+// Options configures the rendering of reports.
+type Options struct {
+	// Color enables ANSI colours in tables. It has no effect on the
+	// other formats.
+	Color bool
+}
+
+// Write renders the report to w in the given format.
+func (r *Report) Write(w io.Writer, format Format, opts Options) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatTable:
+		return r.WriteTable(w, opts)
+	case FormatMarkdown:
+		return r.WriteMarkdown(w)
+	case FormatHTML:
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// Counts are the totals shown in the summary of reports.
+type Counts struct {
+	Packages int
+	Errors   int
+	Findings int
+
+	// BySeverity is the number of findings of each severity.
+	BySeverity map[result.Severity]int
+}
+
+// Counts returns the totals of the report.
+func (r *Report) Counts() Counts {
+	c := Counts{
+		Packages:   len(r.Packages),
+		Findings:   len(r.Findings),
+		BySeverity: map[result.Severity]int{},
+	}
+	for i := range r.Packages {
+		if r.Packages[i].Err != nil {
+			c.Errors++
+		}
+	}
+	for _, f := range r.Findings {
+		c.BySeverity[f.Severity]++
+	}
+	return c
+}
+
+// String describes the counts in a sentence, eg "3 packages checked, 1
+// finding (1 warning)".
+func (c Counts) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s checked, %s", plural(c.Packages, "package"), plural(c.Findings, "finding"))
+	var parts []string
+	for _, s := range severities {
+		if n := c.BySeverity[s]; n > 0 {
+			parts = append(parts, plural(n, string(s)))
+		}
+	}
+	if len(parts) > 0 {
+		fmt.Fprintf(&sb, " (%s)", strings.Join(parts, ", "))
+	}
+	if c.Errors > 0 {
+		fmt.Fprintf(&sb, ", %s could not be fetched", plural(c.Errors, "package"))
+	}
+	return sb.String()
+}
+
+func (r *Report) title() string {
+	if r.Title == "" {
+		return DefaultTitle
+	}
+	return r.Title
+}
+
+// severities are the severities from the most to the least serious.
+var severities = []result.Severity{result.SeverityError, result.SeverityWarning, result.SeverityNote}
+
+func severityRank(s result.Severity) int {
+	for i, v := range severities {
+		if v == s {
+			return i
+		}
+	}
+	return len(severities)
+}
+
+// sortedFindings returns the findings sorted by severity, keeping the
+// order of the report within a severity.
+func (r *Report) sortedFindings() []result.Finding {
+	res := append([]result.Finding(nil), r.Findings...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return severityRank(res[i].Severity) < severityRank(res[j].Severity)
+	})
+	return res
+}
+
+// Location formats where a dependency was declared as path:line. It
+// returns the empty string when the location is unknown.
+func Location(dep *parser.Dependency) string {
+	switch {
+	case dep.Location.Path == "":
+		return ""
+	case dep.Location.Line > 0:
+		return fmt.Sprintf("%s:%d", dep.Location.Path, dep.Location.Line)
+	default:
+		return dep.Location.Path
+	}
+}
+
+// FormatScore formats a score with one decimal, or "-" when unknown.
+func FormatScore(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", *score)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+func testReport() *Report {
+	low := 2.5
+	r := &Report{
+		ToolVersion: "1.2.3",
+		GeneratedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Packages: []result.Package{
+			{
+				Dependency: parser.Dependency{
+					Name: "leftpad", Ecosystem: "npm", Version: "1.0.0", Direct: true,
+					Location: parser.Location{Path: "package.json", Line: 4},
+				},
+				Summary: &v2types.PackageSummaryAnnotation{Score: &low},
+			},
+			{
+				Dependency: parser.Dependency{Name: "evil|pkg", Ecosystem: "npm", Version: "0.1.0"},
+				Summary:    &v2types.PackageSummaryAnnotation{Description: v2types.SummaryDescription{Malicious: true}},
+			},
+			{
+				Dependency: parser.Dependency{Name: "missing", Ecosystem: "npm"},
+				Err:        errors.New("not found"),
+			},
+		},
+	}
+	r.Findings = append(result.Findings(r.Packages, result.DefaultThresholds), result.Finding{
+		Rule: "custom/note", Severity: result.SeverityNote, Package: &r.Packages[0], Message: "Has <b>notes</b>",
+	})
+	return r
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range Formats {
+		got, err := ParseFormat(string(f))
+		require.NoError(t, err)
+		require.Equal(t, f, got)
+	}
+	got, err := ParseFormat("md")
+	require.NoError(t, err)
+	require.Equal(t, FormatMarkdown, got)
+	_, err = ParseFormat("xml")
+	require.Error(t, err)
+}
+
+func TestLocationAndScore(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "", Location(&parser.Dependency{}))
+	require.Equal(t, "go.mod", Location(&parser.Dependency{Location: parser.Location{Path: "go.mod"}}))
+	require.Equal(t, "go.mod:7", Location(&parser.Dependency{Location: parser.Location{Path: "go.mod", Line: 7}}))
+
+	score := 7.25
+	require.Equal(t, "-", FormatScore(nil))
+	require.Equal(t, "7.2", FormatScore(&score))
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, testReport().Write(&buf, FormatJSON, Options{}))
+
+	var doc Document
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, SchemaVersion, doc.SchemaVersion)
+	require.Equal(t, Tool{Name: "trusty", Version: "1.2.3"}, doc.Tool)
+	require.Equal(t, Summary{
+		Packages: 3, Errors: 1, Findings: 3,
+		Severity: map[string]int{"error": 1, "warning": 1, "note": 1},
+	}, doc.Summary)
+
+	require.Len(t, doc.Packages, 3)
+	require.Equal(t, "pkg:npm/leftpad@1.0.0", doc.Packages[0].Purl)
+	require.Equal(t, 2.5, *doc.Packages[0].Score)
+	require.Equal(t, 4, doc.Packages[0].Line)
+	require.True(t, doc.Packages[1].Malicious)
+	require.Equal(t, "not found", doc.Packages[2].Error)
+
+	require.Len(t, doc.Findings, 3)
+	require.Equal(t, "trusty/low-score", doc.Findings[0].Rule)
+	require.Equal(t, "package.json", doc.Findings[0].Path)
+	require.Equal(t, "trusty/malicious", doc.Findings[1].Rule)
+}
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, testReport().Write(&buf, FormatTable, Options{}))
+	require.Equal(t, `SEVERITY  RULE              LOCATION        MESSAGE
+error     trusty/malicious  -               evil|pkg@0.1.0 is malicious
+warning   trusty/low-score  package.json:4  leftpad@1.0.0 has a Trusty score of 2.5, below the minimum of 5.0
+note      custom/note       package.json:4  Has <b>notes</b>
+
+Packages that could not be checked:
+  missing: not found
+
+3 packages checked, 3 findings (1 error, 1 warning, 1 note), 1 package could not be fetched
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, testReport().Write(&buf, FormatTable, Options{Color: true}))
+	require.Contains(t, buf.String(), ansiRed+"error"+ansiReset+"     trusty/malicious")
+
+	buf.Reset()
+	require.NoError(t, (&Report{}).Write(&buf, FormatTable, Options{}))
+	require.Equal(t, "0 packages checked, 0 findings\n", buf.String())
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, testReport().Write(&buf, FormatMarkdown, Options{}))
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "## Trusty report\n\n3 packages checked"))
+	require.Contains(t, out, "| :x: | `trusty/malicious` | `evil\\|pkg@0.1.0` |  | evil\\|pkg@0.1.0 is malicious |\n")
+	require.Contains(t, out, "Has &lt;b&gt;notes&lt;/b&gt;")
+	require.Contains(t, out, "| `missing` | npm | error | - |  |\n")
+	require.Contains(t, out, "<summary>3 packages checked</summary>")
+}
+
+func TestWriteHTML(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, testReport().Write(&buf, FormatHTML, Options{}))
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	require.Contains(t, out, "<title>Trusty report</title>")
+	require.Contains(t, out, "Has &lt;b&gt;notes&lt;/b&gt;")
+	require.Contains(t, out, `<td class="severity error">error</td>`)
+	require.Contains(t, out, "Generated by trusty 1.2.3 on 2024-05-01 12:00 UTC")
+	require.NotContains(t, out, "<script")
+	require.NotContains(t, out, "http")
+}
@@ -0,0 +1,133 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+// ANSI escape sequences of the colours used in tables.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+var severityColor = map[result.Severity]string{
+	result.SeverityError:   ansiRed,
+	result.SeverityWarning: ansiYellow,
+	result.SeverityNote:    ansiCyan,
+}
+
+// WriteTable writes the findings of the report as an aligned table,
+// followed by the packages that could not be fetched and a summary line.
+func (r *Report) WriteTable(w io.Writer, opts Options) error {
+	bw := bufio.NewWriter(w)
+
+	if len(r.Findings) > 0 {
+		t := &table{color: opts.Color}
+		t.header("SEVERITY", "RULE", "LOCATION", "MESSAGE")
+		for _, f := range r.sortedFindings() {
+			loc := Location(&f.Package.Dependency)
+			if loc == "" {
+				loc = "-"
+			}
+			t.row(severityColor[f.Severity], string(f.Severity), string(f.Rule), loc, f.Message)
+		}
+		t.write(bw)
+		bw.WriteString("\n")
+	}
+
+	var failed bool
+	for i := range r.Packages {
+		p := &r.Packages[i]
+		if p.Err == nil {
+			continue
+		}
+		if !failed {
+			bw.WriteString(paint(opts.Color, ansiBold, "Packages that could not be checked:") + "\n")
+			failed = true
+		}
+		bw.WriteString("  " + p.Dependency.ID() + ": " + p.Err.Error() + "\n")
+	}
+	if failed {
+		bw.WriteString("\n")
+	}
+
+	bw.WriteString(r.Counts().String() + "\n")
+	return bw.Flush()
+}
+
+// table aligns columns of text. Unlike text/tabwriter, it ignores the
+// colour escape sequences when computing the width of the cells.
+type table struct {
+	color  bool
+	rows   [][]string
+	colors []string
+	widths []int
+}
+
+func (t *table) header(cells ...string) {
+	t.row(ansiBold, cells...)
+}
+
+// row adds a row. The first cell is painted with color.
+func (t *table) row(color string, cells ...string) {
+	for i, c := range cells {
+		if i >= len(t.widths) {
+			t.widths = append(t.widths, 0)
+		}
+		t.widths[i] = max(t.widths[i], utf8.RuneCountInString(c))
+	}
+	t.rows = append(t.rows, cells)
+	t.colors = append(t.colors, color)
+}
+
+func (t *table) write(w *bufio.Writer) {
+	for i, cells := range t.rows {
+		for j, c := range cells {
+			if j == len(cells)-1 {
+				// Do not pad the last column
+				if i == 0 {
+					c = paint(t.color, t.colors[i], c)
+				}
+				w.WriteString(c)
+				break
+			}
+			pad := strings.Repeat(" ", t.widths[j]-utf8.RuneCountInString(c)+2)
+			if j == 0 || i == 0 {
+				c = paint(t.color, t.colors[i], c)
+			}
+			w.WriteString(c + pad)
+		}
+		w.WriteString("\n")
+	}
+}
+
+// paint wraps s in the colour escape sequence when enabled.
+func paint(enabled bool, color, s string) string {
+	if !enabled || color == "" {
+		return s
+	}
+	return color + s + ansiReset
+}
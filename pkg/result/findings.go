@@ -0,0 +1,210 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package result
+
+import (
+	"fmt"
+
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+// RuleID identifies a check run on the packages.
+type RuleID string
+
+const (
+	// RuleMalicious flags packages reported as malicious.
+	RuleMalicious RuleID = "trusty/malicious"
+	// RuleLowScore flags packages with a Trusty score below a threshold.
+	RuleLowScore RuleID = "trusty/low-score"
+	// RuleDeprecated flags deprecated packages.
+	RuleDeprecated RuleID = "trusty/deprecated"
+	// RuleArchived flags packages whose repository is archived.
+	RuleArchived RuleID = "trusty/archived"
+	// RuleTyposquatting flags packages likely to be typosquatting another.
+	RuleTyposquatting RuleID = "trusty/typosquatting"
+	// RuleProvenanceMismatch flags packages whose provenance conflicts
+	// with their source repository.
+	RuleProvenanceMismatch RuleID = "trusty/provenance-mismatch"
+)
+
+// Severity is how serious a finding is.
+type Severity string
+
+const (
+	// SeverityError is a finding that must be acted on.
+	SeverityError Severity = "error"
+	// SeverityWarning is a finding that should be reviewed.
+	SeverityWarning Severity = "warning"
+	// SeverityNote is an informational finding.
+	SeverityNote Severity = "note"
+)
+
+// Rule describes a check run on the packages.
+type Rule struct {
+	ID          RuleID
+	Name        string
+	Description string
+	Help        string
+	Severity    Severity
+}
+
+// Rules lists the checks run by Findings.
+var Rules = []Rule{
+	{
+		ID:          RuleMalicious,
+		Name:        "MaliciousPackage",
+		Description: "The package has been reported as malicious.",
+		Help:        "Remove the package and rotate any credential exposed to the environments it was installed in.",
+		Severity:    SeverityError,
+	},
+	{
+		ID:          RuleLowScore,
+		Name:        "LowTrustyScore",
+		Description: "The package has a low Trusty score.",
+		Help:        "Review the package, and consider one of the alternatives suggested by Trusty.",
+		Severity:    SeverityWarning,
+	},
+	{
+		ID:          RuleDeprecated,
+		Name:        "DeprecatedPackage",
+		Description: "The package has been deprecated by its maintainers.",
+		Help:        "Replace the package with a maintained alternative.",
+		Severity:    SeverityWarning,
+	},
+	{
+		ID:          RuleArchived,
+		Name:        "ArchivedRepository",
+		Description: "The source repository of the package is archived.",
+		Help:        "The package no longer receives updates. Replace it with a maintained alternative.",
+		Severity:    SeverityWarning,
+	},
+	{
+		ID:          RuleTyposquatting,
+		Name:        "TyposquattingRisk",
+		Description: "The package name is similar to the name of a more popular package.",
+		Help:        "Check that the package is the one you intended to install.",
+		Severity:    SeverityWarning,
+	},
+	{
+		ID:          RuleProvenanceMismatch,
+		Name:        "ProvenanceMismatch",
+		Description: "The package provenance conflicts with its declared source repository.",
+		Help:        "Check that the package was published from the repository it claims.",
+		Severity:    SeverityError,
+	},
+}
+
+// LookupRule returns the rule with the given ID.
+func LookupRule(id RuleID) (Rule, bool) {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// DefaultMinScore is the score below which packages are flagged when no
+// other threshold is set.
+const DefaultMinScore = 5.0
+
+// Thresholds configures the checks run by Findings.
+type Thresholds struct {
+	// MinScore is the score below which a package is flagged. Packages
+	// without a score are not flagged.
+	MinScore float64
+
+	// MaxTyposquatting is the typosquatting risk above which a package is
+	// flagged. The check is disabled when zero.
+	MaxTyposquatting float64
+}
+
+// DefaultThresholds are the thresholds used when no others are set.
+var DefaultThresholds = Thresholds{MinScore: DefaultMinScore}
+
+// Finding is the result of a check flagging a package.
+type Finding struct {
+	Rule     RuleID
+	Severity Severity
+	Package  *Package
+	Message  string
+}
+
+// Typosquatting returns the typosquatting risk of the package, or nil if
+// unknown.
+func (p *Package) Typosquatting() *float64 {
+	switch {
+	case p.Summary != nil:
+		v := p.Summary.Description.TypoSquatting
+		return &v
+	case p.Report != nil && p.Report.Typosquatting != nil:
+		v := p.Report.Typosquatting.Score
+		return &v
+	default:
+		return nil
+	}
+}
+
+// Findings runs the checks of Rules on the package. Packages that could
+// not be fetched have no findings.
+func (p *Package) Findings(t Thresholds) []Finding {
+	if p.Err != nil {
+		return nil
+	}
+
+	id := p.Dependency.ID()
+	var res []Finding
+	add := func(rule RuleID, format string, args ...any) {
+		r, _ := LookupRule(rule)
+		res = append(res, Finding{
+			Rule: rule, Severity: r.Severity, Package: p, Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if mal := p.Malicious(); mal != nil {
+		if mal.Summary != "" {
+			add(RuleMalicious, "%s is malicious: %s", id, mal.Summary)
+		} else {
+			add(RuleMalicious, "%s is malicious", id)
+		}
+	}
+	if score := p.Score(); score != nil && *score < t.MinScore {
+		add(RuleLowScore, "%s has a Trusty score of %.1f, below the minimum of %.1f", id, *score, t.MinScore)
+	}
+	if p.Deprecated() {
+		add(RuleDeprecated, "%s is deprecated", id)
+	}
+	if p.Archived() {
+		add(RuleArchived, "The repository of %s is archived", id)
+	}
+	if risk := p.Typosquatting(); t.MaxTyposquatting > 0 && risk != nil && *risk > t.MaxTyposquatting {
+		add(RuleTyposquatting, "%s has a typosquatting risk of %.1f, above the maximum of %.1f",
+			id, *risk, t.MaxTyposquatting)
+	}
+	if p.ProvenanceType() == v2types.ProvenanceTypeMismatched {
+		add(RuleProvenanceMismatch, "The provenance of %s does not match its source repository", id)
+	}
+	return res
+}
+
+// Findings runs the checks of Rules on all the packages.
+func Findings(packages []Package, t Thresholds) []Finding {
+	var res []Finding
+	for i := range packages {
+		res = append(res, packages[i].Findings(t)...)
+	}
+	return res
+}
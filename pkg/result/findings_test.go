@@ -0,0 +1,58 @@
+package result
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	v1types "github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+func TestFindings(t *testing.T) {
+	t.Parallel()
+
+	low := 2.5
+	high := 9.0
+	yes := true
+	mismatched := v2types.ProvenanceTypeMismatched
+
+	packages := []Package{
+		{
+			Dependency: parser.Dependency{Name: "old", Version: "1.0.0"},
+			Summary: &v2types.PackageSummaryAnnotation{
+				Score:       &low,
+				Description: v2types.SummaryDescription{TypoSquatting: 8, ProvenanceType: &mismatched},
+			},
+			Metadata: &v2types.TrustyPackageData{IsDeprecated: &yes, Archived: &yes},
+		},
+		{
+			Dependency: parser.Dependency{Name: "evil", Version: "0.1.0"},
+			Report: &v1types.Reply{
+				Summary:     v1types.ScoreSummary{Score: &high},
+				PackageData: v1types.PackageData{Malicious: &v1types.MaliciousData{Summary: "Steals keys"}},
+			},
+		},
+		{
+			Dependency: parser.Dependency{Name: "failed"},
+			Err:        errNotFound,
+		},
+	}
+
+	var got []string
+	for _, f := range Findings(packages, Thresholds{MinScore: 5, MaxTyposquatting: 5}) {
+		got = append(got, string(f.Severity)+" "+f.Message)
+	}
+	require.Equal(t, []string{
+		"warning old@1.0.0 has a Trusty score of 2.5, below the minimum of 5.0",
+		"warning old@1.0.0 is deprecated",
+		"warning The repository of old@1.0.0 is archived",
+		"warning old@1.0.0 has a typosquatting risk of 8.0, above the maximum of 5.0",
+		"error The provenance of old@1.0.0 does not match its source repository",
+		"error evil@0.1.0 is malicious: Steals keys",
+	}, got)
+
+	// The typosquatting check is disabled by default
+	require.Len(t, packages[0].Findings(DefaultThresholds), 4)
+}
@@ -0,0 +1,239 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package result ties the dependencies found by the parsers to the data
+// Trusty has about them, and exposes the signals the other packages of
+// the SDK report on.
+package result
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	v1types "github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2client "github.com/stacklok/trusty-sdk-go/pkg/v2/client"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+// DefaultWorkers is the number of packages Fetch queries in parallel when
+// no other is set.
+const DefaultWorkers = 4
+
+// Package is a dependency together with the data Trusty returned about
+// it. Any of the Trusty fields can be nil when the data was not requested
+// or could not be fetched. Fetch fills the v2 fields, while callers using
+// the v1 API can set Report instead.
+type Package struct {
+	Dependency parser.Dependency
+
+	Summary    *v2types.PackageSummaryAnnotation
+	Metadata   *v2types.TrustyPackageData
+	Provenance *v2types.Provenance
+
+	// Report is the report returned by the v1 API.
+	Report *v1types.Reply
+
+	// Err is the error returned when fetching the data of the package.
+	Err error
+}
+
+// FromReply returns the package described by a report of the v1 API.
+func FromReply(reply *v1types.Reply) Package {
+	return Package{
+		Dependency: parser.Dependency{
+			Name:      reply.PackageName,
+			Ecosystem: strings.ToLower(reply.PackageType),
+			Version:   reply.PackageVersion,
+			Scope:     parser.ScopeRuntime,
+		},
+		Report: reply,
+	}
+}
+
+// FromV2 returns the package described by the data returned by the v2 API
+// for dep. Either of summary and metadata can be nil.
+func FromV2(
+	dep *v2types.Dependency, summary *v2types.PackageSummaryAnnotation, metadata *v2types.TrustyPackageData,
+) Package {
+	p := Package{
+		Dependency: parser.Dependency{
+			Name:      dep.PackageName,
+			Ecosystem: strings.ToLower(dep.PackageType),
+			Scope:     parser.ScopeRuntime,
+		},
+		Summary:  summary,
+		Metadata: metadata,
+	}
+	if dep.PackageVersion != nil {
+		p.Dependency.Version = *dep.PackageVersion
+	}
+	return p
+}
+
+// Score returns the Trusty score of the package, or nil if it has none.
+func (p *Package) Score() *float64 {
+	switch {
+	case p.Summary != nil:
+		return p.Summary.Score
+	case p.Report != nil:
+		return p.Report.Summary.Score
+	default:
+		return nil
+	}
+}
+
+// Malicious returns the details of the malicious package report, or nil
+// if the package is not known to be malicious. When only the summary
+// flags the package, the details are empty.
+func (p *Package) Malicious() *v2types.PackageMaliciousPayload {
+	switch {
+	case p.Metadata != nil && p.Metadata.Malicious != nil:
+		return p.Metadata.Malicious
+	case p.Report != nil && p.Report.PackageData.Malicious != nil:
+		return fromV1Malicious(p.Report.PackageData.Malicious)
+	case p.Summary != nil && p.Summary.Description.Malicious:
+		return &v2types.PackageMaliciousPayload{}
+	default:
+		return nil
+	}
+}
+
+func fromV1Malicious(m *v1types.MaliciousData) *v2types.PackageMaliciousPayload {
+	res := &v2types.PackageMaliciousPayload{
+		Summary:   m.Summary,
+		Published: m.Published,
+		Modified:  m.Modified,
+	}
+	if m.Details != "" {
+		res.Details = &m.Details
+	}
+	if m.Source != "" {
+		res.Source = &m.Source
+	}
+	return res
+}
+
+// IsMalicious returns true if the package is known to be malicious.
+func (p *Package) IsMalicious() bool {
+	return p.Malicious() != nil
+}
+
+// Deprecated returns true if the package is deprecated.
+func (p *Package) Deprecated() bool {
+	if p.Metadata != nil && p.Metadata.IsDeprecated != nil {
+		return *p.Metadata.IsDeprecated
+	}
+	return p.Report != nil && p.Report.PackageData.Deprecated
+}
+
+// Archived returns true if the repository of the package is archived.
+func (p *Package) Archived() bool {
+	if p.Metadata != nil && p.Metadata.Archived != nil {
+		return *p.Metadata.Archived
+	}
+	return p.Report != nil && p.Report.PackageData.Archived
+}
+
+// ProvenanceType returns the type of provenance Trusty found for the
+// package, or an empty string when unknown.
+func (p *Package) ProvenanceType() v2types.ProvenanceType {
+	if p.Summary == nil || p.Summary.Description.ProvenanceType == nil {
+		return ""
+	}
+	return *p.Summary.Description.ProvenanceType
+}
+
+// FetchOptions configures Fetch.
+type FetchOptions struct {
+	// Workers is the number of packages queried in parallel. When zero,
+	// DefaultWorkers is used.
+	Workers int
+
+	// Provenance enables fetching the provenance data of the packages,
+	// which takes an extra request per package.
+	Provenance bool
+}
+
+// Fetch queries Trusty for the summary and metadata of each dependency,
+// and returns them in the same order. Dependencies found several times,
+// with the same ecosystem, name and version, are only queried once. Errors
+// are recorded in the Err field of each package, so that one failure does
+// not prevent reporting on the others.
+func Fetch(ctx context.Context, client v2client.Trusty, deps []parser.Dependency, opts FetchOptions) []Package {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+
+	res := make([]Package, len(deps))
+	// first maps the key of each dependency to the index of its first
+	// occurrence, which is the one queried
+	first := map[[3]string]int{}
+	sem := make(chan struct{}, opts.Workers)
+	var wg sync.WaitGroup
+	for i := range deps {
+		res[i].Dependency = deps[i]
+		key := [3]string{deps[i].Ecosystem, deps[i].Name, deps[i].Version}
+		if _, ok := first[key]; ok {
+			continue
+		}
+		first[key] = i
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p *Package) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			p.Err = fetch(ctx, client, p, opts)
+		}(&res[i])
+	}
+	wg.Wait()
+
+	for i := range res {
+		src := &res[first[[3]string{deps[i].Ecosystem, deps[i].Name, deps[i].Version}]]
+		if src == &res[i] {
+			continue
+		}
+		res[i].Summary = src.Summary
+		res[i].Metadata = src.Metadata
+		res[i].Provenance = src.Provenance
+		res[i].Err = src.Err
+	}
+	return res
+}
+
+func fetch(ctx context.Context, client v2client.Trusty, p *Package, opts FetchOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dep := p.Dependency.ToV2()
+
+	var err error
+	if p.Summary, err = client.Summary(ctx, dep); err != nil {
+		return fmt.Errorf("fetching summary of %s: %w", p.Dependency.ID(), err)
+	}
+	if p.Metadata, err = client.PackageMetadata(ctx, dep); err != nil {
+		return fmt.Errorf("fetching metadata of %s: %w", p.Dependency.ID(), err)
+	}
+	if opts.Provenance {
+		if p.Provenance, err = client.Provenance(ctx, dep); err != nil {
+			return fmt.Errorf("fetching provenance of %s: %w", p.Dependency.ID(), err)
+		}
+	}
+	return nil
+}
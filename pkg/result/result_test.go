@@ -0,0 +1,105 @@
+package result
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+type fakeTrusty struct {
+	summaries map[string]*v2types.PackageSummaryAnnotation
+	metadata  map[string]*v2types.TrustyPackageData
+
+	summaryCalls atomic.Int32
+}
+
+func (f *fakeTrusty) Summary(_ context.Context, dep *v2types.Dependency) (*v2types.PackageSummaryAnnotation, error) {
+	f.summaryCalls.Add(1)
+	s, ok := f.summaries[dep.PackageName]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return s, nil
+}
+
+func (f *fakeTrusty) PackageMetadata(_ context.Context, dep *v2types.Dependency) (*v2types.TrustyPackageData, error) {
+	return f.metadata[dep.PackageName], nil
+}
+
+func (*fakeTrusty) Alternatives(context.Context, *v2types.Dependency) (*v2types.PackageAlternatives, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (*fakeTrusty) Provenance(context.Context, *v2types.Dependency) (*v2types.Provenance, error) {
+	return &v2types.Provenance{}, nil
+}
+
+var errNotFound = errors.New("not found")
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+
+	score := 7.5
+	verified := v2types.ProvenanceTypeVerified
+	yes := true
+	client := &fakeTrusty{
+		summaries: map[string]*v2types.PackageSummaryAnnotation{
+			"good": {Score: &score, Description: v2types.SummaryDescription{ProvenanceType: &verified}},
+			"bad":  {Description: v2types.SummaryDescription{Malicious: true}},
+		},
+		metadata: map[string]*v2types.TrustyPackageData{
+			"good": {IsDeprecated: &yes},
+		},
+	}
+
+	res := Fetch(context.Background(), client, []parser.Dependency{
+		{Name: "good", Ecosystem: "npm"},
+		{Name: "bad", Ecosystem: "npm"},
+		{Name: "missing", Ecosystem: "npm"},
+	}, FetchOptions{Workers: 2, Provenance: true})
+	require.Len(t, res, 3)
+
+	require.NoError(t, res[0].Err)
+	require.Equal(t, "good", res[0].Dependency.Name)
+	require.Equal(t, &score, res[0].Score())
+	require.Equal(t, v2types.ProvenanceTypeVerified, res[0].ProvenanceType())
+	require.True(t, res[0].Deprecated())
+	require.False(t, res[0].Archived())
+	require.False(t, res[0].IsMalicious())
+	require.NotNil(t, res[0].Provenance)
+
+	require.NoError(t, res[1].Err)
+	require.Nil(t, res[1].Score())
+	require.True(t, res[1].IsMalicious())
+	require.Equal(t, v2types.ProvenanceType(""), res[1].ProvenanceType())
+
+	require.Error(t, res[2].Err)
+	require.Equal(t, "missing", res[2].Dependency.Name)
+}
+
+func TestFetchDuplicates(t *testing.T) {
+	t.Parallel()
+
+	score := 7.5
+	client := &fakeTrusty{summaries: map[string]*v2types.PackageSummaryAnnotation{"good": {Score: &score}}}
+
+	res := Fetch(context.Background(), client, []parser.Dependency{
+		{Name: "good", Ecosystem: "npm", Version: "1.0.0", Location: parser.Location{Path: "a/package.json"}},
+		{Name: "good", Ecosystem: "npm", Version: "1.0.0", Location: parser.Location{Path: "b/package.json"}},
+		{Name: "good", Ecosystem: "npm", Version: "2.0.0"},
+		{Name: "missing", Ecosystem: "npm"},
+		{Name: "missing", Ecosystem: "npm"},
+	}, FetchOptions{})
+	require.Len(t, res, 5)
+	require.Equal(t, int32(3), client.summaryCalls.Load())
+
+	require.Equal(t, "b/package.json", res[1].Dependency.Location.Path)
+	require.Equal(t, &score, res[1].Score())
+	require.Error(t, res[4].Err)
+}
@@ -0,0 +1,253 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sarif renders findings as SARIF 2.1.0 logs, the format read by
+// GitHub code scanning and other static analysis viewers.
+package sarif
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+const (
+	// Version is the version of the SARIF specification of the logs.
+	Version = "2.1.0"
+
+	// Schema is the JSON schema of the logs.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName = "Trusty"
+	toolURI  = "https://www.trustypkg.dev"
+
+	// fingerprintKey is the partial fingerprint identifying a finding
+	// across runs, regardless of the line it is reported at.
+	fingerprintKey = "trustyFinding/v1"
+)
+
+// Log is a SARIF log.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the output of a single run of a tool.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the tool that produced a run.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver describes the component of the tool that ran the checks.
+type Driver struct {
+	Name           string                `json:"name"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Version        string                `json:"version,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules"`
+}
+
+// ReportingDescriptor describes a rule.
+type ReportingDescriptor struct {
+	ID                   string           `json:"id"`
+	Name                 string           `json:"name,omitempty"`
+	ShortDescription     *Message         `json:"shortDescription,omitempty"`
+	Help                 *Message         `json:"help,omitempty"`
+	DefaultConfiguration *ReportingConfig `json:"defaultConfiguration,omitempty"`
+	Properties           *RuleProperties  `json:"properties,omitempty"`
+}
+
+// ReportingConfig is the default configuration of a rule.
+type ReportingConfig struct {
+	Level string `json:"level"`
+}
+
+// RuleProperties are the properties of a rule read by GitHub code
+// scanning.
+type RuleProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+}
+
+// Message is a text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a finding reported by a rule.
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             Message           `json:"message"`
+	Locations           []Location        `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+// Location is where a result was found.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a location in a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is the file of a location.
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is the position of a location in its file.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// Options configures the logs generated.
+type Options struct {
+	// ToolVersion is the version of the tool reported in the log.
+	ToolVersion string
+
+	// ArtifactURI is the file the findings on dependencies without a known
+	// path are located at, eg the SBOM or the manifest checked. GitHub
+	// code scanning rejects the results without a location, so it should
+	// be set when the findings are not all located.
+	ArtifactURI string
+
+	// Rules describes the rules of the findings that are not checks of
+	// Trusty, eg the ones returned by policy.Policy.RuleDescriptions.
+	// Findings of rules described nowhere get a descriptor with the ID and
+	// severity of the first of them.
+	Rules []result.Rule
+}
+
+// securitySeverity maps the severities to the CVSS-like scores GitHub uses
+// to rank security alerts.
+var securitySeverity = map[result.Severity]string{
+	result.SeverityError:   "9.0",
+	result.SeverityWarning: "5.0",
+	result.SeverityNote:    "2.0",
+}
+
+// FromFindings returns a SARIF log with a result for each finding,
+// located at the manifest and line the dependency was declared at.
+// Findings on dependencies without a known path are located at the whole
+// file opts.ArtifactURI, and have no location when it is empty. The
+// driver describes the checks of Trusty, and the other rules of the
+// findings after them.
+func FromFindings(findings []result.Finding, opts Options) *Log {
+	driver := Driver{
+		Name:           toolName,
+		InformationURI: toolURI,
+		Version:        opts.ToolVersion,
+	}
+	ruleIndex := map[result.RuleID]int{}
+	addRule := func(r result.Rule) {
+		ruleIndex[r.ID] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, descriptor(r))
+	}
+	for _, r := range result.Rules {
+		addRule(r)
+	}
+	for _, f := range findings {
+		if _, ok := ruleIndex[f.Rule]; ok {
+			continue
+		}
+		r := result.Rule{ID: f.Rule, Severity: f.Severity}
+		for _, known := range opts.Rules {
+			if known.ID == f.Rule {
+				r = known
+				break
+			}
+		}
+		addRule(r)
+	}
+
+	run := Run{Tool: Tool{Driver: driver}, Results: []Result{}}
+	for _, f := range findings {
+		dep := &f.Package.Dependency
+		res := Result{
+			RuleID:    string(f.Rule),
+			RuleIndex: ruleIndex[f.Rule],
+			Level:     string(f.Severity),
+			Message:   Message{Text: f.Message},
+			PartialFingerprints: map[string]string{
+				fingerprintKey: fingerprint(string(f.Rule), dep.Location.Path, dep.Ecosystem, dep.ID()),
+			},
+		}
+		switch {
+		case dep.Location.Path != "":
+			loc := PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: dep.Location.Path}}
+			if dep.Location.Line > 0 {
+				loc.Region = &Region{StartLine: dep.Location.Line, StartColumn: dep.Location.Column}
+			}
+			res.Locations = []Location{{PhysicalLocation: loc}}
+		case opts.ArtifactURI != "":
+			res.Locations = []Location{{PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: opts.ArtifactURI},
+			}}}
+		}
+		run.Results = append(run.Results, res)
+	}
+
+	return &Log{Schema: Schema, Version: Version, Runs: []Run{run}}
+}
+
+// descriptor returns the descriptor of a rule.
+func descriptor(r result.Rule) ReportingDescriptor {
+	d := ReportingDescriptor{
+		ID:                   string(r.ID),
+		Name:                 r.Name,
+		DefaultConfiguration: &ReportingConfig{Level: string(r.Severity)},
+		Properties: &RuleProperties{
+			Tags:             []string{"security", "supply-chain"},
+			SecuritySeverity: securitySeverity[r.Severity],
+		},
+	}
+	if r.Description != "" {
+		d.ShortDescription = &Message{Text: r.Description}
+	}
+	if r.Help != "" {
+		d.Help = &Message{Text: r.Help}
+	}
+	return d
+}
+
+// Write writes the log to w as indented JSON.
+func (l *Log) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+func fingerprint(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
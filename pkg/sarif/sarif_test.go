@@ -0,0 +1,102 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/policy"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+)
+
+func TestFromFindings(t *testing.T) {
+	t.Parallel()
+
+	pkg := &result.Package{Dependency: parser.Dependency{
+		Name: "evil", Ecosystem: "npm", Version: "1.0.0",
+		Location: parser.Location{Path: "web/package.json", Line: 12, Column: 5},
+	}}
+	noLocation := &result.Package{Dependency: parser.Dependency{Name: "old", Ecosystem: "npm"}}
+
+	log := FromFindings([]result.Finding{
+		{Rule: result.RuleMalicious, Severity: result.SeverityError, Package: pkg, Message: "evil@1.0.0 is malicious"},
+		{Rule: result.RuleDeprecated, Severity: result.SeverityWarning, Package: noLocation, Message: "old is deprecated"},
+	}, Options{ToolVersion: "1.2.3"})
+
+	require.Equal(t, Version, log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	require.Equal(t, "1.2.3", run.Tool.Driver.Version)
+	require.Len(t, run.Tool.Driver.Rules, len(result.Rules))
+
+	require.Len(t, run.Results, 2)
+	require.Equal(t, "trusty/malicious", run.Results[0].RuleID)
+	require.Equal(t, "error", run.Results[0].Level)
+	require.Equal(t, []Location{{PhysicalLocation: PhysicalLocation{
+		ArtifactLocation: ArtifactLocation{URI: "web/package.json"},
+		Region:           &Region{StartLine: 12, StartColumn: 5},
+	}}}, run.Results[0].Locations)
+	require.Equal(t, string(run.Tool.Driver.Rules[run.Results[1].RuleIndex].ID), run.Results[1].RuleID)
+	require.Empty(t, run.Results[1].Locations)
+	require.NotEqual(t, run.Results[0].PartialFingerprints, run.Results[1].PartialFingerprints)
+
+	located := FromFindings([]result.Finding{
+		{Rule: result.RuleMalicious, Severity: result.SeverityError, Package: pkg, Message: "evil@1.0.0 is malicious"},
+		{Rule: result.RuleDeprecated, Severity: result.SeverityWarning, Package: noLocation, Message: "old is deprecated"},
+	}, Options{ArtifactURI: "sbom.cdx.json"})
+	results := located.Runs[0].Results
+	require.Equal(t, "web/package.json", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, []Location{{PhysicalLocation: PhysicalLocation{
+		ArtifactLocation: ArtifactLocation{URI: "sbom.cdx.json"},
+	}}}, results[1].Locations)
+
+	var buf bytes.Buffer
+	require.NoError(t, log.Write(&buf))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, Schema, decoded["$schema"])
+}
+
+func TestFromFindingsPolicy(t *testing.T) {
+	t.Parallel()
+
+	p := &policy.Policy{Rules: []policy.Rule{{
+		ID: "no-archived", Expression: "pkg.archived", Severity: result.SeverityWarning, Message: "Archived packages are not allowed.",
+	}}}
+	pkg := &result.Package{Dependency: parser.Dependency{
+		Name: "evil", Ecosystem: "npm", Version: "1.0.0",
+		Location: parser.Location{Path: "package.json", Line: 3},
+	}}
+	log := FromFindings([]result.Finding{
+		{Rule: policy.RuleDenied, Severity: result.SeverityError, Package: pkg, Message: "evil@1.0.0 is denied"},
+		{Rule: policy.RuleIDPrefix + "no-archived", Severity: result.SeverityWarning, Package: pkg, Message: "evil is archived"},
+		{Rule: "other/check", Severity: result.SeverityNote, Package: pkg, Message: "evil is odd"},
+		{Rule: result.RuleMalicious, Severity: result.SeverityError, Package: pkg, Message: "evil@1.0.0 is malicious"},
+		{Rule: policy.RuleDenied, Severity: result.SeverityError, Package: pkg, Message: "evil@1.0.0 is denied again"},
+	}, Options{Rules: p.RuleDescriptions()})
+
+	run := log.Runs[0]
+	rules := run.Tool.Driver.Rules
+	require.Len(t, rules, len(result.Rules)+3)
+	for _, res := range run.Results {
+		require.Equal(t, res.RuleID, rules[res.RuleIndex].ID)
+	}
+
+	denied := rules[run.Results[0].RuleIndex]
+	require.Equal(t, "DeniedPackage", denied.Name)
+	require.Equal(t, "error", denied.DefaultConfiguration.Level)
+	custom := rules[run.Results[1].RuleIndex]
+	require.Equal(t, "policy/rule/no-archived", custom.ID)
+	require.Equal(t, &Message{Text: "Archived packages are not allowed."}, custom.ShortDescription)
+	require.Equal(t, "warning", custom.DefaultConfiguration.Level)
+	other := rules[run.Results[2].RuleIndex]
+	require.Equal(t, ReportingDescriptor{
+		ID:                   "other/check",
+		DefaultConfiguration: &ReportingConfig{Level: "note"},
+		Properties:           &RuleProperties{Tags: []string{"security", "supply-chain"}, SecuritySeverity: "2.0"},
+	}, other)
+	require.Equal(t, 0, run.Results[3].RuleIndex)
+}
@@ -0,0 +1,208 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom annotates SBOMs with the data Trusty has about their
+// components, so they can be consumed by the tools already reading them.
+package sbom
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/cyclonedx"
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v2client "github.com/stacklok/trusty-sdk-go/pkg/v2/client"
+)
+
+// Names of the component properties set by AnnotateCycloneDX.
+const (
+	PropertyScore          = "trusty:score"
+	PropertyMalicious      = "trusty:malicious"
+	PropertyProvenanceType = "trusty:provenance_type"
+	PropertyDeprecated     = "trusty:deprecated"
+	PropertyArchived       = "trusty:archived"
+
+	// PropertyMaliciousSource is set on the vulnerabilities recording
+	// malicious packages when Trusty knows where the report came from.
+	PropertyMaliciousSource = "trusty:malicious_source"
+
+	propertyPrefix = "trusty:"
+)
+
+// MaliciousIDPrefix prefixes the ID of the vulnerabilities recording
+// malicious packages. It is followed by the name and version of the
+// package.
+const MaliciousIDPrefix = "TRUSTY-MALICIOUS-"
+
+// trustySource is the source of the vulnerabilities added to documents.
+var trustySource = &cyclonedx.Source{Name: "Trusty", URL: "https://www.trustypkg.dev"}
+
+// EnrichCycloneDX queries Trusty for the components of bom identified by
+// a package URL of a supported ecosystem, and annotates them with
+// AnnotateCycloneDX. The document is annotated with the data of the
+// components that could be fetched even when an error is returned.
+func EnrichCycloneDX(ctx context.Context, client v2client.Trusty, bom *cyclonedx.BOM, opts result.FetchOptions) error {
+	var purls []string
+	var deps []parser.Dependency
+	seen := map[string]bool{}
+	for _, c := range bom.AllComponents() {
+		if c.PURL == "" || seen[c.PURL] {
+			continue
+		}
+		seen[c.PURL] = true
+		dep, err := parser.DependencyFromPurl(c.PURL)
+		if err != nil {
+			continue
+		}
+		purls = append(purls, c.PURL)
+		deps = append(deps, dep)
+	}
+
+	packages := map[string]result.Package{}
+	var errs []error
+	for i, p := range result.Fetch(ctx, client, deps, opts) {
+		if p.Err != nil {
+			errs = append(errs, p.Err)
+			continue
+		}
+		packages[purls[i]] = p
+	}
+	AnnotateCycloneDX(bom, packages)
+	return errors.Join(errs...)
+}
+
+// AnnotateCycloneDX sets the Trusty properties of the components of bom
+// found in packages, which is keyed by package URL, and records the
+// malicious ones in the vulnerabilities of the document. Components
+// reported as malicious without a bom-ref get their package URL as one,
+// and the components sharing a package URL are all listed as affected by
+// its vulnerability. Properties and vulnerabilities set by a previous run
+// are replaced, so packages no longer reported as malicious, or no longer
+// in the document, have no vulnerability left. The rest of a decoded
+// document, including the fields the cyclonedx package does not model, is
+// written back unchanged.
+func AnnotateCycloneDX(bom *cyclonedx.BOM, packages map[string]result.Package) {
+	bom.Vulnerabilities = withoutTrustyVulnerabilities(bom.Vulnerabilities)
+	for _, c := range bom.AllComponents() {
+		p, ok := packages[c.PURL]
+		if c.PURL == "" || !ok {
+			continue
+		}
+		c.Properties = append(withoutTrustyProperties(c.Properties), componentProperties(&p)...)
+
+		if !p.IsMalicious() {
+			continue
+		}
+		if c.BOMRef == "" {
+			c.BOMRef = c.PURL
+		}
+		addVulnerability(bom, MaliciousVulnerability(&p, c.BOMRef))
+	}
+}
+
+func componentProperties(p *result.Package) []cyclonedx.Property {
+	var props []cyclonedx.Property
+	if score := p.Score(); score != nil {
+		props = append(props, cyclonedx.Property{
+			Name: PropertyScore, Value: strconv.FormatFloat(*score, 'f', -1, 64),
+		})
+	}
+	props = append(props, cyclonedx.Property{Name: PropertyMalicious, Value: strconv.FormatBool(p.IsMalicious())})
+	if pt := p.ProvenanceType(); pt != "" {
+		props = append(props, cyclonedx.Property{Name: PropertyProvenanceType, Value: string(pt)})
+	}
+	if p.Metadata != nil {
+		props = append(props,
+			cyclonedx.Property{Name: PropertyDeprecated, Value: strconv.FormatBool(p.Deprecated())},
+			cyclonedx.Property{Name: PropertyArchived, Value: strconv.FormatBool(p.Archived())},
+		)
+	}
+	return props
+}
+
+func withoutTrustyProperties(props []cyclonedx.Property) []cyclonedx.Property {
+	var res []cyclonedx.Property
+	for _, prop := range props {
+		if !strings.HasPrefix(prop.Name, propertyPrefix) {
+			res = append(res, prop)
+		}
+	}
+	return res
+}
+
+// MaliciousVulnerability returns the vulnerability recording that the package
+// is malicious, affecting the component with the bom-ref ref.
+func MaliciousVulnerability(p *result.Package, ref string) cyclonedx.Vulnerability {
+	mal := p.Malicious()
+	v := cyclonedx.Vulnerability{
+		ID:          MaliciousIDPrefix + p.Dependency.ID(),
+		Source:      trustySource,
+		Ratings:     []cyclonedx.Rating{{Severity: "critical", Method: "other"}},
+		Description: mal.Summary,
+		Affects:     []cyclonedx.Affects{{Ref: ref}},
+	}
+	if v.Description == "" {
+		v.Description = "Package reported as malicious"
+	}
+	if mal.Details != nil {
+		v.Detail = *mal.Details
+	}
+	if mal.Published != nil {
+		v.Published = mal.Published.UTC().Format(time.RFC3339)
+	}
+	if mal.Modified != nil {
+		v.Updated = mal.Modified.UTC().Format(time.RFC3339)
+	}
+	if mal.Source != nil {
+		v.Properties = []cyclonedx.Property{{Name: PropertyMaliciousSource, Value: *mal.Source}}
+	}
+	return v
+}
+
+// withoutTrustyVulnerabilities returns the vulnerabilities that were not
+// recorded by AnnotateCycloneDX.
+func withoutTrustyVulnerabilities(vulns []cyclonedx.Vulnerability) []cyclonedx.Vulnerability {
+	var res []cyclonedx.Vulnerability
+	for _, v := range vulns {
+		trusty := v.Source != nil && v.Source.Name == trustySource.Name
+		if !trusty || !strings.HasPrefix(v.ID, MaliciousIDPrefix) {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// addVulnerability adds v to the document. When a vulnerability with the
+// same ID is already there, the components affected by v are added to it.
+func addVulnerability(bom *cyclonedx.BOM, v cyclonedx.Vulnerability) {
+	for i := range bom.Vulnerabilities {
+		existing := &bom.Vulnerabilities[i]
+		if existing.ID != v.ID {
+			continue
+		}
+		for _, a := range v.Affects {
+			if !slices.ContainsFunc(existing.Affects, func(e cyclonedx.Affects) bool { return e.Ref == a.Ref }) {
+				existing.Affects = append(existing.Affects, a)
+			}
+		}
+		return
+	}
+	bom.Vulnerabilities = append(bom.Vulnerabilities, v)
+}
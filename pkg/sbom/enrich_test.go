@@ -0,0 +1,181 @@
+package sbom
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/cyclonedx"
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+func TestAnnotateCycloneDX(t *testing.T) {
+	t.Parallel()
+
+	score := 8.2
+	no := false
+	historical := v2types.ProvenanceTypeHistorical
+	published := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	source := "ossf-malicious-packages"
+
+	bom := &cyclonedx.BOM{
+		BOMFormat:   cyclonedx.BOMFormat,
+		SpecVersion: "1.5",
+		Components: []cyclonedx.Component{
+			{
+				Type: "library", BOMRef: "good", Name: "good", Version: "1.0.0", PURL: "pkg:npm/good@1.0.0",
+				Properties: []cyclonedx.Property{{Name: "foo", Value: "bar"}, {Name: PropertyScore, Value: "1"}},
+				Components: []cyclonedx.Component{
+					{Type: "library", Name: "evil", Version: "0.0.1", PURL: "pkg:npm/evil@0.0.1"},
+				},
+			},
+			{Type: "library", Name: "unknown", PURL: "pkg:npm/unknown@1.0.0"},
+		},
+	}
+
+	packages := map[string]result.Package{
+		"pkg:npm/good@1.0.0": {
+			Dependency: parser.Dependency{Name: "good", Version: "1.0.0"},
+			Summary: &v2types.PackageSummaryAnnotation{
+				Score:       &score,
+				Description: v2types.SummaryDescription{ProvenanceType: &historical},
+			},
+			Metadata: &v2types.TrustyPackageData{Archived: &no, IsDeprecated: &no},
+		},
+		"pkg:npm/evil@0.0.1": {
+			Dependency: parser.Dependency{Name: "evil", Version: "0.0.1"},
+			Summary:    &v2types.PackageSummaryAnnotation{},
+			Metadata: &v2types.TrustyPackageData{Malicious: &v2types.PackageMaliciousPayload{
+				Summary: "Steals credentials", Published: &published, Source: &source,
+			}},
+		},
+	}
+
+	// Annotating twice must not duplicate properties or vulnerabilities
+	AnnotateCycloneDX(bom, packages)
+	AnnotateCycloneDX(bom, packages)
+
+	require.Equal(t, []cyclonedx.Property{
+		{Name: "foo", Value: "bar"},
+		{Name: PropertyScore, Value: "8.2"},
+		{Name: PropertyMalicious, Value: "false"},
+		{Name: PropertyProvenanceType, Value: string(historical)},
+		{Name: PropertyDeprecated, Value: "false"},
+		{Name: PropertyArchived, Value: "false"},
+	}, bom.Components[0].Properties)
+
+	evil := bom.Components[0].Components[0]
+	require.Equal(t, "pkg:npm/evil@0.0.1", evil.BOMRef)
+	require.Equal(t, []cyclonedx.Property{
+		{Name: PropertyMalicious, Value: "true"},
+		{Name: PropertyDeprecated, Value: "false"},
+		{Name: PropertyArchived, Value: "false"},
+	}, evil.Properties)
+	require.Nil(t, bom.Components[1].Properties)
+
+	require.Equal(t, []cyclonedx.Vulnerability{{
+		ID:          MaliciousIDPrefix + "evil@0.0.1",
+		Source:      trustySource,
+		Ratings:     []cyclonedx.Rating{{Severity: "critical", Method: "other"}},
+		Description: "Steals credentials",
+		Published:   "2024-03-01T10:00:00Z",
+		Affects:     []cyclonedx.Affects{{Ref: "pkg:npm/evil@0.0.1"}},
+		Properties:  []cyclonedx.Property{{Name: PropertyMaliciousSource, Value: source}},
+	}}, bom.Vulnerabilities)
+}
+
+func TestAnnotateCycloneDXReplacesVulnerabilities(t *testing.T) {
+	t.Parallel()
+
+	other := &cyclonedx.Source{Name: "Scanner"}
+	bom := &cyclonedx.BOM{
+		BOMFormat:   cyclonedx.BOMFormat,
+		SpecVersion: "1.5",
+		Components: []cyclonedx.Component{
+			{Type: "library", BOMRef: "web/evil", Name: "evil", Version: "0.0.1", PURL: "pkg:npm/evil@0.0.1"},
+			{Type: "library", BOMRef: "api/evil", Name: "evil", Version: "0.0.1", PURL: "pkg:npm/evil@0.0.1"},
+			{Type: "library", BOMRef: "fixed", Name: "fixed", Version: "1.0.0", PURL: "pkg:npm/fixed@1.0.0"},
+		},
+		Vulnerabilities: []cyclonedx.Vulnerability{
+			{ID: MaliciousIDPrefix + "fixed@1.0.0", Source: trustySource, Affects: []cyclonedx.Affects{{Ref: "fixed"}}},
+			{ID: MaliciousIDPrefix + "removed@1.0.0", Source: trustySource, Affects: []cyclonedx.Affects{{Ref: "removed"}}},
+			{ID: "CVE-2024-0001", Source: other, Affects: []cyclonedx.Affects{{Ref: "fixed"}}},
+			{ID: MaliciousIDPrefix + "kept@1.0.0", Source: other},
+		},
+	}
+	packages := map[string]result.Package{
+		"pkg:npm/evil@0.0.1": {
+			Dependency: parser.Dependency{Name: "evil", Version: "0.0.1"},
+			Metadata:   &v2types.TrustyPackageData{Malicious: &v2types.PackageMaliciousPayload{Summary: "Steals credentials"}},
+		},
+		"pkg:npm/fixed@1.0.0": {
+			Dependency: parser.Dependency{Name: "fixed", Version: "1.0.0"},
+			Metadata:   &v2types.TrustyPackageData{},
+		},
+	}
+
+	AnnotateCycloneDX(bom, packages)
+	AnnotateCycloneDX(bom, packages)
+
+	var ids []string
+	for _, v := range bom.Vulnerabilities {
+		ids = append(ids, v.ID)
+	}
+	require.Equal(t, []string{"CVE-2024-0001", MaliciousIDPrefix + "kept@1.0.0", MaliciousIDPrefix + "evil@0.0.1"}, ids)
+	require.Equal(t, []cyclonedx.Affects{{Ref: "web/evil"}, {Ref: "api/evil"}}, bom.Vulnerabilities[2].Affects)
+}
+
+func TestAnnotateCycloneDXKeepsUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	supplier := `"supplier": {
+        "name": "Acme",
+        "url": [
+          "https://acme.example.com"
+        ]
+      }`
+	doc := `{
+  "$schema": "http://cyclonedx.org/schema/bom-1.5.schema.json",
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "components": [
+    {
+      "type": "library",
+      ` + supplier + `,
+      "name": "left-pad",
+      "copyright": "Copyright 2024 Acme",
+      "purl": "pkg:npm/left-pad@1.3.0"
+    }
+  ],
+  "compositions": [
+    {
+      "aggregate": "complete"
+    }
+  ]
+}
+`
+	bom, err := cyclonedx.Decode([]byte(doc))
+	require.NoError(t, err)
+
+	score := 3.1
+	AnnotateCycloneDX(bom, map[string]result.Package{
+		"pkg:npm/left-pad@1.3.0": {
+			Dependency: parser.Dependency{Name: "left-pad", Version: "1.3.0"},
+			Summary:    &v2types.PackageSummaryAnnotation{Score: &score},
+		},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, cyclonedx.Encode(&buf, bom, cyclonedx.FormatJSON))
+	out := buf.String()
+	require.Contains(t, out, `"$schema": "http://cyclonedx.org/schema/bom-1.5.schema.json",`)
+	require.Contains(t, out, supplier)
+	require.Contains(t, out, `"copyright": "Copyright 2024 Acme",`)
+	require.Contains(t, out, `"compositions": [`)
+	require.Contains(t, out, `"name": "trusty:score",
+          "value": "3.1"`)
+}
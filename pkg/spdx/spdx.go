@@ -0,0 +1,259 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spdx models the packages and relationships of SPDX 2.x documents
+// and reads them from their JSON and tag-value encodings. Files, snippets
+// and license information other than the package licenses are dropped.
+package spdx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DocumentID is the SPDX identifier of the document itself.
+	DocumentID = "SPDXRef-DOCUMENT"
+
+	// RefTypePurl is the type of the external references holding a
+	// package URL.
+	RefTypePurl = "purl"
+)
+
+// Relationship types used to describe dependencies between packages.
+const (
+	RelationshipDescribes            = "DESCRIBES"
+	RelationshipDescribedBy          = "DESCRIBED_BY"
+	RelationshipContains             = "CONTAINS"
+	RelationshipDependsOn            = "DEPENDS_ON"
+	RelationshipDependencyOf         = "DEPENDENCY_OF"
+	RelationshipRuntimeDependencyOf  = "RUNTIME_DEPENDENCY_OF"
+	RelationshipBuildDependencyOf    = "BUILD_DEPENDENCY_OF"
+	RelationshipDevDependencyOf      = "DEV_DEPENDENCY_OF"
+	RelationshipTestDependencyOf     = "TEST_DEPENDENCY_OF"
+	RelationshipOptionalDependencyOf = "OPTIONAL_DEPENDENCY_OF"
+)
+
+// Document is an SPDX document.
+type Document struct {
+	SPDXVersion       string         `json:"spdxVersion"`
+	DataLicense       string         `json:"dataLicense,omitempty"`
+	SPDXID            string         `json:"SPDXID"`
+	Name              string         `json:"name"`
+	DocumentNamespace string         `json:"documentNamespace,omitempty"`
+	DocumentDescribes []string       `json:"documentDescribes,omitempty"`
+	CreationInfo      *CreationInfo  `json:"creationInfo,omitempty"`
+	Packages          []Package      `json:"packages,omitempty"`
+	Relationships     []Relationship `json:"relationships,omitempty"`
+}
+
+// CreationInfo describes who created the document and when.
+type CreationInfo struct {
+	Created  string   `json:"created,omitempty"`
+	Creators []string `json:"creators,omitempty"`
+}
+
+// Package is a package described in the document.
+type Package struct {
+	SPDXID           string        `json:"SPDXID"`
+	Name             string        `json:"name"`
+	VersionInfo      string        `json:"versionInfo,omitempty"`
+	Supplier         string        `json:"supplier,omitempty"`
+	DownloadLocation string        `json:"downloadLocation,omitempty"`
+	LicenseConcluded string        `json:"licenseConcluded,omitempty"`
+	LicenseDeclared  string        `json:"licenseDeclared,omitempty"`
+	ExternalRefs     []ExternalRef `json:"externalRefs,omitempty"`
+}
+
+// ExternalRef points to a resource identifying or describing a package.
+type ExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// Relationship links two elements of the document, eg a package and one
+// of its dependencies.
+type Relationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// Purl returns the first package URL of the package, or an empty string
+// if it has none.
+func (p *Package) Purl() string {
+	for _, ref := range p.ExternalRefs {
+		if ref.ReferenceType == RefTypePurl {
+			return ref.ReferenceLocator
+		}
+	}
+	return ""
+}
+
+// Described returns the IDs of the elements the document describes, from
+// both its documentDescribes field and its DESCRIBES relationships.
+func (d *Document) Described() []string {
+	res := append([]string{}, d.DocumentDescribes...)
+	for _, r := range d.Relationships {
+		switch {
+		case r.RelationshipType == RelationshipDescribes && r.SPDXElementID == d.SPDXID:
+			res = append(res, r.RelatedSPDXElement)
+		case r.RelationshipType == RelationshipDescribedBy && r.RelatedSPDXElement == d.SPDXID:
+			res = append(res, r.SPDXElementID)
+		}
+	}
+	return res
+}
+
+// Decode reads an SPDX 2.x document from its JSON or tag-value encoding.
+func Decode(data []byte) (*Document, error) {
+	var doc *Document
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		doc = &Document{}
+		if err := json.Unmarshal(trimmed, doc); err != nil {
+			return nil, fmt.Errorf("decoding JSON document: %w", err)
+		}
+	} else {
+		var err error
+		if doc, err = decodeTagValue(trimmed); err != nil {
+			return nil, fmt.Errorf("decoding tag-value document: %w", err)
+		}
+	}
+
+	if !strings.HasPrefix(doc.SPDXVersion, "SPDX-2.") {
+		return nil, fmt.Errorf("unsupported SPDX version %q", doc.SPDXVersion)
+	}
+	return doc, nil
+}
+
+// decodeTagValue reads a document in the tag-value format. Tags apply to
+// the document until the first section starts, and then to the package,
+// file, snippet or license opened last.
+func decodeTagValue(data []byte) (*Document, error) {
+	doc := &Document{}
+	var pkg *Package
+	section := "document"
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, 1024*1024)
+	lineNum := 0
+	for s.Scan() {
+		lineNum++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tag, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing tag", lineNum)
+		}
+		value = strings.TrimSpace(value)
+
+		// Multi-line values are wrapped in <text> tags
+		if strings.HasPrefix(value, "<text>") {
+			text := strings.TrimPrefix(value, "<text>")
+			for !strings.Contains(text, "</text>") && s.Scan() {
+				lineNum++
+				text += "\n" + s.Text()
+			}
+			value, _, _ = strings.Cut(text, "</text>")
+		}
+
+		switch tag {
+		case "PackageName":
+			doc.Packages = append(doc.Packages, Package{Name: value})
+			pkg = &doc.Packages[len(doc.Packages)-1]
+			section = "package"
+		case "FileName", "SnippetSPDXID", "LicenseID":
+			section = "other"
+		case "Relationship":
+			fields := strings.Fields(value)
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: invalid relationship %q", lineNum, value)
+			}
+			doc.Relationships = append(doc.Relationships, Relationship{
+				SPDXElementID: fields[0], RelationshipType: fields[1], RelatedSPDXElement: fields[2],
+			})
+		default:
+			switch section {
+			case "document":
+				setDocumentTag(doc, tag, value)
+			case "package":
+				if err := setPackageTag(pkg, tag, value); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func setDocumentTag(doc *Document, tag, value string) {
+	switch tag {
+	case "SPDXVersion":
+		doc.SPDXVersion = value
+	case "DataLicense":
+		doc.DataLicense = value
+	case "SPDXID":
+		doc.SPDXID = value
+	case "DocumentName":
+		doc.Name = value
+	case "DocumentNamespace":
+		doc.DocumentNamespace = value
+	case "Created", "Creator":
+		if doc.CreationInfo == nil {
+			doc.CreationInfo = &CreationInfo{}
+		}
+		if tag == "Created" {
+			doc.CreationInfo.Created = value
+		} else {
+			doc.CreationInfo.Creators = append(doc.CreationInfo.Creators, value)
+		}
+	}
+}
+
+func setPackageTag(pkg *Package, tag, value string) error {
+	switch tag {
+	case "SPDXID":
+		pkg.SPDXID = value
+	case "PackageVersion":
+		pkg.VersionInfo = value
+	case "PackageSupplier":
+		pkg.Supplier = value
+	case "PackageDownloadLocation":
+		pkg.DownloadLocation = value
+	case "PackageLicenseConcluded":
+		pkg.LicenseConcluded = value
+	case "PackageLicenseDeclared":
+		pkg.LicenseDeclared = value
+	case "ExternalRef":
+		fields := strings.Fields(value)
+		if len(fields) != 3 {
+			return fmt.Errorf("invalid external reference %q", value)
+		}
+		pkg.ExternalRefs = append(pkg.ExternalRefs, ExternalRef{
+			ReferenceCategory: fields[0], ReferenceType: fields[1], ReferenceLocator: fields[2],
+		})
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package spdx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTagValue(t *testing.T) {
+	t.Parallel()
+
+	doc, err := Decode([]byte(`SPDXVersion: SPDX-2.3
+SPDXID: SPDXRef-DOCUMENT
+DocumentName: example
+Creator: Tool: example-1.0
+Created: 2024-01-01T00:00:00Z
+
+FileName: ./main.go
+SPDXID: SPDXRef-main
+
+PackageName: yaml
+SPDXID: SPDXRef-yaml
+PackageVersion: v3.0.1
+PackageLicenseDeclared: MIT
+PackageComment: <text>A
+comment</text>
+ExternalRef: PACKAGE-MANAGER purl pkg:golang/gopkg.in/yaml.v3@v3.0.1
+
+Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-yaml
+`))
+	require.NoError(t, err)
+	require.Equal(t, &Document{
+		SPDXVersion: "SPDX-2.3",
+		SPDXID:      DocumentID,
+		Name:        "example",
+		CreationInfo: &CreationInfo{
+			Created:  "2024-01-01T00:00:00Z",
+			Creators: []string{"Tool: example-1.0"},
+		},
+		Packages: []Package{{
+			SPDXID:          "SPDXRef-yaml",
+			Name:            "yaml",
+			VersionInfo:     "v3.0.1",
+			LicenseDeclared: "MIT",
+			ExternalRefs: []ExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     RefTypePurl,
+				ReferenceLocator:  "pkg:golang/gopkg.in/yaml.v3@v3.0.1",
+			}},
+		}},
+		Relationships: []Relationship{{
+			SPDXElementID: DocumentID, RelationshipType: RelationshipDescribes, RelatedSPDXElement: "SPDXRef-yaml",
+		}},
+	}, doc)
+	require.Equal(t, []string{"SPDXRef-yaml"}, doc.Described())
+	require.Equal(t, "pkg:golang/gopkg.in/yaml.v3@v3.0.1", doc.Packages[0].Purl())
+
+	_, err = Decode([]byte("SPDXVersion: SPDX-2.3\nRelationship: SPDXRef-a DEPENDS_ON\n"))
+	require.Error(t, err)
+}
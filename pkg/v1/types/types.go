@@ -24,6 +24,11 @@ type Dependency struct {
 	Name      string
 	Version   string
 	Ecosystem Ecosystem
+
+	// Scope is the scope or kind the dependency was declared with in
+	// its manifest (eg "test" or "provided"). It is empty when the
+	// manifest does not declare one or uses the ecosystem's default.
+	Scope string
 }
 
 const (
@@ -71,6 +76,10 @@ func (ecosystem Ecosystem) AsString() string {
 }
 
 // ConvertDepsToMap converts a slice of Dependency structs to a map for easier comparison
+//
+// Deprecated: the map is keyed by name only, so packages of different
+// ecosystems and several versions of a package collide. Convert the
+// dependencies with parser.FromV1 and compare them with diff.Dependencies.
 func ConvertDepsToMap(deps []Dependency) map[string]string {
 	depMap := make(map[string]string)
 	for _, dep := range deps {
@@ -80,6 +89,9 @@ func ConvertDepsToMap(deps []Dependency) map[string]string {
 }
 
 // DiffDependencies compares two sets of dependencies (represented as maps) and finds what's added in newDeps.
+//
+// Deprecated: use diff.Dependencies, which also reports removed
+// dependencies and version changes.
 func DiffDependencies(oldDeps, newDeps map[string]string) map[string]string {
 	addedDeps := make(map[string]string)
 	for dep, version := range newDeps {
@@ -0,0 +1,88 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"strings"
+	"unicode"
+)
+
+// generic is a version of an unknown scheme, split in its runs of digits
+// and of letters.
+type generic struct {
+	segments []string
+}
+
+func parseGeneric(s string) *generic {
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "+")
+	v := &generic{}
+	start := -1
+	digits := false
+	for i, r := range s {
+		isDigit := unicode.IsDigit(r)
+		if !isDigit && !unicode.IsLetter(r) {
+			if start >= 0 {
+				v.segments = append(v.segments, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && isDigit != digits {
+			v.segments = append(v.segments, s[start:i])
+			start = -1
+		}
+		if start < 0 {
+			start, digits = i, isDigit
+		}
+	}
+	if start >= 0 {
+		v.segments = append(v.segments, s[start:])
+	}
+	return v
+}
+
+// compare compares the segments in order, numerically when both are
+// numbers. Missing segments count as zero, so that 1.2 equals 1.2.0, and
+// numbers sort after letters, so that 1.0.0 is greater than 1.0.0-rc.1.
+func (v *generic) compare(other comparer) int {
+	w := other.(*generic)
+	for i := 0; i < len(v.segments) || i < len(w.segments); i++ {
+		a, b := "0", "0"
+		if i < len(v.segments) {
+			a = v.segments[i]
+		}
+		if i < len(w.segments) {
+			b = w.segments[i]
+		}
+		an, bn := isNumber(a), isNumber(b)
+		var c int
+		switch {
+		case an && bn:
+			c = compareNumbers(a, b)
+		case an:
+			c = 1
+		case bn:
+			c = -1
+		default:
+			c = strings.Compare(a, b)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
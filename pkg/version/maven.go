@@ -0,0 +1,266 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mavenQualifiers are the well-known qualifiers of Maven versions, in
+// order. The empty qualifier is the release.
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenAliases = map[string]string{"ga": "", "final": "", "release": "", "cr": "rc"}
+
+// mavenItem is an item of a Maven version: a number, a qualifier or a
+// list of items. It follows the ordering of Maven's ComparableVersion.
+type mavenItem interface {
+	// compareItem compares with another item, or with nothing when
+	// other is nil.
+	compareItem(other mavenItem) int
+	isNull() bool
+}
+
+type mavenInt string
+
+type mavenString string
+
+type mavenList []mavenItem
+
+func (i mavenInt) isNull() bool {
+	return strings.Trim(string(i), "0") == ""
+}
+
+func (i mavenInt) compareItem(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if i.isNull() {
+			return 0
+		}
+		return 1
+	case mavenInt:
+		return compareNumbers(string(i), string(o))
+	default:
+		// 1.1 > 1-sp and 1.1 > 1-1
+		return 1
+	}
+}
+
+func (s mavenString) isNull() bool {
+	return s == ""
+}
+
+// qualifierRank returns a string ordering the qualifiers: the index of the
+// well-known ones, and the unknown ones after them, alphabetically.
+func (s mavenString) qualifierRank() string {
+	for i, q := range mavenQualifiers {
+		if string(s) == q {
+			return strconv.Itoa(i)
+		}
+	}
+	return strconv.Itoa(len(mavenQualifiers)) + "-" + string(s)
+}
+
+func (s mavenString) compareItem(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		// 1-rc < 1, 1-sp > 1
+		return strings.Compare(s.qualifierRank(), mavenString("").qualifierRank())
+	case mavenInt:
+		return -1
+	case mavenString:
+		return strings.Compare(s.qualifierRank(), o.qualifierRank())
+	default:
+		return -1
+	}
+}
+
+func (l mavenList) isNull() bool {
+	return len(l) == 0
+}
+
+func (l mavenList) compareItem(other mavenItem) int {
+	switch o := other.(type) {
+	case nil:
+		if len(l) == 0 {
+			return 0
+		}
+		return l[0].compareItem(nil)
+	case mavenInt:
+		return -1
+	case mavenString:
+		return 1
+	case mavenList:
+		for i := 0; i < len(l) || i < len(o); i++ {
+			var a, b mavenItem
+			if i < len(l) {
+				a = l[i]
+			}
+			if i < len(o) {
+				b = o[i]
+			}
+			var c int
+			if a == nil {
+				c = -b.compareItem(nil)
+			} else {
+				c = a.compareItem(b)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+// maven is a Maven version.
+type maven struct {
+	items mavenList
+}
+
+// parseMaven parses a Maven version. Any string is a valid Maven version.
+func parseMaven(s string) *maven {
+	s = strings.ToLower(s)
+
+	// lists holds the lists being filled, from the root to the deepest
+	root := &mavenBuilder{}
+	lists := []*mavenBuilder{root}
+	current := root
+	add := func(item mavenItem) {
+		current.items = append(current.items, item)
+	}
+	// sublist starts a list nested in the current one
+	sublist := func() {
+		l := &mavenBuilder{}
+		lists = append(lists, l)
+		current.items = append(current.items, l)
+		current = l
+	}
+	parseItem := func(token string, followedByDigit bool) mavenItem {
+		if isNumber(token) {
+			return mavenInt(token)
+		}
+		if followedByDigit && len(token) == 1 {
+			// 1a1 is 1-alpha-1
+			switch token {
+			case "a":
+				token = "alpha"
+			case "b":
+				token = "beta"
+			case "m":
+				token = "milestone"
+			}
+		}
+		if alias, ok := mavenAliases[token]; ok {
+			token = alias
+		}
+		return mavenString(token)
+	}
+
+	start := 0
+	digit := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.':
+			if i == start {
+				add(mavenInt("0"))
+			} else {
+				add(parseItem(s[start:i], false))
+			}
+			start = i + 1
+		case c == '-':
+			if i == start {
+				add(mavenInt("0"))
+			} else {
+				add(parseItem(s[start:i], false))
+			}
+			start = i + 1
+			sublist()
+		case c >= '0' && c <= '9':
+			if !digit && i > start {
+				add(parseItem(s[start:i], true))
+				start = i
+				sublist()
+			}
+			digit = true
+		default:
+			if digit && i > start {
+				add(parseItem(s[start:i], false))
+				start = i
+				sublist()
+			}
+			digit = false
+		}
+	}
+	if len(s) > start {
+		add(parseItem(s[start:], false))
+	}
+
+	// Trailing null items do not matter: 1.0 == 1 == 1-ga
+	for i := len(lists) - 1; i >= 0; i-- {
+		normalizeMaven(lists[i])
+	}
+	return &maven{items: resolveMaven(root)}
+}
+
+// mavenBuilder is a list being parsed, which can still be modified.
+type mavenBuilder struct {
+	items []mavenItem
+}
+
+func (l *mavenBuilder) isNull() bool {
+	return len(l.items) == 0
+}
+
+func (l *mavenBuilder) compareItem(other mavenItem) int {
+	return resolveMaven(l).compareItem(other)
+}
+
+// normalizeMaven removes the null items of a list that are only followed
+// by null items or nested lists, like ComparableVersion does.
+func normalizeMaven(l *mavenBuilder) {
+	for i := len(l.items) - 1; i >= 0; i-- {
+		item := l.items[i]
+		if item.isNull() {
+			l.items = append(l.items[:i], l.items[i+1:]...)
+			continue
+		}
+		if _, ok := item.(*mavenBuilder); !ok {
+			break
+		}
+	}
+}
+
+// resolveMaven converts a list being parsed to its final value.
+func resolveMaven(l *mavenBuilder) mavenList {
+	res := make(mavenList, len(l.items))
+	for i, item := range l.items {
+		if sub, ok := item.(*mavenBuilder); ok {
+			res[i] = resolveMaven(sub)
+		} else {
+			res[i] = item
+		}
+	}
+	return res
+}
+
+func (v *maven) compare(other comparer) int {
+	return v.items.compareItem(other.(*maven).items)
+}
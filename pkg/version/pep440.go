@@ -0,0 +1,200 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pep440Pattern is the pattern of the versions accepted by PEP 440,
+// including the alternative spellings it normalizes.
+var pep440Pattern = regexp.MustCompile(`(?i)^v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(?P<pre_l>alpha|a|beta|b|preview|pre|c|rc)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+// pep440 is a Python package version.
+type pep440 struct {
+	epoch   string
+	release []string
+
+	// preKind is the rank of the pre-release phase: 0 for alpha, 1 for
+	// beta and 2 for release candidates. It is -1 without pre-release.
+	preKind int
+	pre     string
+	hasPost bool
+	post    string
+	hasDev  bool
+	dev     string
+	local   []string
+}
+
+func parsePEP440(s string) (*pep440, error) {
+	m := pep440Pattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid PEP 440 version %q", s)
+	}
+	group := func(name string) string {
+		return m[pep440Pattern.SubexpIndex(name)]
+	}
+
+	v := &pep440{epoch: group("epoch"), preKind: -1}
+	if v.epoch == "" {
+		v.epoch = "0"
+	}
+	v.release = strings.Split(group("release"), ".")
+	// Trailing zeros do not matter: 1.0 == 1.0.0
+	for len(v.release) > 1 && strings.Trim(v.release[len(v.release)-1], "0") == "" {
+		v.release = v.release[:len(v.release)-1]
+	}
+
+	if l := strings.ToLower(group("pre_l")); l != "" {
+		switch l {
+		case "a", "alpha":
+			v.preKind = 0
+		case "b", "beta":
+			v.preKind = 1
+		default:
+			v.preKind = 2
+		}
+		v.pre = numberOrZero(group("pre_n"))
+	}
+	if n := group("post_n1"); n != "" {
+		v.hasPost, v.post = true, n
+	} else if group("post_l") != "" {
+		v.hasPost, v.post = true, numberOrZero(group("post_n2"))
+	}
+	if group("dev_l") != "" {
+		v.hasDev, v.dev = true, numberOrZero(group("dev_n"))
+	}
+	if local := group("local"); local != "" {
+		v.local = strings.FieldsFunc(strings.ToLower(local), func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+	return v, nil
+}
+
+func numberOrZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+func (v *pep440) compare(other comparer) int {
+	w := other.(*pep440)
+	if c := compareNumbers(v.epoch, w.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(v.release) || i < len(w.release); i++ {
+		a, b := "0", "0"
+		if i < len(v.release) {
+			a = v.release[i]
+		}
+		if i < len(w.release) {
+			b = w.release[i]
+		}
+		if c := compareNumbers(a, b); c != 0 {
+			return c
+		}
+	}
+	if c := v.preRank().compare(w.preRank()); c != 0 {
+		return c
+	}
+	if c := v.postRank().compare(w.postRank()); c != 0 {
+		return c
+	}
+	if c := v.devRank().compare(w.devRank()); c != 0 {
+		return c
+	}
+	return compareLocal(v.local, w.local)
+}
+
+// rank orders the optional parts of versions: a missing part sorts
+// before (bound -1) or after (bound 1) all the present ones.
+type rank struct {
+	bound  int
+	kind   int
+	number string
+}
+
+func (r rank) compare(o rank) int {
+	if r.bound != o.bound {
+		return sign(r.bound - o.bound)
+	}
+	if r.bound != 0 {
+		return 0
+	}
+	if r.kind != o.kind {
+		return sign(r.kind - o.kind)
+	}
+	return compareNumbers(r.number, o.number)
+}
+
+func (v *pep440) preRank() rank {
+	switch {
+	case v.preKind >= 0:
+		return rank{kind: v.preKind, number: v.pre}
+	case v.hasDev && !v.hasPost:
+		// 1.0.dev1 is lower than 1.0a1
+		return rank{bound: -1}
+	default:
+		return rank{bound: 1}
+	}
+}
+
+func (v *pep440) postRank() rank {
+	if !v.hasPost {
+		return rank{bound: -1}
+	}
+	return rank{number: v.post}
+}
+
+func (v *pep440) devRank() rank {
+	if !v.hasDev {
+		return rank{bound: 1}
+	}
+	return rank{number: v.dev}
+}
+
+// compareLocal compares local version labels. Versions without a label
+// are lower, numeric segments are greater than alphanumeric ones.
+func compareLocal(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, bn := isNumber(a[i]), isNumber(b[i])
+		var c int
+		switch {
+		case an && bn:
+			c = compareNumbers(a[i], b[i])
+		case an:
+			c = 1
+		case bn:
+			c = -1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return sign(len(a) - len(b))
+}
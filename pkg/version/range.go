@@ -0,0 +1,771 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Range is a set of versions, written in the syntax of the constraints of
+// an ecosystem:
+//
+//   - npm: "^4.17.1", "~1.2", "1.x", "1.2.3 - 2", ">=1.0.0 <2 || >=3"
+//   - Cargo: "1.2" (a caret requirement), "~1.2", ">= 1.2, < 2", "1.*"
+//   - PEP 440: "~=2.0", "==1.4.*", ">=1.2,<2,!=1.5"
+//   - Maven: "[1.0,2.0)", "(,1.0],[1.2,)", "[1.0]", and the Gradle
+//     "1.+" and "latest.release"
+//   - Go and the other ecosystems: space or comma separated comparisons,
+//     such as ">= 1.2, < 2"; a version without operator matches exactly
+//
+// Pre-releases follow the rules of the ecosystem: npm, Cargo and PEP 440
+// ranges only contain the pre-releases they name.
+type Range struct {
+	scheme Scheme
+	raw    string
+	// sets are the alternatives of the range: a version is in the range
+	// when it satisfies all the constraints of one of them.
+	sets [][]constraint
+}
+
+// operator is the comparison of a constraint.
+type operator int
+
+const (
+	opEq operator = iota
+	opNe
+	opLt
+	opLe
+	opGt
+	opGe
+	// opIdentical matches the version string exactly, like "===" in PEP 440.
+	opIdentical
+	// opPrefix matches the versions starting with the string, like "1.+"
+	// in Gradle.
+	opPrefix
+	// opIn and opNotIn match the versions between v (included) and upper
+	// (excluded), or outside of them.
+	opIn
+	opNotIn
+)
+
+// constraint is a comparison with a version.
+type constraint struct {
+	op    operator
+	v     Version
+	upper Version
+	text  string
+	// ignoreLocal compares versions without their local label, like the
+	// PEP 440 specifiers without one do.
+	ignoreLocal bool
+}
+
+// dialect is the syntax of the ranges of an ecosystem.
+type dialect int
+
+const (
+	dialectComparisons dialect = iota
+	dialectNpm
+	dialectCargo
+	dialectPEP440
+	dialectMaven
+)
+
+func dialectOf(scheme Scheme, ecosystem string) dialect {
+	switch scheme {
+	case SchemeSemver:
+		switch strings.ToLower(ecosystem) {
+		case "crates", "cargo":
+			return dialectCargo
+		}
+		return dialectNpm
+	case SchemePEP440:
+		return dialectPEP440
+	case SchemeMaven:
+		return dialectMaven
+	default:
+		return dialectComparisons
+	}
+}
+
+// ParseRange parses a range of versions of a scheme. Semantic version
+// ranges are read with the npm syntax.
+func ParseRange(scheme Scheme, s string) (Range, error) {
+	return parseRange(scheme, dialectOf(scheme, ""), s)
+}
+
+// ParseRangeFor parses a range of versions of a package of an ecosystem,
+// as named by the parser package.
+func ParseRangeFor(ecosystem, s string) (Range, error) {
+	scheme := SchemeOf(ecosystem)
+	return parseRange(scheme, dialectOf(scheme, ecosystem), s)
+}
+
+// MustParseRange is like ParseRange but panics when the range is invalid.
+func MustParseRange(scheme Scheme, s string) Range {
+	r, err := ParseRange(scheme, s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func parseRange(scheme Scheme, d dialect, s string) (Range, error) {
+	s = strings.TrimSpace(s)
+	switch scheme {
+	case SchemeSemver, SchemeGo, SchemePEP440, SchemeMaven, SchemeGeneric:
+	default:
+		return Range{}, fmt.Errorf("unknown version scheme %q", scheme)
+	}
+	p := &rangeParser{scheme: scheme}
+	var err error
+	switch d {
+	case dialectNpm:
+		err = p.parseNpm(s, false)
+	case dialectCargo:
+		err = p.parseNpm(s, true)
+	case dialectPEP440:
+		err = p.parsePEP440(s)
+	case dialectMaven:
+		err = p.parseMaven(s)
+	default:
+		err = p.parseComparisons(s)
+	}
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid version range %q: %w", s, err)
+	}
+	return Range{scheme: scheme, raw: s, sets: p.sets}, nil
+}
+
+// String returns the range as it was parsed.
+func (r Range) String() string {
+	return r.raw
+}
+
+// Scheme returns the scheme of the versions of the range.
+func (r Range) Scheme() Scheme {
+	return r.scheme
+}
+
+// Contains returns true if the version is in the range.
+func (r Range) Contains(v Version) bool {
+	if v.IsZero() {
+		return false
+	}
+	for _, set := range r.sets {
+		if r.satisfies(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsString parses a version of the scheme of the range and returns
+// true if it is in the range. Invalid versions are in no range.
+func (r Range) ContainsString(s string) bool {
+	v, err := Parse(r.scheme, s)
+	return err == nil && r.Contains(v)
+}
+
+func (r Range) satisfies(set []constraint, v Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if !isPrerelease(v) {
+		return true
+	}
+	switch r.scheme {
+	case SchemeSemver:
+		// npm and Cargo only match a pre-release when a constraint names a
+		// pre-release of the same major, minor and patch numbers.
+		for _, c := range set {
+			if isPrerelease(c.v) && sameRelease(c.v, v) {
+				return true
+			}
+		}
+		return false
+	case SchemePEP440:
+		// PEP 440 only matches pre-releases when a specifier names one.
+		// The bounds of prefix matches are not named by the specifiers.
+		for _, c := range set {
+			if c.op != opIn && c.op != opNotIn && isPrerelease(c.v) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *constraint) matches(v Version) bool {
+	switch c.op {
+	case opIdentical:
+		return strings.EqualFold(v.raw, c.text)
+	case opPrefix:
+		return strings.HasPrefix(v.raw, c.text)
+	case opIn:
+		return v.Compare(c.v) >= 0 && v.Compare(c.upper) < 0
+	case opNotIn:
+		return v.Compare(c.v) < 0 || v.Compare(c.upper) >= 0
+	}
+	if c.ignoreLocal {
+		v = withoutLocal(v)
+	}
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case opEq:
+		return cmp == 0
+	case opNe:
+		return cmp != 0
+	case opLt:
+		return cmp < 0
+	case opLe:
+		return cmp <= 0
+	case opGt:
+		return cmp > 0
+	case opGe:
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+// Resolve returns the highest of the versions in the range.
+func (r Range) Resolve(versions []Version) (Version, bool) {
+	var best Version
+	for _, v := range versions {
+		if r.Contains(v) && (best.IsZero() || v.Compare(best) > 0) {
+			best = v
+		}
+	}
+	return best, !best.IsZero()
+}
+
+// Exact returns the version when the range contains a single version, such
+// as "1.2.3" in npm, "==1.2.3" in PEP 440 or "[1.2.3]" in Maven.
+func (r Range) Exact() (Version, bool) {
+	if len(r.sets) != 1 || len(r.sets[0]) != 1 {
+		return Version{}, false
+	}
+	c := r.sets[0][0]
+	if c.op != opEq {
+		return Version{}, false
+	}
+	return c.v, true
+}
+
+// LowerBound returns the lowest version the range starts from, such as
+// 4.17.1 for "^4.17.1" in npm or 1.0 for "[1.0,2.0)" in Maven. It returns
+// false when the range has no lower bound, as in "<2".
+func (r Range) LowerBound() (Version, bool) {
+	var lowest Version
+	for _, set := range r.sets {
+		var lower Version
+		for _, c := range set {
+			switch c.op {
+			case opEq, opGe, opGt, opIn:
+				if lower.IsZero() || c.v.Compare(lower) > 0 {
+					lower = c.v
+				}
+			}
+		}
+		if lower.IsZero() {
+			return Version{}, false
+		}
+		if lowest.IsZero() || lower.Compare(lowest) < 0 {
+			lowest = lower
+		}
+	}
+	return lowest, !lowest.IsZero()
+}
+
+func isPrerelease(v Version) bool {
+	switch k := v.key.(type) {
+	case *semver:
+		return len(k.pre) > 0
+	case *pep440:
+		return k.preKind >= 0 || k.hasDev
+	default:
+		return false
+	}
+}
+
+func sameRelease(a, b Version) bool {
+	x, ok1 := a.key.(*semver)
+	y, ok2 := b.key.(*semver)
+	return ok1 && ok2 && x.numbers == y.numbers
+}
+
+func withoutLocal(v Version) Version {
+	k, ok := v.key.(*pep440)
+	if !ok || len(k.local) == 0 {
+		return v
+	}
+	stripped := *k
+	stripped.local = nil
+	v.key = &stripped
+	return v
+}
+
+// rangeParser accumulates the sets of constraints of a range.
+type rangeParser struct {
+	scheme Scheme
+	sets   [][]constraint
+}
+
+func (p *rangeParser) version(s string) (Version, error) {
+	return Parse(p.scheme, s)
+}
+
+// add adds a comparison with a version written in s to the last set.
+func (p *rangeParser) add(op operator, s string) error {
+	v, err := p.version(s)
+	if err != nil {
+		return err
+	}
+	p.addVersion(op, v)
+	return nil
+}
+
+func (p *rangeParser) addVersion(op operator, v Version) {
+	last := len(p.sets) - 1
+	p.sets[last] = append(p.sets[last], constraint{op: op, v: v, text: v.raw})
+}
+
+// addBetween adds the versions from lower (included) to upper (excluded).
+func (p *rangeParser) addBetween(lower, upper string) error {
+	if err := p.add(opGe, lower); err != nil {
+		return err
+	}
+	return p.add(opLt, upper)
+}
+
+// comparisonOperators are the operators of comparisons, longest first.
+var comparisonOperators = []struct {
+	text string
+	op   operator
+}{
+	{"==", opEq}, {"!=", opNe}, {"<=", opLe}, {">=", opGe}, {"=", opEq}, {"<", opLt}, {">", opGt},
+}
+
+// cutOperator splits a comparison in its operator and its version.
+func cutOperator(s string) (operator, string, bool) {
+	for _, o := range comparisonOperators {
+		if rest, ok := strings.CutPrefix(s, o.text); ok {
+			return o.op, strings.TrimSpace(rest), true
+		}
+	}
+	return opEq, s, false
+}
+
+// splitComparisons splits space or comma separated comparisons, keeping
+// the operators separated from their version with a space.
+func splitComparisons(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	var res []string
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.Trim(field, "=!<>~^") == "" && i+1 < len(fields) {
+			i++
+			field += fields[i]
+		}
+		res = append(res, field)
+	}
+	return res
+}
+
+var versionPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.+_:~-]*$`)
+
+// parseComparisons parses space or comma separated comparisons.
+func (p *rangeParser) parseComparisons(s string) error {
+	p.sets = append(p.sets, nil)
+	fields := splitComparisons(s)
+	if len(fields) == 0 {
+		return fmt.Errorf("no version")
+	}
+	for _, field := range fields {
+		op, v, _ := cutOperator(field)
+		if !versionPattern.MatchString(v) {
+			return fmt.Errorf("invalid comparison %q", field)
+		}
+		if err := p.add(op, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partial is a semantic version in a range, where the numbers can be
+// missing or wildcards, as in "1.2" or "1.x".
+type partial struct {
+	numbers []string
+	pre     string
+}
+
+func parsePartial(s string) (partial, error) {
+	orig := s
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "="), "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+	var res partial
+	wildcard := false
+	for i, n := range strings.Split(core, ".") {
+		switch {
+		case i >= 3:
+			return partial{}, fmt.Errorf("invalid version %q", orig)
+		case n == "x" || n == "X" || n == "*":
+			// The numbers after a wildcard must be wildcards too
+			wildcard = true
+		case wildcard || !isNumber(n):
+			return partial{}, fmt.Errorf("invalid version %q", orig)
+		default:
+			res.numbers = append(res.numbers, n)
+		}
+	}
+	if wildcard && hasPre {
+		return partial{}, fmt.Errorf("invalid version %q", orig)
+	}
+	if hasPre {
+		if len(res.numbers) != 3 || pre == "" {
+			return partial{}, fmt.Errorf("invalid version %q", orig)
+		}
+		res.pre = pre
+	}
+	return res, nil
+}
+
+// String returns the version with the missing numbers set to zero.
+func (v partial) String() string {
+	numbers := append([]string(nil), v.numbers...)
+	for len(numbers) < 3 {
+		numbers = append(numbers, "0")
+	}
+	s := strings.Join(numbers, ".")
+	if v.pre != "" {
+		s += "-" + v.pre
+	}
+	return s
+}
+
+// bump returns the lowest pre-release of the version following v by its
+// number at index i, eg 1.3.0-0 for 1.2.5 and 1.
+func (v partial) bump(i int) string {
+	numbers := []string{"0", "0", "0"}
+	copy(numbers, v.numbers[:i])
+	numbers[i] = increment(v.numbers[i])
+	return strings.Join(numbers, ".") + "-0"
+}
+
+// increment adds one to a string of decimal digits.
+func increment(n string) string {
+	digits := []byte(strings.TrimLeft(n, "0"))
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return string(digits)
+		}
+		digits[i] = '0'
+	}
+	return "1" + string(digits)
+}
+
+// parseNpm parses npm ranges, or Cargo requirements when cargo is true.
+func (p *rangeParser) parseNpm(s string, cargo bool) error {
+	alternatives := []string{s}
+	if !cargo {
+		alternatives = strings.Split(s, "||")
+	}
+	for _, alt := range alternatives {
+		p.sets = append(p.sets, nil)
+		alt = strings.TrimSpace(alt)
+		if lower, upper, ok := strings.Cut(alt, " - "); ok && !cargo {
+			if err := p.parseHyphen(strings.TrimSpace(lower), strings.TrimSpace(upper)); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, field := range splitComparisons(alt) {
+			if err := p.parseNpmComparison(field, cargo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *rangeParser) parseHyphen(lower, upper string) error {
+	lo, err := parsePartial(lower)
+	if err != nil {
+		return err
+	}
+	hi, err := parsePartial(upper)
+	if err != nil {
+		return err
+	}
+	if err := p.add(opGe, lo.String()); err != nil {
+		return err
+	}
+	switch len(hi.numbers) {
+	case 0:
+		return nil
+	case 3:
+		return p.add(opLe, hi.String())
+	default:
+		return p.add(opLt, hi.bump(len(hi.numbers)-1))
+	}
+}
+
+func (p *rangeParser) parseNpmComparison(field string, cargo bool) error {
+	var prefix string
+	for _, op := range []string{"~>", "^", "~"} {
+		if rest, ok := strings.CutPrefix(field, op); ok {
+			prefix, field = op, strings.TrimSpace(rest)
+			break
+		}
+	}
+	op, rest, hasOp := cutOperator(field)
+	if prefix != "" && hasOp {
+		return fmt.Errorf("invalid comparison %q", prefix+field)
+	}
+	v, err := parsePartial(rest)
+	if err != nil {
+		return err
+	}
+	n := len(v.numbers)
+	if prefix == "" && !hasOp && cargo {
+		// Cargo reads bare versions as caret requirements
+		prefix = "^"
+	}
+	switch prefix {
+	case "^":
+		switch {
+		case n == 0:
+			return nil
+		case n == 1 || v.numbers[0] != "0":
+			return p.addBetween(v.String(), v.bump(0))
+		case n == 2 || v.numbers[1] != "0":
+			return p.addBetween(v.String(), v.bump(1))
+		default:
+			return p.addBetween(v.String(), v.bump(2))
+		}
+	case "~", "~>":
+		switch n {
+		case 0:
+			return nil
+		case 1:
+			return p.addBetween(v.String(), v.bump(0))
+		default:
+			return p.addBetween(v.String(), v.bump(1))
+		}
+	}
+
+	if n == 3 {
+		return p.add(op, v.String())
+	}
+	if n == 0 {
+		// "*" matches everything, "<*" and ">*" nothing
+		if op == opLt || op == opGt || op == opNe {
+			return p.add(opLt, "0.0.0-0")
+		}
+		return nil
+	}
+	switch op {
+	case opEq:
+		return p.addBetween(v.String(), v.bump(n-1))
+	case opNe:
+		p.sets[len(p.sets)-1] = append(p.sets[len(p.sets)-1], constraint{
+			op: opNotIn, v: MustParse(p.scheme, v.String()), upper: MustParse(p.scheme, v.bump(n-1)),
+		})
+		return nil
+	case opGt:
+		return p.add(opGe, strings.TrimSuffix(v.bump(n-1), "-0"))
+	case opGe:
+		return p.add(opGe, v.String())
+	case opLt:
+		return p.add(opLt, v.String()+"-0")
+	default:
+		return p.add(opLt, v.bump(n-1))
+	}
+}
+
+// pep440Operators are the operators of PEP 440 specifiers, longest first.
+var pep440Operators = []struct {
+	text string
+	op   operator
+}{
+	{"===", opIdentical}, {"~=", opGe}, {"==", opEq}, {"!=", opNe}, {"<=", opLe}, {">=", opGe}, {"<", opLt}, {">", opGt},
+}
+
+// parsePEP440 parses comma separated PEP 440 specifiers.
+func (p *rangeParser) parsePEP440(s string) error {
+	p.sets = append(p.sets, nil)
+	for _, spec := range strings.Split(s, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			return fmt.Errorf("empty specifier")
+		}
+		op, text := opEq, spec
+		compatible := false
+		for _, o := range pep440Operators {
+			if rest, ok := strings.CutPrefix(spec, o.text); ok {
+				op, text = o.op, strings.TrimSpace(rest)
+				compatible = o.text == "~="
+				break
+			}
+		}
+		if err := p.addPEP440(op, text, compatible); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rangeParser) addPEP440(op operator, text string, compatible bool) error {
+	set := &p.sets[len(p.sets)-1]
+	if op == opIdentical {
+		*set = append(*set, constraint{op: opIdentical, text: text})
+		return nil
+	}
+
+	if prefix, ok := strings.CutSuffix(text, ".*"); ok {
+		// ==1.2.* matches the versions from 1.2.dev0 to 1.3.dev0
+		if op != opEq && op != opNe {
+			return fmt.Errorf("invalid specifier %q", text)
+		}
+		epoch, release, err := pep440Release(prefix)
+		if err != nil {
+			return err
+		}
+		return p.addPEP440Prefix(op, epoch, release)
+	}
+
+	v, err := p.version(text)
+	if err != nil {
+		return err
+	}
+	if compatible {
+		// ~=2.2.1 is >=2.2.1, ==2.2.*
+		epoch, release, err := pep440Release(text)
+		if err != nil {
+			return err
+		}
+		if len(release) < 2 {
+			return fmt.Errorf("invalid compatible release %q", text)
+		}
+		p.addVersion(opGe, v)
+		return p.addPEP440Prefix(opEq, epoch, release[:len(release)-1])
+	}
+	k := v.key.(*pep440)
+	*set = append(*set, constraint{op: op, v: v, text: text, ignoreLocal: len(k.local) == 0})
+	return nil
+}
+
+// addPEP440Prefix adds a prefix match of a release, or its negation.
+func (p *rangeParser) addPEP440Prefix(op operator, epoch string, release []string) error {
+	lower, err := p.version(epoch + strings.Join(release, ".") + ".dev0")
+	if err != nil {
+		return err
+	}
+	next := append([]string(nil), release...)
+	next[len(next)-1] = increment(next[len(next)-1])
+	upper, err := p.version(epoch + strings.Join(next, ".") + ".dev0")
+	if err != nil {
+		return err
+	}
+	if op == opEq {
+		op = opIn
+	} else {
+		op = opNotIn
+	}
+	set := &p.sets[len(p.sets)-1]
+	*set = append(*set, constraint{op: op, v: lower, upper: upper})
+	return nil
+}
+
+// pep440Release returns the epoch prefix, such as "1!", and the numbers of
+// the release of a PEP 440 version, including its trailing zeros.
+func pep440Release(s string) (string, []string, error) {
+	m := pep440Pattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", nil, fmt.Errorf("invalid PEP 440 version %q", s)
+	}
+	var epoch string
+	if e := m[pep440Pattern.SubexpIndex("epoch")]; e != "" {
+		epoch = e + "!"
+	}
+	return epoch, strings.Split(m[pep440Pattern.SubexpIndex("release")], "."), nil
+}
+
+// mavenRangePattern matches a Maven range, such as "[1.0,2.0)" or "[1.0]".
+var mavenRangePattern = regexp.MustCompile(`^([\[(])\s*([^,\[\]()]*?)\s*(?:(,)\s*([^,\[\]()]*?)\s*)?([\])])\s*,?\s*`)
+
+// parseMaven parses Maven ranges and Gradle dynamic versions, falling back
+// to comparisons.
+func (p *rangeParser) parseMaven(s string) error {
+	switch {
+	case s == "+" || strings.HasPrefix(s, "latest."):
+		p.sets = append(p.sets, nil)
+		return nil
+	case strings.HasSuffix(s, "+"):
+		p.sets = append(p.sets, []constraint{{op: opPrefix, text: strings.TrimSuffix(s, "+")}})
+		return nil
+	case !strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "("):
+		return p.parseComparisons(s)
+	}
+
+	for rest := s; rest != ""; {
+		m := mavenRangePattern.FindStringSubmatch(rest)
+		if m == nil {
+			return fmt.Errorf("invalid range at %q", rest)
+		}
+		rest = rest[len(m[0]):]
+		p.sets = append(p.sets, nil)
+		open, lower, comma, upper, closing := m[1], m[2], m[3], m[4], m[5]
+		if comma == "" {
+			// [1.0] is exactly 1.0
+			if open != "[" || closing != "]" || lower == "" {
+				return fmt.Errorf("invalid range %q", m[0])
+			}
+			if err := p.add(opEq, lower); err != nil {
+				return err
+			}
+			continue
+		}
+		if lower != "" {
+			op := opGe
+			if open == "(" {
+				op = opGt
+			}
+			if err := p.add(op, lower); err != nil {
+				return err
+			}
+		}
+		if upper != "" {
+			op := opLe
+			if closing == ")" {
+				op = opLt
+			}
+			if err := p.add(op, upper); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeContains(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ecosystem string
+		r         string
+		in        []string
+		out       []string
+	}{
+		// npm
+		{"npm", "^4.17.1", []string{"4.17.1", "4.99.0"}, []string{"4.17.0", "5.0.0", "5.0.0-rc.1", "4.18.0-beta"}},
+		{"npm", "^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"npm", "^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"npm", "^1.2.3-beta.2", []string{"1.2.3-beta.4", "1.2.3", "1.9.0"}, []string{"1.2.3-beta.1", "1.2.4-beta.2"}},
+		{"npm", "~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0"}},
+		{"npm", "~1", []string{"1.0.0", "1.9.9"}, []string{"2.0.0"}},
+		{"npm", "1.x", []string{"1.0.0", "1.5.3"}, []string{"0.9.0", "2.0.0"}},
+		{"npm", "1.2", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"npm", "1.2.3", []string{"1.2.3", "v1.2.3"}, []string{"1.2.4"}},
+		{"npm", "*", []string{"0.0.1", "9.9.9"}, []string{"1.0.0-rc.1"}},
+		{"npm", "", []string{"1.0.0"}, nil},
+		{"npm", "1.2.3 - 2.3", []string{"1.2.3", "2.3.9"}, []string{"1.2.2", "2.4.0"}},
+		{"npm", "1.2 - 2.3.4", []string{"1.2.0", "2.3.4"}, []string{"2.3.5"}},
+		{"npm", ">=1.0.0 <2 || >=3", []string{"1.5.0", "3.1.0"}, []string{"2.5.0", "0.9.0"}},
+		{"npm", "> 1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"npm", "<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"npm", "<1.2", []string{"1.1.9"}, []string{"1.2.0", "1.1.9-rc.1"}},
+		// Cargo
+		{"crates", "1.2", []string{"1.2.0", "1.9.0"}, []string{"2.0.0", "1.1.0"}},
+		{"crates", "0.3", []string{"0.3.1"}, []string{"0.4.0"}},
+		{"crates", "=1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"crates", ">= 1.2, < 1.5", []string{"1.2.0", "1.4.9"}, []string{"1.5.0"}},
+		{"crates", "1.*", []string{"1.7.0"}, []string{"2.0.0"}},
+		{"crates", "~1.2", []string{"1.2.5"}, []string{"1.3.0"}},
+		// PEP 440
+		{"pypi", "~=2.0", []string{"2.0", "2.9.1"}, []string{"1.9", "3.0", "2.1rc1"}},
+		{"pypi", "~=2.2.1", []string{"2.2.1", "2.2.9"}, []string{"2.3.0"}},
+		{"pypi", "==1.4.*", []string{"1.4", "1.4.2", "1.4.post1"}, []string{"1.5", "1.3.9"}},
+		{"pypi", "!=1.4.*", []string{"1.5", "1.3.9"}, []string{"1.4.2"}},
+		{"pypi", ">=1.2,<2,!=1.5", []string{"1.2", "1.9"}, []string{"1.5", "2.0", "1.1"}},
+		{"pypi", "==1.0", []string{"1.0", "1.0.0", "1.0+local.1"}, []string{"1.0.1"}},
+		{"pypi", ">=2.0rc1", []string{"2.0rc1", "2.0", "2.1a1"}, []string{"2.0b1"}},
+		{"pypi", "===1.0", []string{"1.0"}, []string{"1.0.0"}},
+		// Maven
+		{"maven", "[1.0,2.0)", []string{"1.0", "1.5.3"}, []string{"2.0", "0.9"}},
+		{"maven", "(,1.0],[1.2,)", []string{"0.1", "1.0", "1.2", "3.0"}, []string{"1.1"}},
+		{"maven", "(1.0,2.0]", []string{"1.0.1", "2.0"}, []string{"1.0"}},
+		{"maven", "[1.0]", []string{"1.0", "1"}, []string{"1.0.1"}},
+		{"maven", "1.+", []string{"1.0", "1.5.2"}, []string{"2.0", "10.1"}},
+		{"maven", "latest.release", []string{"5.3.21"}, nil},
+		{"maven", "5.3.21", []string{"5.3.21"}, []string{"5.3.22"}},
+		// Go
+		{"go", ">= v1.2.0, < v2.0.0", []string{"v1.2.0", "v1.9.0-rc.1"}, []string{"v2.0.0"}},
+		{"go", "v1.2.3", []string{"v1.2.3"}, []string{"v1.2.4"}},
+		// Other ecosystems
+		{"deb", ">=1.0 <2", []string{"1.10.0", "1.0"}, []string{"2.0.0"}},
+		{"deb", "<1.0.0", []string{"1.0.0-rc.1"}, []string{"1.0.0"}},
+		{"deb", "!= 1.0", []string{"1.0.1"}, []string{"1.0"}},
+	} {
+		r, err := ParseRangeFor(tc.ecosystem, tc.r)
+		require.NoError(t, err, "%s: %q", tc.ecosystem, tc.r)
+		for _, v := range tc.in {
+			require.True(t, r.ContainsString(v), "%s: %s in %q", tc.ecosystem, v, tc.r)
+		}
+		for _, v := range tc.out {
+			require.False(t, r.ContainsString(v), "%s: %s not in %q", tc.ecosystem, v, tc.r)
+		}
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct{ ecosystem, r string }{
+		{"npm", "^1.2.3.4"},
+		{"npm", "~>x.y"},
+		{"pypi", "~=1"},
+		{"pypi", ">=1.*"},
+		{"pypi", ">=1.0,"},
+		{"maven", "[1.0,2.0"},
+		{"maven", "(1.0)"},
+		{"go", ">=v1.2"},
+		{"deb", "~>1"},
+		{"deb", ""},
+	} {
+		_, err := ParseRangeFor(tc.ecosystem, tc.r)
+		require.Error(t, err, "%s: %q", tc.ecosystem, tc.r)
+	}
+
+	_, err := ParseRange("unknown", "1.0")
+	require.Error(t, err)
+}
+
+func TestRangeResolve(t *testing.T) {
+	t.Parallel()
+
+	var versions []Version
+	for _, v := range []string{"4.17.20", "4.17.21", "4.18.0-rc.1", "5.0.0"} {
+		versions = append(versions, MustParse(SchemeSemver, v))
+	}
+	v, ok := MustParseRange(SchemeSemver, "^4.17.1").Resolve(versions)
+	require.True(t, ok)
+	require.Equal(t, "4.17.21", v.String())
+
+	_, ok = MustParseRange(SchemeSemver, "^6").Resolve(versions)
+	require.False(t, ok)
+}
+
+func TestRangeExact(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ecosystem, r, want string
+	}{
+		{"npm", "4.17.21", "4.17.21"},
+		{"npm", "=4.17.21", "4.17.21"},
+		{"npm", "^4.17.21", ""},
+		{"npm", "4.17", ""},
+		{"crates", "1.0.0", ""},
+		{"crates", "=1.0.0", "1.0.0"},
+		{"pypi", "==2.31.0", "2.31.0"},
+		{"pypi", ">=2.31.0", ""},
+		{"maven", "[1.2.3]", "1.2.3"},
+		{"maven", "1.2.3", "1.2.3"},
+		{"go", "v1.2.3", "v1.2.3"},
+	} {
+		r, err := ParseRangeFor(tc.ecosystem, tc.r)
+		require.NoError(t, err)
+		v, ok := r.Exact()
+		require.Equal(t, tc.want != "", ok, "%s: %q", tc.ecosystem, tc.r)
+		require.Equal(t, tc.want, v.String(), "%s: %q", tc.ecosystem, tc.r)
+	}
+}
+
+func TestRangeLowerBound(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ecosystem, r, want string
+	}{
+		{"npm", "^4.17.1", "4.17.1"},
+		{"npm", "<2 || >=3", ""},
+		{"npm", ">=1.2.0 <2.0.0 || ^0.9.0", "0.9.0"},
+		{"crates", ">= 1.2, < 2", "1.2.0"},
+		{"crates", "1.2.3", "1.2.3"},
+		{"pypi", ">=2.0,!=2.1", "2.0"},
+		{"maven", "[1.0,2.0)", "1.0"},
+		{"maven", "(,2.0]", ""},
+		{"maven", "[1.0,2.0),[3.0,)", "1.0"},
+	} {
+		r, err := ParseRangeFor(tc.ecosystem, tc.r)
+		require.NoError(t, err)
+		v, ok := r.LowerBound()
+		require.Equal(t, tc.want != "", ok, "%s: %q", tc.ecosystem, tc.r)
+		require.Equal(t, tc.want, v.String(), "%s: %q", tc.ecosystem, tc.r)
+	}
+}
@@ -0,0 +1,104 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// semver is a semantic version. Build metadata does not take part in the
+// ordering and is not kept.
+type semver struct {
+	// numbers are the major, minor and patch numbers.
+	numbers [3]string
+	// parts is the number of numbers written in the version, eg 2 for
+	// "1.2". It is used to expand partial versions in ranges.
+	parts int
+	pre   []string
+}
+
+// parseSemver parses a semantic version. Go versions must have all three
+// numbers, while the other ecosystems accept partial versions.
+func parseSemver(s string, goModule bool) (*semver, error) {
+	orig := s
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "="), "v")
+	s, _, _ = strings.Cut(s, "+")
+
+	core, pre, hasPre := strings.Cut(s, "-")
+	numbers := strings.Split(core, ".")
+	if len(numbers) > 3 || (goModule && len(numbers) != 3) {
+		return nil, fmt.Errorf("invalid semantic version %q", orig)
+	}
+	v := &semver{parts: len(numbers)}
+	for i := range v.numbers {
+		v.numbers[i] = "0"
+		if i < len(numbers) {
+			if !isNumber(numbers[i]) {
+				return nil, fmt.Errorf("invalid semantic version %q", orig)
+			}
+			v.numbers[i] = numbers[i]
+		}
+	}
+	if hasPre {
+		v.pre = strings.Split(pre, ".")
+		for _, id := range v.pre {
+			if id == "" {
+				return nil, fmt.Errorf("invalid semantic version %q", orig)
+			}
+		}
+	}
+	return v, nil
+}
+
+func (v *semver) compare(other comparer) int {
+	w := other.(*semver)
+	for i := range v.numbers {
+		if c := compareNumbers(v.numbers[i], w.numbers[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A release is greater than its pre-releases
+	switch {
+	case len(v.pre) == 0 && len(w.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(w.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(w.pre); i++ {
+		a, b := v.pre[i], w.pre[i]
+		an, bn := isNumber(a), isNumber(b)
+		var c int
+		switch {
+		case an && bn:
+			c = compareNumbers(a, b)
+		case an:
+			// Numeric identifiers are lower than alphanumeric ones
+			c = -1
+		case bn:
+			c = 1
+		default:
+			c = strings.Compare(a, b)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return sign(len(v.pre) - len(w.pre))
+}
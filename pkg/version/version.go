@@ -0,0 +1,202 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version parses and compares the versions of packages following
+// the rules of their ecosystem: semantic versioning for npm and Cargo,
+// PEP 440 for Python, the ordering of Maven and Go module versions,
+// including pseudo-versions.
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scheme is a versioning scheme.
+type Scheme string
+
+const (
+	// SchemeSemver is semantic versioning 2.0.0, used by npm and Cargo.
+	// Parsing is lenient: a "v" or "=" prefix is ignored, and missing
+	// minor and patch numbers are zero.
+	SchemeSemver Scheme = "semver"
+	// SchemeGo is the versions of Go modules: semantic versions with a
+	// "v" prefix, of which pseudo-versions are pre-releases. The
+	// "+incompatible" suffix is ignored when comparing.
+	SchemeGo Scheme = "go"
+	// SchemePEP440 is the versions of Python packages.
+	SchemePEP440 Scheme = "pep440"
+	// SchemeMaven is the versions of Maven artifacts, ordered like Maven's
+	// ComparableVersion.
+	SchemeMaven Scheme = "maven"
+	// SchemeGeneric orders versions by their numeric and alphabetic
+	// segments. It is used for the ecosystems without a known scheme.
+	SchemeGeneric Scheme = "generic"
+)
+
+// SchemeOf returns the versioning scheme of an ecosystem, as named by the
+// parser package.
+func SchemeOf(ecosystem string) Scheme {
+	switch strings.ToLower(ecosystem) {
+	case "npm", "crates", "cargo":
+		return SchemeSemver
+	case "go", "golang":
+		return SchemeGo
+	case "pypi":
+		return SchemePEP440
+	case "maven", "gradle":
+		return SchemeMaven
+	default:
+		return SchemeGeneric
+	}
+}
+
+// Version is a version parsed following a scheme.
+type Version struct {
+	scheme Scheme
+	raw    string
+	key    comparer
+}
+
+// comparer is the parsed form of a version in a scheme.
+type comparer interface {
+	// compare compares with a version of the same scheme.
+	compare(other comparer) int
+}
+
+// Parse parses a version following a scheme.
+func Parse(scheme Scheme, s string) (Version, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Version{}, fmt.Errorf("empty version")
+	}
+
+	var key comparer
+	var err error
+	switch scheme {
+	case SchemeSemver:
+		key, err = parseSemver(s, false)
+	case SchemeGo:
+		key, err = parseSemver(s, true)
+	case SchemePEP440:
+		key, err = parsePEP440(s)
+	case SchemeMaven:
+		key = parseMaven(s)
+	case SchemeGeneric:
+		key = parseGeneric(s)
+	default:
+		return Version{}, fmt.Errorf("unknown version scheme %q", scheme)
+	}
+	if err != nil {
+		return Version{}, err
+	}
+	return Version{scheme: scheme, raw: s, key: key}, nil
+}
+
+// ParseFor parses a version of a package of an ecosystem.
+func ParseFor(ecosystem, s string) (Version, error) {
+	return Parse(SchemeOf(ecosystem), s)
+}
+
+// MustParse is like Parse but panics when the version is invalid. It is
+// meant for versions known to be valid, eg in tests.
+func MustParse(scheme Scheme, s string) Version {
+	v, err := Parse(scheme, s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String returns the version as it was parsed.
+func (v Version) String() string {
+	return v.raw
+}
+
+// Scheme returns the scheme the version was parsed with.
+func (v Version) Scheme() Scheme {
+	return v.scheme
+}
+
+// IsZero returns true for the zero Version, which is not a valid version.
+func (v Version) IsZero() bool {
+	return v.key == nil
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or greater
+// than w. Versions of different schemes are compared as generic versions.
+func (v Version) Compare(w Version) int {
+	switch {
+	case v.key == nil && w.key == nil:
+		return 0
+	case v.key == nil:
+		return -1
+	case w.key == nil:
+		return 1
+	case v.scheme != w.scheme:
+		return parseGeneric(v.raw).compare(parseGeneric(w.raw))
+	default:
+		return v.key.compare(w.key)
+	}
+}
+
+// Compare parses and compares two versions of a package of an ecosystem.
+// It returns -1, 0 or 1 when a is lower than, equal to or greater than b.
+func Compare(ecosystem, a, b string) (int, error) {
+	scheme := SchemeOf(ecosystem)
+	va, err := Parse(scheme, a)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %q: %w", a, err)
+	}
+	vb, err := Parse(scheme, b)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %q: %w", b, err)
+	}
+	return va.Compare(vb), nil
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareNumbers compares two strings of decimal digits without
+// overflowing.
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return sign(len(a) - len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
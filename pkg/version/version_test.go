@@ -0,0 +1,123 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireOrdered checks that each version is lower than the next one.
+func requireOrdered(t *testing.T, scheme Scheme, versions ...string) {
+	t.Helper()
+	for i := 0; i+1 < len(versions); i++ {
+		a, b := MustParse(scheme, versions[i]), MustParse(scheme, versions[i+1])
+		require.Equal(t, -1, a.Compare(b), "%s < %s", a, b)
+		require.Equal(t, 1, b.Compare(a), "%s > %s", b, a)
+	}
+}
+
+func requireEqual(t *testing.T, scheme Scheme, a, b string) {
+	t.Helper()
+	require.Equal(t, 0, MustParse(scheme, a).Compare(MustParse(scheme, b)), "%s == %s", a, b)
+}
+
+func TestSemver(t *testing.T) {
+	t.Parallel()
+
+	requireOrdered(t, SchemeSemver,
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.2", "1.10.0", "18446744073709551616.0.0")
+	requireEqual(t, SchemeSemver, "v1.2.3", "=1.2.3")
+	requireEqual(t, SchemeSemver, "1.2.3+build.5", "1.2.3")
+	requireEqual(t, SchemeSemver, "1", "1.0.0")
+
+	for _, invalid := range []string{"1.2.3.4", "1.x", "1.0.0-", "latest", ""} {
+		_, err := Parse(SchemeSemver, invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestGo(t *testing.T) {
+	t.Parallel()
+
+	requireOrdered(t, SchemeGo,
+		"v0.0.0-20190101000000-aaaaaaaaaaaa",
+		"v0.0.0-20191109021931-daa7c04131f5",
+		"v0.1.0",
+		"v1.2.3",
+		"v1.2.4-0.20191109021931-daa7c04131f5",
+		"v1.2.4",
+		"v2.0.0+incompatible",
+		"v2.1.0")
+	requireEqual(t, SchemeGo, "v2.0.0+incompatible", "v2.0.0")
+
+	_, err := Parse(SchemeGo, "v1.2")
+	require.Error(t, err)
+}
+
+func TestPEP440(t *testing.T) {
+	t.Parallel()
+
+	requireOrdered(t, SchemePEP440,
+		"1.0.dev456", "1.0a1", "1.0a2.dev456", "1.0a12.dev456", "1.0a12",
+		"1.0b1.dev456", "1.0b2", "1.0b2.post345.dev456", "1.0b2.post345",
+		"1.0rc1.dev456", "1.0rc1", "1.0", "1.0+abc.5", "1.0+abc.7", "1.0+5",
+		"1.0.post456.dev34", "1.0.post456", "1.0.15", "1.1.dev1", "1!0.1")
+	requireEqual(t, SchemePEP440, "1.0", "1.0.0")
+	requireEqual(t, SchemePEP440, "1.0-1", "1.0.post1")
+	requireEqual(t, SchemePEP440, "1.0alpha1", "1.0a1")
+	requireEqual(t, SchemePEP440, "1.0c1", "1.0rc1")
+	requireEqual(t, SchemePEP440, "v2.0.PRE1", "2.0rc1")
+
+	_, err := Parse(SchemePEP440, "1.0-foo")
+	require.Error(t, err)
+}
+
+func TestMaven(t *testing.T) {
+	t.Parallel()
+
+	requireOrdered(t, SchemeMaven,
+		"1-alpha-1", "1-alpha-2", "1-beta", "1-milestone-1", "1-rc", "1-snapshot",
+		"1", "1-sp", "1-abc", "1-1", "1.0.1", "1.1", "1.10", "2.0-rc1", "2.0")
+	requireEqual(t, SchemeMaven, "1", "1.0.0")
+	requireEqual(t, SchemeMaven, "1-ga", "1.0")
+	requireEqual(t, SchemeMaven, "1.0-final", "1")
+	requireEqual(t, SchemeMaven, "1a1", "1-alpha-1")
+	requireEqual(t, SchemeMaven, "1cr", "1rc")
+	requireEqual(t, SchemeMaven, "1.0-1", "1-1")
+	requireEqual(t, SchemeMaven, "1.0.RELEASE", "1")
+}
+
+func TestGeneric(t *testing.T) {
+	t.Parallel()
+
+	requireOrdered(t, SchemeGeneric, "1.0.0-rc.1", "1.0.0", "1.0.1", "1.2", "1.10", "2:1.0")
+	requireEqual(t, SchemeGeneric, "1.2", "1.2.0")
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ecosystem, a, b string
+		want            int
+	}{
+		{"npm", "4.17.20", "4.17.21", -1},
+		{"crates", "1.0.0", "1.0.0-rc.1", 1},
+		{"go", "v1.2.3", "v1.2.3", 0},
+		{"pypi", "2.0", "2.0rc1", 1},
+		{"maven", "5.3.21", "5.3.3", 1},
+		{"deb", "1.2", "1.10", -1},
+	} {
+		got, err := Compare(tc.ecosystem, tc.a, tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got, "%s: %s <=> %s", tc.ecosystem, tc.a, tc.b)
+	}
+
+	_, err := Compare("npm", "^1.0.0", "1.0.0")
+	require.Error(t, err)
+
+	// Versions of different schemes fall back to the generic ordering
+	require.Equal(t, -1, MustParse(SchemeSemver, "1.0.0").Compare(MustParse(SchemeMaven, "1.1")))
+	require.True(t, Version{}.IsZero())
+}
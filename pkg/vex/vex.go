@@ -0,0 +1,188 @@
+//
+// Copyright 2024 Stacklok, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vex exports the malicious packages found by Trusty as
+// vulnerability exchange documents, in the OpenVEX and CycloneDX VEX
+// formats.
+package vex
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/cyclonedx"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	"github.com/stacklok/trusty-sdk-go/pkg/sbom"
+)
+
+const (
+	// OpenVEXContext is the version of the OpenVEX specification the
+	// documents conform to.
+	OpenVEXContext = "https://openvex.dev/ns/v0.2.0"
+
+	// StatusAffected is the OpenVEX status of the products affected by a
+	// vulnerability.
+	StatusAffected = "affected"
+
+	// defaultAuthor is the author of the documents when none is set.
+	defaultAuthor = "Trusty"
+
+	// actionStatement is the remediation given for malicious packages.
+	actionStatement = "Remove the package and rotate any credential exposed to the environments it was installed in"
+
+	// cycloneDXSpecVersion is the version of the CycloneDX VEX documents.
+	cycloneDXSpecVersion = "1.5"
+)
+
+// Options configures the documents generated.
+type Options struct {
+	// ID identifies the document. When empty, a random URN is used.
+	ID string
+
+	// Author is the author of the document. Defaults to "Trusty".
+	Author string
+
+	// Timestamp is the creation time of the document. When zero, the
+	// current time is used.
+	Timestamp time.Time
+}
+
+func (o *Options) defaults() {
+	if o.ID == "" {
+		o.ID = uuid.New().URN()
+	}
+	if o.Author == "" {
+		o.Author = defaultAuthor
+	}
+	if o.Timestamp.IsZero() {
+		o.Timestamp = time.Now()
+	}
+	o.Timestamp = o.Timestamp.UTC()
+}
+
+// OpenVEXDocument is an OpenVEX document.
+type OpenVEXDocument struct {
+	Context    string      `json:"@context"`
+	ID         string      `json:"@id"`
+	Author     string      `json:"author"`
+	Timestamp  time.Time   `json:"timestamp"`
+	Version    int         `json:"version"`
+	Tooling    string      `json:"tooling,omitempty"`
+	Statements []Statement `json:"statements"`
+}
+
+// Statement asserts the status of products regarding a vulnerability.
+type Statement struct {
+	Vulnerability   Vulnerability `json:"vulnerability"`
+	Products        []Product     `json:"products"`
+	Status          string        `json:"status"`
+	ActionStatement string        `json:"action_statement,omitempty"`
+	Timestamp       *time.Time    `json:"timestamp,omitempty"`
+	LastUpdated     *time.Time    `json:"last_updated,omitempty"`
+}
+
+// Vulnerability identifies the vulnerability a statement is about.
+type Vulnerability struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Product is a product a statement applies to, identified by its
+// package URL.
+type Product struct {
+	ID string `json:"@id"`
+}
+
+// OpenVEX returns an OpenVEX document with a statement for each
+// malicious package, identified by its package URL. Packages that are
+// not malicious, or whose ecosystem has no package URL type, are skipped.
+func OpenVEX(packages []result.Package, opts Options) *OpenVEXDocument {
+	opts.defaults()
+	doc := &OpenVEXDocument{
+		Context:    OpenVEXContext,
+		ID:         opts.ID,
+		Author:     opts.Author,
+		Timestamp:  opts.Timestamp,
+		Version:    1,
+		Tooling:    defaultAuthor,
+		Statements: []Statement{},
+	}
+
+	for _, p := range maliciousPackages(packages) {
+		mal := p.Malicious()
+		v := sbom.MaliciousVulnerability(&p, "")
+		doc.Statements = append(doc.Statements, Statement{
+			Vulnerability:   Vulnerability{Name: v.ID, Description: v.Description},
+			Products:        []Product{{ID: p.Dependency.Purl()}},
+			Status:          StatusAffected,
+			ActionStatement: actionStatement,
+			Timestamp:       mal.Published,
+			LastUpdated:     mal.Modified,
+		})
+	}
+	return doc
+}
+
+// CycloneDX returns a CycloneDX VEX document listing the malicious
+// packages as components, each affected by an exploitable vulnerability.
+// The components are referenced by their package URL.
+func CycloneDX(packages []result.Package, opts Options) *cyclonedx.BOM {
+	opts.defaults()
+	bom := &cyclonedx.BOM{
+		BOMFormat:    cyclonedx.BOMFormat,
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: opts.ID,
+		Version:      1,
+		Metadata: &cyclonedx.Metadata{
+			Timestamp: opts.Timestamp.Format(time.RFC3339),
+		},
+	}
+
+	for _, p := range maliciousPackages(packages) {
+		purl := p.Dependency.Purl()
+		bom.Components = append(bom.Components, cyclonedx.Component{
+			Type:    "library",
+			BOMRef:  purl,
+			Name:    p.Dependency.Name,
+			Version: p.Dependency.Version,
+			PURL:    purl,
+		})
+		v := sbom.MaliciousVulnerability(&p, purl)
+		v.Recommendation = actionStatement
+		v.Analysis = &cyclonedx.Analysis{
+			State:  "exploitable",
+			Detail: v.Description,
+		}
+		bom.Vulnerabilities = append(bom.Vulnerabilities, v)
+	}
+	return bom
+}
+
+// maliciousPackages returns the malicious packages with a package URL,
+// without duplicates.
+func maliciousPackages(packages []result.Package) []result.Package {
+	var res []result.Package
+	seen := map[string]bool{}
+	for _, p := range packages {
+		purl := p.Dependency.Purl()
+		if !p.IsMalicious() || purl == "" || seen[purl] {
+			continue
+		}
+		seen[purl] = true
+		res = append(res, p)
+	}
+	return res
+}
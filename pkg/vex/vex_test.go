@@ -0,0 +1,93 @@
+package vex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stacklok/trusty-sdk-go/pkg/cyclonedx"
+	"github.com/stacklok/trusty-sdk-go/pkg/parser"
+	"github.com/stacklok/trusty-sdk-go/pkg/result"
+	v1types "github.com/stacklok/trusty-sdk-go/pkg/v1/types"
+	v2types "github.com/stacklok/trusty-sdk-go/pkg/v2/types"
+)
+
+var (
+	published = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	timestamp = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	packages = []result.Package{
+		{
+			Dependency: parser.Dependency{Name: "@evil/pkg", Ecosystem: "npm", Version: "1.0.0"},
+			Metadata: &v2types.TrustyPackageData{Malicious: &v2types.PackageMaliciousPayload{
+				Summary: "Exfiltrates tokens", Published: &published,
+			}},
+		},
+		{
+			Dependency: parser.Dependency{Name: "@evil/pkg", Ecosystem: "npm", Version: "1.0.0"},
+			Summary:    &v2types.PackageSummaryAnnotation{Description: v2types.SummaryDescription{Malicious: true}},
+		},
+		{
+			Dependency: parser.Dependency{Name: "reqeusts", Ecosystem: "pypi", Version: "2.0.0"},
+			Report: &v1types.Reply{PackageData: v1types.PackageData{Malicious: &v1types.MaliciousData{
+				Summary: "Typosquat of requests",
+			}}},
+		},
+		{
+			Dependency: parser.Dependency{Name: "express", Ecosystem: "npm", Version: "4.18.2"},
+			Summary:    &v2types.PackageSummaryAnnotation{},
+		},
+	}
+)
+
+func TestOpenVEX(t *testing.T) {
+	t.Parallel()
+
+	doc := OpenVEX(packages, Options{ID: "urn:test", Timestamp: timestamp})
+	require.Equal(t, &OpenVEXDocument{
+		Context:   OpenVEXContext,
+		ID:        "urn:test",
+		Author:    "Trusty",
+		Timestamp: timestamp,
+		Version:   1,
+		Tooling:   "Trusty",
+		Statements: []Statement{
+			{
+				Vulnerability:   Vulnerability{Name: "TRUSTY-MALICIOUS-@evil/pkg@1.0.0", Description: "Exfiltrates tokens"},
+				Products:        []Product{{ID: "pkg:npm/%40evil/pkg@1.0.0"}},
+				Status:          StatusAffected,
+				ActionStatement: actionStatement,
+				Timestamp:       &published,
+			},
+			{
+				Vulnerability:   Vulnerability{Name: "TRUSTY-MALICIOUS-reqeusts@2.0.0", Description: "Typosquat of requests"},
+				Products:        []Product{{ID: "pkg:pypi/reqeusts@2.0.0"}},
+				Status:          StatusAffected,
+				ActionStatement: actionStatement,
+			},
+		},
+	}, doc)
+
+	doc = OpenVEX(nil, Options{})
+	require.NotEmpty(t, doc.ID)
+	require.Empty(t, doc.Statements)
+}
+
+func TestCycloneDX(t *testing.T) {
+	t.Parallel()
+
+	bom := CycloneDX(packages, Options{ID: "urn:uuid:test", Timestamp: timestamp})
+	require.Equal(t, "2024-06-01T12:00:00Z", bom.Metadata.Timestamp)
+	require.Equal(t, "urn:uuid:test", bom.SerialNumber)
+	require.Equal(t, []cyclonedx.Component{
+		{Type: "library", BOMRef: "pkg:npm/%40evil/pkg@1.0.0", Name: "@evil/pkg", Version: "1.0.0", PURL: "pkg:npm/%40evil/pkg@1.0.0"},
+		{Type: "library", BOMRef: "pkg:pypi/reqeusts@2.0.0", Name: "reqeusts", Version: "2.0.0", PURL: "pkg:pypi/reqeusts@2.0.0"},
+	}, bom.Components)
+	require.Len(t, bom.Vulnerabilities, 2)
+	require.Equal(t, "TRUSTY-MALICIOUS-@evil/pkg@1.0.0", bom.Vulnerabilities[0].ID)
+	require.Equal(t, "2024-05-01T00:00:00Z", bom.Vulnerabilities[0].Published)
+	require.Equal(t, []cyclonedx.Affects{{Ref: "pkg:npm/%40evil/pkg@1.0.0"}}, bom.Vulnerabilities[0].Affects)
+	require.Equal(t, &cyclonedx.Analysis{State: "exploitable", Detail: "Exfiltrates tokens"}, bom.Vulnerabilities[0].Analysis)
+	require.Equal(t, actionStatement, bom.Vulnerabilities[1].Recommendation)
+}